@@ -0,0 +1,84 @@
+// evaluate 命令行回测历史报告最终决策的准确率，结果与 GET /api/v1/evaluation 一致
+//
+// 用法（在 backend/go-api 目录下运行，读取与服务相同的 .env 配置）:
+//
+//	go run ./cmd/evaluate -from 2026-06-01 -to 2026-09-30 -horizons 5,20,60
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"stock-analysis-api/backend/go-api/config"
+	"stock-analysis-api/backend/go-api/internal/client"
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/evaluation"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"time"
+)
+
+func main() {
+	code := flag.String("code", "", "只回测指定股票代码")
+	from := flag.String("from", "", "报告开始日期 YYYY-MM-DD（含）")
+	to := flag.String("to", "", "报告结束日期 YYYY-MM-DD（含）")
+	horizons := flag.String("horizons", "", "逗号分隔的前瞻周期（交易日），默认 5,20,60")
+	holdBand := flag.Float64("hold-band", evaluation.DefaultHoldBand, "持有建议判定区间（±%）")
+	details := flag.Bool("details", false, "输出每条建议的明细（仅 -json）")
+	asJSON := flag.Bool("json", false, "以JSON输出")
+	flag.Parse()
+
+	config.Load()
+
+	opts := evaluation.Options{
+		Filter:   store.ReportFilter{Code: *code},
+		HoldBand: *holdBand,
+		Details:  *details,
+	}
+	var err error
+	if opts.Horizons, err = evaluation.ParseHorizons(*horizons); err != nil {
+		log.Fatal(err)
+	}
+	if *from != "" {
+		if opts.Filter.From, err = time.ParseInLocation("2006-01-02", *from, time.Local); err != nil {
+			log.Fatal("from 格式应为 YYYY-MM-DD")
+		}
+	}
+	if *to != "" {
+		day, err := time.ParseInLocation("2006-01-02", *to, time.Local)
+		if err != nil {
+			log.Fatal("to 格式应为 YYYY-MM-DD")
+		}
+		opts.Filter.To = day.AddDate(0, 0, 1)
+	}
+
+	dataProvider, err := datasource.New(config.AppConfig.MarketDataProviders, client.NewPythonClient(), config.AppConfig.FixtureDir)
+	if err != nil {
+		log.Fatalf("初始化行情数据源失败: %v", err)
+	}
+	db, err := store.Open(config.AppConfig.DatabasePath)
+	if err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, err := evaluation.Run(ctx, store.NewReportStore(db), dataProvider, opts)
+	if err != nil {
+		log.Fatalf("回测失败: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	fmt.Print(result.Text())
+}
@@ -1,45 +1,119 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"stock-analysis-api/backend/go-api/config"
+	"stock-analysis-api/backend/go-api/internal/alert"
 	"stock-analysis-api/backend/go-api/internal/client"
+	"stock-analysis-api/backend/go-api/internal/datasource"
 	"stock-analysis-api/backend/go-api/internal/handler"
 	"stock-analysis-api/backend/go-api/internal/llm"
+	"stock-analysis-api/backend/go-api/internal/market"
+	"stock-analysis-api/backend/go-api/internal/pipeline"
+	"stock-analysis-api/backend/go-api/internal/resilience"
+	"stock-analysis-api/backend/go-api/internal/screener"
+	"stock-analysis-api/backend/go-api/internal/securities"
 	"stock-analysis-api/backend/go-api/internal/service"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
 	config.Load()
+	if err := market.SetHolidays(config.AppConfig.MarketHolidays); err != nil {
+		log.Fatalf("MARKET_HOLIDAYS 无效: %v", err)
+	}
 
 	r := gin.Default()
 
-	// 初始化Python客户端
+	// 初始化Python客户端与行情数据源
 	pythonClient := client.NewPythonClient()
+	dataProvider, err := datasource.New(config.AppConfig.MarketDataProviders, pythonClient, config.AppConfig.FixtureDir)
+	if err != nil {
+		log.Fatalf("初始化行情数据源失败: %v", err)
+	}
+	log.Printf("行情数据源: %s", dataProvider.Name())
+
+	// 加载分析流水线
+	pipelineDef, err := pipeline.Load(config.AppConfig.PipelineFile)
+	if err != nil {
+		log.Fatalf("加载分析流水线失败: %v", err)
+	}
+	plan, err := pipelineDef.Compile()
+	if err != nil {
+		log.Fatalf("分析流水线无效: %v", err)
+	}
+	log.Printf("使用分析流水线: %s (%d 个步骤)", pipelineDef.Name, len(plan.Steps))
+
+	// 根据配置初始化LLM客户端，并按步骤配置路由
+	llmClient, err := buildLLMRouter(plan)
+	if err != nil {
+		log.Fatalf("初始化LLM失败: %v", err)
+	}
+
+	llm.SetPrices(config.AppConfig.LLMPrices)
+	usageLedger := service.NewUsageLedger(config.AppConfig.UsageLogPath)
 
-	// 根据配置初始化LLM客户端
-	var llmClient llm.LLMClient
-	switch config.AppConfig.LLMProvider {
-	case "claude":
-		llmClient = llm.NewClaudeClient()
-		log.Println("使用 Claude LLM")
-	case "glm":
-		llmClient = llm.NewGLMClient()
-		log.Println("使用 GLM (智谱AI) LLM")
-	case "deepseek":
-		llmClient = llm.NewDeepSeekClient()
-		log.Println("使用 DeepSeek LLM")
-	default:
-		log.Fatalf("不支持的LLM提供商: %s", config.AppConfig.LLMProvider)
+	// 初始化数据库
+	db, err := store.Open(config.AppConfig.DatabasePath)
+	if err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
 	}
+	defer db.Close()
+	reportStore := store.NewReportStore(db)
+
+	// 证券主数据：先加载本地缓存，后台定期从数据源刷新
+	securityMaster := securities.NewMaster(dataProvider, store.NewSecurityStore(db),
+		config.AppConfig.SecurityRefreshInterval, config.AppConfig.SecurityFetchTimeout)
+	securityMaster.Start(context.Background())
 
 	// 初始化服务
-	orchestrator := service.NewAnalysisOrchestrator(pythonClient, llmClient)
+	resultCache := service.NewResultCache(reportStore, config.AppConfig.ResultCacheTTL, config.AppConfig.ResultReplayDelay)
+	orchestrator := service.NewAnalysisOrchestrator(dataProvider, llmClient, plan, usageLedger, reportStore, resultCache)
+
+	runStore := service.NewRunStore(config.AppConfig.SSERetention, config.AppConfig.SSEResumeGrace)
+
+	// 自选股与收盘后批量分析
+	watchlistStore := store.NewWatchlistStore(db)
+	batchStore := store.NewBatchStore(db)
+	if config.AppConfig.BatchAnalysisTime != "off" {
+		scheduler, err := service.NewBatchScheduler(orchestrator, watchlistStore, batchStore, reportStore,
+			config.AppConfig.BatchAnalysisTime, config.AppConfig.BatchConcurrency, config.AppConfig.BatchStockTimeout)
+		if err != nil {
+			log.Fatalf("初始化批量分析失败: %v", err)
+		}
+		scheduler.Start(context.Background())
+	}
+
+	// 提醒规则定时检查与 Webhook 推送
+	alertStore := store.NewAlertStore(db)
+	webhookStore := store.NewWebhookStore(db)
+	if config.AppConfig.AlertCheckInterval > 0 {
+		dispatcher := alert.NewDispatcher(webhookStore, config.AppConfig.AlertWebhookTimeout,
+			config.AppConfig.AlertWebhookMaxAttempts, config.AppConfig.AlertWebhookBackoff, config.AppConfig.AlertWebhookAllowHosts)
+		dispatcher.Start(context.Background())
+		alert.NewEngine(dataProvider, alertStore, reportStore, dispatcher, config.AppConfig.AlertCheckInterval).
+			Start(context.Background())
+	}
+
+	// 条件选股：全市场数据先加载本地缓存，后台定期从数据源刷新
+	stockScreener := screener.NewScreener(dataProvider, store.NewUniverseStore(db), securityMaster,
+		config.AppConfig.ScreenRefreshInterval, config.AppConfig.ScreenFetchTimeout)
+	stockScreener.Start(context.Background())
 
 	// 初始化Handler
-	analyzeHandler := handler.NewAnalyzeHandler(orchestrator)
+	analyzeHandler := handler.NewAnalyzeHandler(orchestrator, runStore, securityMaster)
+	reportHandler := handler.NewReportHandler(reportStore)
+	stockHandler := handler.NewStockHandler(securityMaster)
+	watchlistHandler := handler.NewWatchlistHandler(watchlistStore, batchStore, securityMaster, config.AppConfig.WatchlistMaxStocks)
+	alertHandler := handler.NewAlertHandler(alertStore, webhookStore, securityMaster, config.AppConfig.AlertMaxRules,
+		config.AppConfig.AlertWebhookAllowHosts)
+	evaluationHandler := handler.NewEvaluationHandler(reportStore, dataProvider)
+	screenHandler := handler.NewScreenHandler(stockScreener, orchestrator, runStore)
 
 	// 路由
 	r.GET("/health", func(c *gin.Context) {
@@ -49,6 +123,29 @@ func main() {
 	api := r.Group("/api/v1")
 	{
 		api.POST("/analyze", analyzeHandler.StreamAnalyze)
+		api.POST("/compare", analyzeHandler.StreamCompare)
+		api.POST("/portfolio/analyze", analyzeHandler.StreamPortfolio)
+		api.GET("/analyze/:runId/events", analyzeHandler.ResumeEvents)
+		api.GET("/reports", reportHandler.ListReports)
+		api.GET("/reports/:id", reportHandler.GetReport)
+		api.GET("/evaluation", evaluationHandler.Evaluate)
+		api.GET("/stocks/search", stockHandler.SearchStocks)
+		api.POST("/screen", screenHandler.Screen)
+		api.GET("/watchlists", watchlistHandler.ListWatchlists)
+		api.POST("/watchlists", watchlistHandler.CreateWatchlist)
+		api.GET("/watchlists/:id", watchlistHandler.GetWatchlist)
+		api.PUT("/watchlists/:id", watchlistHandler.UpdateWatchlist)
+		api.DELETE("/watchlists/:id", watchlistHandler.DeleteWatchlist)
+		api.POST("/watchlists/:id/stocks", watchlistHandler.AddStock)
+		api.DELETE("/watchlists/:id/stocks/:code", watchlistHandler.RemoveStock)
+		api.GET("/batch-runs", watchlistHandler.ListBatchRuns)
+		api.GET("/alerts/rules", alertHandler.ListRules)
+		api.POST("/alerts/rules", alertHandler.CreateRule)
+		api.DELETE("/alerts/rules/:id", alertHandler.DeleteRule)
+		api.GET("/alerts/events", alertHandler.ListEvents)
+		api.GET("/alerts/webhooks", alertHandler.ListWebhooks)
+		api.POST("/alerts/webhooks", alertHandler.CreateWebhook)
+		api.DELETE("/alerts/webhooks/:id", alertHandler.DeleteWebhook)
 	}
 
 	addr := ":" + config.AppConfig.Port
@@ -57,3 +154,68 @@ func main() {
 		log.Fatal("启动失败:", err)
 	}
 }
+
+// buildLLMRouter 创建默认LLM客户端，并为流水线中声明了 llm 配置的步骤创建专用客户端。
+// 每个客户端都包装为FallbackClient：瞬时错误重试，失败后依次切换到 LLM_FALLBACK_PROVIDERS。
+// 提供商的API Key等配置在此由 llm.NewClient 校验，config.Load 不做检查，使离线工具无需LLM凭据
+func buildLLMRouter(plan *pipeline.Plan) (*llm.Router, error) {
+	cfg := config.AppConfig
+	backoff := resilience.Backoff{Base: cfg.LLMRetryBackoff, Max: 10 * time.Second}
+
+	// 每个提供商共享一个熔断器
+	breakers := make(map[string]*resilience.CircuitBreaker)
+	breakerFor := func(provider string) *resilience.CircuitBreaker {
+		if b, ok := breakers[provider]; ok {
+			return b
+		}
+		b := resilience.NewCircuitBreaker(provider, cfg.LLMBreakerThreshold, cfg.LLMBreakerCooldown)
+		breakers[provider] = b
+		return b
+	}
+
+	fallbacks := make([]llm.FallbackTarget, 0, len(cfg.LLMFallbackProviders))
+	for _, provider := range cfg.LLMFallbackProviders {
+		client, err := llm.NewClient(provider, llm.ModelOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("备用LLM %s 配置无效: %w", provider, err)
+		}
+		fallbacks = append(fallbacks, llm.FallbackTarget{Name: provider, Client: client, Breaker: breakerFor(provider)})
+	}
+
+	withFallback := func(provider string, client llm.LLMClient) llm.LLMClient {
+		targets := []llm.FallbackTarget{{Name: provider, Client: client, Breaker: breakerFor(provider)}}
+		for _, fb := range fallbacks {
+			if fb.Name != provider {
+				targets = append(targets, fb)
+			}
+		}
+		return llm.NewFallbackClient(targets, cfg.LLMMaxRetries, backoff)
+	}
+
+	defaultProvider := cfg.LLMProvider
+	defaultClient, err := llm.NewClient(defaultProvider, llm.ModelOptions{})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("默认LLM: %s, 备用: %v", defaultProvider, cfg.LLMFallbackProviders)
+
+	router := llm.NewRouter(withFallback(defaultProvider, defaultClient))
+	for _, spec := range plan.Steps {
+		if spec.LLM == nil {
+			continue
+		}
+
+		provider := spec.LLM.Provider
+		if provider == "" {
+			provider = defaultProvider
+		}
+		client, err := llm.NewClient(provider, spec.LLM.ModelOptions)
+		if err != nil {
+			return nil, fmt.Errorf("步骤 %s 的LLM配置无效: %w", spec.Step, err)
+		}
+		router.Route(spec.Step, withFallback(provider, client))
+		log.Printf("步骤 %s 路由到 %s %s", spec.Step, provider, spec.LLM.Model)
+	}
+
+	return router, nil
+}
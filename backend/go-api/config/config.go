@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -10,7 +13,7 @@ import (
 type Config struct {
 	Port              string
 	PythonServiceURL  string
-	LLMProvider       string // "claude", "glm", or "deepseek"
+	LLMProvider       string // "claude", "glm", "deepseek", "openai", "ollama" or "llamacpp"
 	ClaudeAPIKey      string
 	GLMAPIKey         string
 	GLMBaseURL        string
@@ -18,6 +21,59 @@ type Config struct {
 	DeepSeekAPIKey    string
 	DeepSeekBaseURL   string
 	DeepSeekModel     string
+	OpenAIName        string            // OpenAI兼容供应商名称，如 Qwen、Moonshot、vLLM
+	OpenAIAPIKey      string
+	OpenAIBaseURL     string
+	OpenAIModel       string
+	OpenAIHeaders     map[string]string // 额外请求头，格式 "K1=V1,K2=V2"
+	OllamaBaseURL     string
+	OllamaModel       string
+	LlamaCppBaseURL   string
+	LlamaCppModel     string
+
+	LLMFallbackProviders []string      // 主提供商失败后依次尝试的备用提供商
+	LLMMaxRetries        int           // 单个提供商瞬时错误的最大重试次数
+	LLMRetryBackoff      time.Duration // 重试退避基准时长
+	LLMBreakerThreshold  int           // 连续失败多少次后熔断
+	LLMBreakerCooldown   time.Duration // 熔断后的冷却时长
+	LLMPrices            string        // 价格表覆盖，格式 "model=input:output,..."（元/百万token）
+	UsageLogPath         string        // 用量账本路径（JSON Lines），为空时不记录
+	DatabasePath         string        // SQLite数据库路径，保存分析报告
+	ResultCacheTTL       time.Duration // 交易时段内分析结果缓存有效期，0 表示关闭缓存
+	ResultReplayDelay    time.Duration // 回放缓存结果时每个片段的间隔，0 表示一次性推送
+
+	PythonTimeout          time.Duration // 单次调用Python服务的超时
+	PythonMaxRetries       int           // 5xx/超时的重试次数
+	PythonRetryBackoff     time.Duration // 重试退避基准时长
+	PythonBreakerThreshold int           // 连续失败多少次后熔断
+	PythonBreakerCooldown  time.Duration // 熔断后的冷却时长
+	PythonCacheTTL         time.Duration // 交易时段内股票数据缓存有效期，休市期间有效至下一交易时段开盘；0 表示关闭缓存
+
+	MarketDataProviders []string // 行情数据源，多个时按顺序组合并互相补齐缺失字段
+	FixtureDir          string   // fixture 数据源的数据目录
+
+	SecurityRefreshInterval time.Duration // 证券主数据（股票搜索）刷新间隔
+	SecurityFetchTimeout    time.Duration // 从数据源拉取证券列表的超时
+
+	ScreenRefreshInterval time.Duration // 选股用全市场数据的刷新间隔，0 表示不自动刷新
+	ScreenFetchTimeout    time.Duration // 从数据源拉取全市场数据的超时
+
+	WatchlistMaxStocks int           // 单个自选股列表的股票数量上限
+	BatchAnalysisTime  string        // 每个交易日自选股批量分析的开始时间（北京时间 HH:MM，不早于15:00收盘），"off" 关闭
+	BatchConcurrency   int           // 批量分析同时进行的股票数
+	BatchStockTimeout  time.Duration // 批量分析中单只股票的超时
+	MarketHolidays     []string      // 工作日中的休市日期（YYYY-MM-DD，法定节假日），影响交易日判断与缓存失效
+
+	AlertCheckInterval      time.Duration // 提醒规则的检查间隔，0 关闭提醒
+	AlertMaxRules           int           // 每个用户的提醒规则数量上限
+	AlertWebhookTimeout     time.Duration // 单次 Webhook 推送的超时
+	AlertWebhookMaxAttempts int           // Webhook 推送的最大尝试次数，超过后标记为失败
+	AlertWebhookBackoff     time.Duration // Webhook 推送失败后首次重试的等待时间，之后指数增长
+	AlertWebhookAllowHosts  []string      // 允许推送到本机/内网地址的 Webhook 主机名白名单，默认全部拒绝
+
+	PipelineFile      string        // 分析流水线YAML定义，为空时使用内置流水线
+	SSERetention      time.Duration // 分析结束后事件保留时长，用于断线重连回放
+	SSEResumeGrace    time.Duration // 运行无客户端订阅（创建后未订阅或全部断开）的宽限期，超时则取消分析
 }
 
 var AppConfig *Config
@@ -41,24 +97,59 @@ func Load() {
 		DeepSeekAPIKey:    getEnv("DEEPSEEK_API_KEY", ""),
 		DeepSeekBaseURL:   getEnv("DEEPSEEK_BASE_URL", "https://api.deepseek.com/v1"),
 		DeepSeekModel:     getEnv("DEEPSEEK_MODEL", "deepseek-chat"),
-	}
+		OpenAIName:        getEnv("OPENAI_PROVIDER_NAME", "OpenAI兼容"),
+		OpenAIAPIKey:      getEnv("OPENAI_API_KEY", ""),
+		OpenAIBaseURL:     getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		OpenAIModel:       getEnv("OPENAI_MODEL", ""),
+		OpenAIHeaders:     parseHeaders(getEnv("OPENAI_EXTRA_HEADERS", "")),
+		OllamaBaseURL:     getEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
+		OllamaModel:       getEnv("OLLAMA_MODEL", "qwen2.5:7b"),
+		LlamaCppBaseURL:   getEnv("LLAMACPP_BASE_URL", "http://localhost:8081"), // 避开本服务默认的8080端口
+		LlamaCppModel:     getEnv("LLAMACPP_MODEL", "llamacpp"),
 
-	// 验证LLM配置
-	switch llmProvider {
-	case "claude":
-		if AppConfig.ClaudeAPIKey == "" {
-			log.Fatal("CLAUDE_API_KEY未配置")
-		}
-	case "glm":
-		if AppConfig.GLMAPIKey == "" {
-			log.Fatal("GLM_API_KEY未配置")
-		}
-	case "deepseek":
-		if AppConfig.DeepSeekAPIKey == "" {
-			log.Fatal("DEEPSEEK_API_KEY未配置")
-		}
-	default:
-		log.Fatalf("不支持的LLM提供商: %s (支持: claude, glm, deepseek)", llmProvider)
+		LLMFallbackProviders: splitList(getEnv("LLM_FALLBACK_PROVIDERS", "")),
+		LLMMaxRetries:        getEnvInt("LLM_MAX_RETRIES", 2),
+		LLMRetryBackoff:      getEnvDuration("LLM_RETRY_BACKOFF", 500*time.Millisecond),
+		LLMBreakerThreshold:  getEnvInt("LLM_BREAKER_THRESHOLD", 3),
+		LLMBreakerCooldown:   getEnvDuration("LLM_BREAKER_COOLDOWN", time.Minute),
+		LLMPrices:            getEnv("LLM_PRICES", ""),
+		UsageLogPath:         getEnv("USAGE_LOG_PATH", "./data/usage.jsonl"),
+		DatabasePath:         getEnv("DATABASE_PATH", "./data/stocks.db"),
+		ResultCacheTTL:       getEnvDuration("RESULT_CACHE_TTL", 30*time.Minute),
+		ResultReplayDelay:    getEnvDuration("RESULT_REPLAY_DELAY", 0),
+
+		PythonTimeout:          getEnvDuration("PYTHON_TIMEOUT", 30*time.Second),
+		PythonMaxRetries:       getEnvInt("PYTHON_MAX_RETRIES", 2),
+		PythonRetryBackoff:     getEnvDuration("PYTHON_RETRY_BACKOFF", 500*time.Millisecond),
+		PythonBreakerThreshold: getEnvInt("PYTHON_BREAKER_THRESHOLD", 5),
+		PythonBreakerCooldown:  getEnvDuration("PYTHON_BREAKER_COOLDOWN", 30*time.Second),
+		PythonCacheTTL:         getEnvDuration("PYTHON_CACHE_TTL", 2*time.Minute),
+
+		MarketDataProviders: splitList(getEnv("MARKET_DATA_PROVIDER", "python")),
+		FixtureDir:          getEnv("MARKET_DATA_FIXTURE_DIR", "./fixtures"),
+
+		SecurityRefreshInterval: getEnvDuration("SECURITY_REFRESH_INTERVAL", 24*time.Hour),
+		SecurityFetchTimeout:    getEnvDuration("SECURITY_FETCH_TIMEOUT", 5*time.Minute),
+
+		ScreenRefreshInterval: getEnvDuration("SCREEN_REFRESH_INTERVAL", 2*time.Hour),
+		ScreenFetchTimeout:    getEnvDuration("SCREEN_FETCH_TIMEOUT", 10*time.Minute),
+
+		WatchlistMaxStocks: getEnvInt("WATCHLIST_MAX_STOCKS", 50),
+		BatchAnalysisTime:  getEnv("BATCH_ANALYSIS_TIME", "15:30"),
+		MarketHolidays:     splitList(getEnv("MARKET_HOLIDAYS", "")),
+		BatchConcurrency:   getEnvInt("BATCH_CONCURRENCY", 3),
+		BatchStockTimeout:  getEnvDuration("BATCH_STOCK_TIMEOUT", 10*time.Minute),
+
+		AlertCheckInterval:      getEnvDuration("ALERT_CHECK_INTERVAL", time.Minute),
+		AlertMaxRules:           getEnvInt("ALERT_MAX_RULES", 100),
+		AlertWebhookTimeout:     getEnvDuration("ALERT_WEBHOOK_TIMEOUT", 10*time.Second),
+		AlertWebhookMaxAttempts: getEnvInt("ALERT_WEBHOOK_MAX_ATTEMPTS", 6),
+		AlertWebhookBackoff:     getEnvDuration("ALERT_WEBHOOK_BACKOFF", 30*time.Second),
+		AlertWebhookAllowHosts:  splitList(getEnv("ALERT_WEBHOOK_ALLOW_HOSTS", "")),
+
+		PipelineFile:      getEnv("PIPELINE_FILE", ""),
+		SSERetention:      getEnvDuration("SSE_RETENTION", 10*time.Minute),
+		SSEResumeGrace:    getEnvDuration("SSE_RESUME_GRACE", 30*time.Second),
 	}
 
 	log.Printf("配置加载完成 - Port: %s, Python: %s, LLM: %s", AppConfig.Port, AppConfig.PythonServiceURL, llmProvider)
@@ -70,3 +161,53 @@ func getEnv(key, defaultVal string) string {
 	}
 	return defaultVal
 }
+
+func getEnvInt(key string, defaultVal int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		log.Printf("%s 格式无效 (%s)，使用默认值 %d", key, val, defaultVal)
+		return defaultVal
+	}
+	return n
+}
+
+func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		log.Printf("%s 格式无效 (%s)，使用默认值 %v", key, val, defaultVal)
+		return defaultVal
+	}
+	return d
+}
+
+// parseHeaders 解析 "K1=V1,K2=V2" 格式的请求头配置
+func parseHeaders(val string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(val, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
+
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,143 @@
+package alert
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/market"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// fetchConcurrency 同时获取行情快照的股票数
+const fetchConcurrency = 4
+
+// Engine 定时检查所有用户的提醒规则。行情类规则只在交易时段检查（休市期间数据不变），
+// 操作建议变化规则每次都检查，以便及时发现收盘后批量分析产生的新报告
+type Engine struct {
+	provider   datasource.MarketDataProvider
+	alerts     *store.AlertStore
+	reports    *store.ReportStore
+	dispatcher *Dispatcher
+	interval   time.Duration
+}
+
+// NewEngine 创建提醒检查引擎
+func NewEngine(provider datasource.MarketDataProvider, alerts *store.AlertStore, reports *store.ReportStore, dispatcher *Dispatcher, interval time.Duration) *Engine {
+	return &Engine{provider: provider, alerts: alerts, reports: reports, dispatcher: dispatcher, interval: interval}
+}
+
+// Start 启动定时检查，ctx 取消时停止
+func (e *Engine) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// 单次检查不超过一个间隔，避免与下一次重叠
+			checkCtx, cancel := context.WithTimeout(ctx, e.interval)
+			if err := e.Check(checkCtx, time.Now()); err != nil {
+				log.Printf("检查提醒规则失败: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// Check 检查一次所有规则，触发的提醒写入数据库并交给推送器
+func (e *Engine) Check(ctx context.Context, now time.Time) error {
+	rules, err := e.alerts.AllRules()
+	if err != nil {
+		return err
+	}
+
+	trading := market.IsTradingTime(now)
+	var snapshotCodes, reportCodes []string
+	needSnapshot, needReport := make(map[string]bool), make(map[string]bool)
+	for _, r := range rules {
+		switch {
+		case !needsSnapshot(r.Kind):
+			if !needReport[r.Code] {
+				needReport[r.Code] = true
+				reportCodes = append(reportCodes, r.Code)
+			}
+		case trading && !needSnapshot[r.Code]:
+			needSnapshot[r.Code] = true
+			snapshotCodes = append(snapshotCodes, r.Code)
+		}
+	}
+
+	snapshots := e.fetchSnapshots(ctx, snapshotCodes)
+	reports := make(map[string]*store.Report, len(reportCodes))
+	for _, code := range reportCodes {
+		report, err := e.reports.LatestReport(code)
+		if err != nil {
+			return err
+		}
+		reports[code] = report
+	}
+
+	triggered := 0
+	for _, r := range rules {
+		out, ok := Evaluate(r, Input{Snapshot: snapshots[r.Code], Report: reports[r.Code]})
+		if !ok {
+			continue
+		}
+		if !out.Triggered {
+			if !reflect.DeepEqual(out.State, r.State) {
+				if err := e.alerts.SaveState(r.ID, out.State); err != nil {
+					log.Printf("保存提醒规则 %d 状态失败: %v", r.ID, err)
+				}
+			}
+			continue
+		}
+
+		name := r.Name
+		if name == "" && snapshots[r.Code] != nil {
+			name = snapshots[r.Code].Name
+		}
+		event := &store.AlertEvent{Code: r.Code, Name: name, Kind: r.Kind, Message: out.Message, Value: out.Value, TriggeredAt: now}
+		if err := e.alerts.Trigger(r, out.State, event); err != nil {
+			log.Printf("记录提醒规则 %d 的提醒失败: %v", r.ID, err)
+			continue
+		}
+		triggered++
+		log.Printf("触发提醒: rule=%d, user=%s, %s", r.ID, r.UserID, out.Message)
+	}
+	if triggered > 0 {
+		e.dispatcher.Notify()
+	}
+	return nil
+}
+
+// fetchSnapshots 并发获取行情快照，获取失败的股票本次跳过
+func (e *Engine) fetchSnapshots(ctx context.Context, codes []string) map[string]*model.PythonAnalysisResponse {
+	var mu sync.Mutex
+	snapshots := make(map[string]*model.PythonAnalysisResponse, len(codes))
+	g := new(errgroup.Group)
+	g.SetLimit(fetchConcurrency)
+	for _, code := range codes {
+		g.Go(func() error {
+			snapshot, err := datasource.Snapshot(ctx, e.provider, code)
+			if err != nil {
+				log.Printf("提醒检查获取 %s 行情失败: %v", code, err)
+				return nil
+			}
+			mu.Lock()
+			snapshots[code] = snapshot
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Wait()
+	return snapshots
+}
@@ -0,0 +1,99 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedDestination Webhook 指向回环、内网或链路本地等地址
+var ErrBlockedDestination = errors.New("不允许推送到本机或内网地址")
+
+// cgnat 运营商级 NAT 地址段 100.64.0.0/10，netip 未将其归入私有地址
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+// blockedAddr 判断地址是否为不允许推送的目标：回环、私有、链路本地（含云厂商元数据地址 169.254.169.254）、
+// 组播、未指定地址和 CGNAT 地址
+func blockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() ||
+		addr.IsUnspecified() || cgnat.Contains(addr)
+}
+
+// hostAllowed 主机名是否在白名单中（不区分大小写），白名单用于放行自建的内网通知服务
+func hostAllowed(host string, allowHosts []string) bool {
+	for _, h := range allowHosts {
+		if strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckURL 创建 Webhook 时的快速校验：必须是 http(s) URL，且不能直接写回环/内网 IP 或 localhost。
+// 域名解析结果在推送时由拨号器再次检查，防止 DNS 重绑定
+func CheckURL(raw string, allowHosts []string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("Webhook地址必须是 http(s) URL")
+	}
+	host := u.Hostname()
+	if hostAllowed(host, allowHosts) {
+		return nil
+	}
+	lower := strings.ToLower(strings.TrimSuffix(host, "."))
+	if lower == "localhost" || strings.HasSuffix(lower, ".localhost") {
+		return ErrBlockedDestination
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && blockedAddr(addr) {
+		return ErrBlockedDestination
+	}
+	return nil
+}
+
+// newHTTPClient 推送用的 HTTP 客户端：在建立连接时检查实际连接的 IP，不跟随重定向，不使用环境代理。
+// 白名单中的主机跳过地址检查
+func newHTTPClient(timeout time.Duration, allowHosts []string) *http.Client {
+	guarded := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("无法解析目标地址 %s: %w", address, err)
+			}
+			if blockedAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrBlockedDestination, address)
+			}
+			return nil
+		},
+	}
+	plain := &net.Dialer{Timeout: timeout}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(address)
+			if err == nil && hostAllowed(host, allowHosts) {
+				return plain.DialContext(ctx, network, address)
+			}
+			return guarded.DialContext(ctx, network, address)
+		},
+		TLSHandshakeTimeout: timeout,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// 重定向可能指向内网地址，3xx 按推送失败处理
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		allow   []string
+		wantErr bool
+	}{
+		{url: "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=x"},
+		{url: "http://example.com:8080/hook"},
+		{url: "ftp://example.com/hook", wantErr: true},
+		{url: "https:///hook", wantErr: true},
+		{url: "http://localhost:8000/api", wantErr: true},
+		{url: "http://api.localhost/", wantErr: true},
+		{url: "http://127.0.0.1/", wantErr: true},
+		{url: "http://10.1.2.3/", wantErr: true},
+		{url: "http://172.16.0.1/", wantErr: true},
+		{url: "http://192.168.1.1/", wantErr: true},
+		{url: "http://100.64.0.1/", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data/", wantErr: true},
+		{url: "http://[::1]/", wantErr: true},
+		{url: "http://[fe80::1]/", wantErr: true},
+		{url: "http://[::ffff:127.0.0.1]/", wantErr: true},
+		{url: "http://0.0.0.0/", wantErr: true},
+		{url: "http://10.1.2.3/", allow: []string{"10.1.2.3"}},
+		{url: "http://localhost:9000/", allow: []string{"LOCALHOST"}},
+	}
+
+	for _, tt := range tests {
+		err := CheckURL(tt.url, tt.allow)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckURL(%q) = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHTTPClientBlocksPrivateDestinations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+	if _, err := newHTTPClient(time.Second, nil).Do(req); !errors.Is(err, ErrBlockedDestination) {
+		t.Fatalf("loopback delivery err = %v, want ErrBlockedDestination", err)
+	}
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+	resp, err := newHTTPClient(time.Second, []string{"127.0.0.1"}).Do(req)
+	if err != nil {
+		t.Fatalf("allow-listed delivery failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestHTTPClientDoesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	resp, err := newHTTPClient(time.Second, []string{"127.0.0.1"}).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("status = %d, want 302 returned without following", resp.StatusCode)
+	}
+}
@@ -0,0 +1,198 @@
+// Package alert 按用户定义的规则定时检查行情、风险提示与分析报告，触发提醒并通过 Webhook 推送
+package alert
+
+import (
+	"fmt"
+	"math"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"strings"
+)
+
+// 规则类型
+const (
+	KindPriceAbove   = "price_above"   // 最新价向上突破阈值（元）
+	KindPriceBelow   = "price_below"   // 最新价向下跌破阈值（元）
+	KindChangePct    = "change_pct"    // 当日涨跌幅绝对值达到阈值（%）
+	KindPEAbove      = "pe_above"      // 市盈率(TTM)高于阈值
+	KindPEBelow      = "pe_below"      // 市盈率(TTM)低于阈值
+	KindNewRisk      = "new_risk"      // 出现新的风险提示
+	KindActionChange = "action_change" // 最新分析报告的操作建议发生变化，可限定变化前后的建议
+)
+
+// noRisk 数据源在未检测到风险时返回的占位提示
+const noRisk = "未检测到明显风险"
+
+// Validate 校验规则类型与参数
+func Validate(r *store.AlertRule) error {
+	switch r.Kind {
+	case KindPriceAbove, KindPriceBelow, KindChangePct:
+		if !(r.Threshold > 0) || math.IsInf(r.Threshold, 0) {
+			return fmt.Errorf("规则 %s 的阈值必须为正数", r.Kind)
+		}
+	case KindPEAbove, KindPEBelow:
+		if math.IsNaN(r.Threshold) || math.IsInf(r.Threshold, 0) {
+			return fmt.Errorf("规则 %s 的阈值无效", r.Kind)
+		}
+	case KindNewRisk:
+	case KindActionChange:
+		if r.From != "" && r.From == r.To {
+			return fmt.Errorf("变化前后的操作建议不能相同")
+		}
+	default:
+		return fmt.Errorf("不支持的提醒规则类型: %s", r.Kind)
+	}
+	if r.Kind != KindActionChange && (r.From != "" || r.To != "") {
+		return fmt.Errorf("只有 %s 规则可以指定 from/to", KindActionChange)
+	}
+	return nil
+}
+
+// needsSnapshot 规则是否依赖行情快照（价格、估值、风险提示），其余规则依赖分析报告
+func needsSnapshot(kind string) bool {
+	return kind != KindActionChange
+}
+
+// Input 一次检查所用的数据
+type Input struct {
+	Snapshot *model.PythonAnalysisResponse // 行情快照，规则不依赖或获取失败时为 nil
+	Report   *store.Report                 // 股票最近一次完成的分析报告，没有时为 nil
+}
+
+// Outcome 规则的检查结果
+type Outcome struct {
+	State     store.AlertState // 检查后应保存的状态
+	Triggered bool
+	Message   string
+	Value     *float64 // 触发时的指标值
+}
+
+// Evaluate 根据上一次检查的状态判断规则是否触发，数据缺失时返回 false 且不改变状态。
+// 阈值条件从不成立变为成立时触发；价格穿越、新风险提示与建议变化在首次检查时只记录基线
+func Evaluate(r *store.AlertRule, in Input) (Outcome, bool) {
+	switch r.Kind {
+	case KindPriceAbove, KindPriceBelow, KindChangePct, KindPEAbove, KindPEBelow:
+		if in.Snapshot == nil {
+			return Outcome{}, false
+		}
+		return evaluateThreshold(r, in.Snapshot)
+	case KindNewRisk:
+		if in.Snapshot == nil {
+			return Outcome{}, false
+		}
+		return evaluateRisks(r, in.Snapshot), true
+	case KindActionChange:
+		if in.Report == nil {
+			return Outcome{}, false
+		}
+		return evaluateAction(r, in.Report)
+	default:
+		return Outcome{}, false
+	}
+}
+
+func evaluateThreshold(r *store.AlertRule, s *model.PythonAnalysisResponse) (Outcome, bool) {
+	var metric model.Metric
+	var matched, crossing bool
+	var text string
+	switch r.Kind {
+	case KindPriceAbove:
+		metric, crossing = s.Price.LatestPrice, true
+		matched = metric.Value >= r.Threshold
+		text = fmt.Sprintf("最新价 %.2f 元，向上突破 %.2f 元", metric.Value, r.Threshold)
+	case KindPriceBelow:
+		metric, crossing = s.Price.LatestPrice, true
+		matched = metric.Value <= r.Threshold
+		text = fmt.Sprintf("最新价 %.2f 元，向下跌破 %.2f 元", metric.Value, r.Threshold)
+	case KindChangePct:
+		metric = s.Price.PriceChangePct
+		matched = math.Abs(metric.Value) >= r.Threshold
+		text = fmt.Sprintf("当日涨跌幅 %+.2f%%，超过 ±%.2f%%", metric.Value, r.Threshold)
+	case KindPEAbove:
+		metric = s.BasicInfo.PETTM
+		matched = metric.Value > r.Threshold
+		text = fmt.Sprintf("市盈率(TTM) %.2f，高于 %.2f", metric.Value, r.Threshold)
+	case KindPEBelow:
+		metric = s.BasicInfo.PETTM
+		matched = metric.Value < r.Threshold
+		text = fmt.Sprintf("市盈率(TTM) %.2f，低于 %.2f", metric.Value, r.Threshold)
+	}
+	if !metric.Valid {
+		return Outcome{}, false
+	}
+
+	prev := r.State
+	out := Outcome{State: store.AlertState{Initialized: true, Matched: matched}}
+	// 涨跌幅按日计算，新的交易日重新触发
+	if r.Kind == KindChangePct {
+		out.State.Date = s.Price.Date
+		if prev.Date != s.Price.Date {
+			prev.Matched = false
+		}
+	}
+	if matched && !prev.Matched && (prev.Initialized || !crossing) {
+		out.Triggered = true
+		out.Message = stockLabel(r, s.Name) + " " + text
+		out.Value = metric.Ptr()
+	}
+	return out, true
+}
+
+func evaluateRisks(r *store.AlertRule, s *model.PythonAnalysisResponse) Outcome {
+	var risks []string
+	for _, risk := range s.Risks {
+		if risk = strings.TrimSpace(risk); risk != "" && risk != noRisk {
+			risks = append(risks, risk)
+		}
+	}
+
+	out := Outcome{State: store.AlertState{Initialized: true, Risks: risks}}
+	if !r.State.Initialized {
+		return out
+	}
+	seen := make(map[string]bool, len(r.State.Risks))
+	for _, risk := range r.State.Risks {
+		seen[risk] = true
+	}
+	var added []string
+	for _, risk := range risks {
+		if !seen[risk] {
+			added = append(added, risk)
+		}
+	}
+	if len(added) > 0 {
+		out.Triggered = true
+		out.Message = fmt.Sprintf("%s 新增风险提示：%s", stockLabel(r, s.Name), strings.Join(added, "；"))
+	}
+	return out
+}
+
+func evaluateAction(r *store.AlertRule, report *store.Report) (Outcome, bool) {
+	action, _ := report.Recommendation()
+	if action == "" {
+		return Outcome{}, false
+	}
+
+	prev := r.State
+	out := Outcome{State: store.AlertState{Initialized: true, ReportID: report.ID, Action: action}}
+	if !prev.Initialized || prev.ReportID == report.ID || prev.Action == "" || prev.Action == action {
+		return out, true
+	}
+	if (r.From == "" || r.From == prev.Action) && (r.To == "" || r.To == action) {
+		out.Triggered = true
+		out.Message = fmt.Sprintf("%s 操作建议由 %s 变为 %s（报告 #%d）",
+			stockLabel(r, report.Name), prev.Action.Label(), action.Label(), report.ID)
+	}
+	return out, true
+}
+
+// stockLabel 股票的展示名称，如“贵州茅台(600519)”
+func stockLabel(r *store.AlertRule, name string) string {
+	if r.Name != "" {
+		name = r.Name
+	}
+	if name == "" {
+		return r.Code
+	}
+	return fmt.Sprintf("%s(%s)", name, r.Code)
+}
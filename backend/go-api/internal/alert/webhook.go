@@ -0,0 +1,247 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"stock-analysis-api/backend/go-api/internal/market"
+	"stock-analysis-api/backend/go-api/internal/resilience"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"strconv"
+	"time"
+)
+
+// Webhook 推送格式
+const (
+	FormatJSON     = "json"     // 通用JSON，附带 HMAC 签名请求头
+	FormatWeCom    = "wecom"    // 企业微信群机器人 markdown 消息
+	FormatDingTalk = "dingtalk" // 钉钉群机器人 markdown 消息，配置密钥时按钉钉“加签”方式签名
+)
+
+const (
+	// pollInterval 无新提醒时检查到期重试的间隔
+	pollInterval = 10 * time.Second
+	// batchSize 单次读取的待推送记录数
+	batchSize = 50
+)
+
+// ValidFormat 判断推送格式是否受支持
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatWeCom || format == FormatDingTalk
+}
+
+// NewSecret 生成随机签名密钥
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign 计算通用签名：hex(HMAC-SHA256(secret, timestamp + "." + body))
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher 从推送队列中取出到期的记录推送到 Webhook，失败时按指数退避重试，超过最大次数后标记为失败。
+// 队列保存在数据库中，服务重启后继续推送
+type Dispatcher struct {
+	webhooks    *store.WebhookStore
+	client      *http.Client
+	maxAttempts int
+	backoff     resilience.Backoff
+	wake        chan struct{}
+}
+
+// NewDispatcher 创建 Webhook 推送器，allowHosts 中的主机允许解析到内网地址
+func NewDispatcher(webhooks *store.WebhookStore, timeout time.Duration, maxAttempts int, backoff time.Duration, allowHosts []string) *Dispatcher {
+	return &Dispatcher{
+		webhooks:    webhooks,
+		client:      newHTTPClient(timeout, allowHosts),
+		maxAttempts: max(maxAttempts, 1),
+		backoff:     resilience.Backoff{Base: backoff, Max: time.Hour},
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Start 启动后台推送，ctx 取消时停止
+func (d *Dispatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			d.flush(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-d.wake:
+			}
+		}
+	}()
+}
+
+// Notify 有新的提醒事件时唤醒推送，不阻塞
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush 推送所有到期的记录
+func (d *Dispatcher) flush(ctx context.Context) {
+	for ctx.Err() == nil {
+		pending, err := d.webhooks.Due(time.Now(), batchSize)
+		if err != nil {
+			log.Printf("读取待推送记录失败: %v", err)
+			return
+		}
+		for _, p := range pending {
+			d.deliver(ctx, p)
+		}
+		if len(pending) < batchSize {
+			return
+		}
+	}
+}
+
+// deliver 推送一条记录并更新其状态
+func (d *Dispatcher) deliver(ctx context.Context, p *store.PendingDelivery) {
+	attempts := p.Attempts + 1
+	err := d.send(ctx, p)
+	switch {
+	case err == nil:
+		err = d.webhooks.MarkDelivered(p.ID, attempts, time.Now())
+	case attempts >= d.maxAttempts:
+		log.Printf("Webhook推送失败，已放弃: delivery=%d, webhook=%d, 第%d次: %v", p.ID, p.WebhookID, attempts, err)
+		err = d.webhooks.MarkFailed(p.ID, attempts, err.Error())
+	default:
+		next := time.Now().Add(d.backoff.Delay(attempts - 1))
+		log.Printf("Webhook推送失败，%s 重试: delivery=%d, webhook=%d, 第%d次: %v",
+			next.In(market.Shanghai).Format(time.DateTime), p.ID, p.WebhookID, attempts, err)
+		err = d.webhooks.MarkRetry(p.ID, attempts, err.Error(), next)
+	}
+	if err != nil {
+		log.Printf("更新推送记录失败: %v", err)
+	}
+}
+
+// send 按 Webhook 格式构造并发送请求，2xx 且机器人未返回错误码时视为成功
+func (d *Dispatcher) send(ctx context.Context, p *store.PendingDelivery) error {
+	body, err := payload(p)
+	if err != nil {
+		return err
+	}
+
+	target := p.Webhook.URL
+	if p.Webhook.Format == FormatDingTalk && p.Webhook.Secret != "" {
+		if target, err = dingTalkSign(target, p.Webhook.Secret, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Alert-Event", strconv.FormatInt(p.EventID, 10))
+	req.Header.Set("X-Alert-Delivery", strconv.FormatInt(p.ID, 10))
+	if p.Webhook.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Alert-Timestamp", timestamp)
+		req.Header.Set("X-Alert-Signature", "sha256="+Sign(p.Webhook.Secret, timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(respBody), 200))
+	}
+	// 企业微信与钉钉机器人出错时仍返回200，以 errcode 表示错误
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if json.Unmarshal(respBody, &result) == nil && result.ErrCode != 0 {
+		return fmt.Errorf("机器人返回错误 %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// payload 按 Webhook 格式构造请求体
+func payload(p *store.PendingDelivery) ([]byte, error) {
+	e := p.Event
+	switch p.Webhook.Format {
+	case FormatWeCom:
+		return json.Marshal(map[string]any{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"content": markdown(e)},
+		})
+	case FormatDingTalk:
+		return json.Marshal(map[string]any{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"title": "股票提醒 " + e.Code, "text": markdown(e)},
+		})
+	default:
+		return json.Marshal(map[string]any{
+			"event_id":     e.ID,
+			"rule_id":      e.RuleID,
+			"code":         e.Code,
+			"name":         e.Name,
+			"kind":         e.Kind,
+			"message":      e.Message,
+			"value":        e.Value,
+			"triggered_at": e.TriggeredAt,
+		})
+	}
+}
+
+func markdown(e store.AlertEvent) string {
+	return fmt.Sprintf("**股票提醒**\n\n%s\n\n> %s", e.Message, e.TriggeredAt.In(market.Shanghai).Format(time.DateTime))
+}
+
+// dingTalkSign 按钉钉机器人“加签”方式在URL上附加 timestamp 与 sign 参数
+func dingTalkSign(rawURL, secret string, now time.Time) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("Webhook地址无效: %w", err)
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+
+	q := u.Query()
+	q.Set("timestamp", timestamp)
+	q.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"stock-analysis-api/backend/go-api/internal/market"
+	"sync"
+	"time"
+)
+
+// sweepThreshold 缓存条目超过该数量时，写入前清理已过期条目
+const sweepThreshold = 1024
+
+type cacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// ttlCache 按交易时段计算失效时间的内存缓存：交易时段内有效期为 ttl，休市期间有效至下一交易时段开盘
+type ttlCache[V any] struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry[V]
+}
+
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry[V]),
+	}
+}
+
+func (c *ttlCache[V]) enabled() bool {
+	return c.ttl > 0
+}
+
+func (c *ttlCache[V]) get(key string) (V, bool) {
+	var zero V
+	if !c.enabled() {
+		return zero, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache[V]) set(key string, value V) {
+	if !c.enabled() {
+		return
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= sweepThreshold {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = cacheEntry[V]{value: value, expiresAt: market.Expiry(now, c.ttl)}
+}
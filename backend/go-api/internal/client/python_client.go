@@ -2,54 +2,255 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"net"
 	"net/http"
 	"stock-analysis-api/backend/go-api/config"
 	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/resilience"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
+// ErrServiceUnavailable Python服务熔断中，请求被快速拒绝
+var ErrServiceUnavailable = errors.New("Python服务暂不可用，请稍后重试")
+
+// StatusError Python服务返回的非200响应
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Python服务返回错误: %d - %s", e.StatusCode, e.Body)
+}
+
+// PythonClient Python数据服务客户端。5xx/超时带退避重试，连续失败后熔断；
+// 按股票代码缓存分析数据，并合并同一代码的并发请求为一次上游调用
 type PythonClient struct {
-	baseURL string
-	client  *http.Client
+	baseURL        string
+	client         *http.Client
+	listClient     *http.Client // 证券列表需逐个请求行业板块，使用更长的超时
+	universeClient *http.Client // 全市场数据需分页拉取业绩报表，单独设置超时
+	maxRetries     int
+	backoff        resilience.Backoff
+	breaker        *resilience.CircuitBreaker // 单只股票的交互式请求（/analyze、/history）
+	bulkBreaker    *resilience.CircuitBreaker // 耗时数分钟的批量拉取（/stocks、/universe），失败不影响交互式分析
+
+	cache        *ttlCache[*model.PythonAnalysisResponse]
+	historyCache *ttlCache[[]model.Bar]
+	group        singleflight.Group
 }
 
 func NewPythonClient() *PythonClient {
+	cfg := config.AppConfig
 	return &PythonClient{
-		baseURL: config.AppConfig.PythonServiceURL,
+		baseURL: cfg.PythonServiceURL,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: cfg.PythonTimeout,
+		},
+		listClient: &http.Client{
+			Timeout: cfg.SecurityFetchTimeout,
+		},
+		universeClient: &http.Client{
+			Timeout: cfg.ScreenFetchTimeout,
 		},
+		maxRetries:   cfg.PythonMaxRetries,
+		backoff:      resilience.Backoff{Base: cfg.PythonRetryBackoff, Max: 5 * time.Second},
+		breaker:      resilience.NewCircuitBreaker("python", cfg.PythonBreakerThreshold, cfg.PythonBreakerCooldown),
+		bulkBreaker:  resilience.NewCircuitBreaker("python-bulk", cfg.PythonBreakerThreshold, cfg.PythonBreakerCooldown),
+		cache:        newTTLCache[*model.PythonAnalysisResponse](cfg.PythonCacheTTL),
+		historyCache: newTTLCache[[]model.Bar](cfg.PythonCacheTTL),
+	}
+}
+
+// Analyze 获取股票分析数据，ctx 取消时立即返回。
+// 返回的数据可能来自缓存并被多个调用方共享，调用方不得修改
+func (pc *PythonClient) Analyze(ctx context.Context, code string) (*model.PythonAnalysisResponse, error) {
+	return cachedFetch(ctx, pc, pc.cache, "analyze:"+code, func(ctx context.Context) (*model.PythonAnalysisResponse, error) {
+		var result model.PythonAnalysisResponse
+		if err := pc.postWithRetry(ctx, "/analyze", map[string]string{"code": code}, &result); err != nil {
+			return nil, err
+		}
+		return &result, nil
+	})
+}
+
+// historyResponse Python服务 /history 响应
+type historyResponse struct {
+	Code string      `json:"code"`
+	Bars []model.Bar `json:"bars"`
+}
+
+// History 获取 [start, end] 区间内的前复权日K线，按日期升序。返回的切片可能被共享，调用方不得修改
+func (pc *PythonClient) History(ctx context.Context, code string, start, end time.Time) ([]model.Bar, error) {
+	startDate, endDate := start.Format("20060102"), end.Format("20060102")
+	key := "history:" + code + ":" + startDate + ":" + endDate
+	return cachedFetch(ctx, pc, pc.historyCache, key, func(ctx context.Context) ([]model.Bar, error) {
+		var result historyResponse
+		body := map[string]string{"code": code, "start_date": startDate, "end_date": endDate}
+		if err := pc.postWithRetry(ctx, "/history", body, &result); err != nil {
+			return nil, err
+		}
+		return result.Bars, nil
+	})
+}
+
+// securitiesResponse Python服务 /stocks 响应
+type securitiesResponse struct {
+	Stocks []model.Security `json:"stocks"`
+}
+
+// Securities 获取沪深北A股证券列表（代码、名称、拼音首字母、行业），不缓存，由证券主数据定期刷新
+func (pc *PythonClient) Securities(ctx context.Context) ([]model.Security, error) {
+	var result securitiesResponse
+	if err := pc.doWithRetry(ctx, pc.listClient, pc.bulkBreaker, http.MethodGet, "/stocks", nil, &result); err != nil {
+		return nil, err
 	}
+	return result.Stocks, nil
+}
+
+// universeResponse Python服务 /universe 响应
+type universeResponse struct {
+	Stocks []model.PythonAnalysisResponse `json:"stocks"`
 }
 
-// Analyze 调用Python分析服务
-func (pc *PythonClient) Analyze(code string) (*model.PythonAnalysisResponse, error) {
-	url := pc.baseURL + "/analyze"
+// Universe 获取全部A股的行情、估值与最新一期财务指标（不含多期财务与风险提示），不缓存，由选股模块定期刷新
+func (pc *PythonClient) Universe(ctx context.Context) ([]model.PythonAnalysisResponse, error) {
+	var result universeResponse
+	if err := pc.doWithRetry(ctx, pc.universeClient, pc.bulkBreaker, http.MethodGet, "/universe", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Stocks, nil
+}
 
-	reqBody := map[string]string{"code": code}
-	jsonData, err := json.Marshal(reqBody)
+// cachedFetch 先查缓存，未命中时调用 fetch 并写入缓存。
+// 同一key的并发请求只发起一次上游调用，上游调用不随单个调用方取消，避免一个客户端断开导致其他等待者失败
+func cachedFetch[V any](ctx context.Context, pc *PythonClient, cache *ttlCache[V], key string, fetch func(context.Context) (V, error)) (V, error) {
+	if cached, ok := cache.get(key); ok {
+		return cached, nil
+	}
+
+	ch := pc.group.DoChan(key, func() (interface{}, error) {
+		result, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		cache.set(key, result)
+		return result, nil
+	})
+
+	var zero V
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		if res.Shared {
+			log.Printf("合并Python数据请求: %s", key)
+		}
+		return res.Val.(V), nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// postWithRetry 发送POST请求并解析JSON响应。熔断器打开时快速失败
+func (pc *PythonClient) postWithRetry(ctx context.Context, path string, body interface{}, out interface{}) error {
+	return pc.doWithRetry(ctx, pc.client, pc.breaker, http.MethodPost, path, body, out)
+}
+
+// doWithRetry 发送请求并解析JSON响应，body 为 nil 时不带请求体，结果计入 breaker
+func (pc *PythonClient) doWithRetry(ctx context.Context, httpClient *http.Client, breaker *resilience.CircuitBreaker, method, path string, body interface{}, out interface{}) error {
+	var jsonData []byte
+	if body != nil {
+		var err error
+		if jsonData, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("序列化请求失败: %w", err)
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= pc.maxRetries; attempt++ {
+		if attempt > 0 {
+			log.Printf("Python服务 %s 第%d次重试: %v", path, attempt, lastErr)
+			if err := pc.backoff.Sleep(ctx, attempt-1); err != nil {
+				return err
+			}
+		}
+
+		if !breaker.Allow() {
+			return ErrServiceUnavailable
+		}
+
+		lastErr = pc.do(ctx, httpClient, method, path, jsonData, out)
+		if ctx.Err() != nil {
+			// 调用方取消不代表服务状态，归还可能占用的半开探测名额
+			breaker.Release()
+			return lastErr
+		}
+		if lastErr == nil || !isTransient(lastErr) {
+			// 非瞬时错误（如4xx）说明服务本身可用
+			breaker.Success()
+			return lastErr
+		}
+		if breaker.Failure() {
+			log.Printf("Python服务连续失败，熔断器 %s 打开 %v", breaker.Name(), config.AppConfig.PythonBreakerCooldown)
+		}
+	}
+	return lastErr
+}
+
+func (pc *PythonClient) do(ctx context.Context, httpClient *http.Client, method, path string, jsonData []byte, out interface{}) error {
+	var reqBody io.Reader
+	if jsonData != nil {
+		reqBody = bytes.NewReader(jsonData)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, pc.baseURL+path, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("序列化请求失败: %w", err)
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	if jsonData != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	resp, err := pc.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("调用Python服务失败: %w", err)
+		return fmt.Errorf("调用Python服务失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Python服务返回错误: %d - %s", resp.StatusCode, string(body))
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
-	var result model.PythonAnalysisResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("解析响应失败: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
 	}
+	return nil
+}
 
-	return &result, nil
+// isTransient 判断错误是否值得重试：5xx、429、超时和连接类错误
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
 }
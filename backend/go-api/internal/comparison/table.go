@@ -0,0 +1,194 @@
+// Package comparison 把多只股票的估值与财务指标整理为统一口径的对比表，并标出各指标的领先者
+package comparison
+
+import (
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/financials"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"strings"
+)
+
+// Peer 参与对比的单只股票。Trends 在历史数据不足时为 nil
+type Peer struct {
+	Symbol   symbol.Symbol
+	Snapshot *model.PythonAnalysisResponse
+	Trends   *financials.Trends
+}
+
+// Stock 对比表的表头信息
+type Stock struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Exchange   string `json:"exchange,omitempty"`
+	Board      string `json:"board,omitempty"`
+	Industry   string `json:"industry"`
+	ReportDate string `json:"report_date"` // 财务指标报告期
+}
+
+// Row 对比表中的一个指标，Values 与 Stocks 顺序一致
+type Row struct {
+	Key    string         `json:"key"`
+	Label  string         `json:"label"`
+	Unit   string         `json:"unit,omitempty"`
+	Values []model.Metric `json:"values"`
+	Best   string         `json:"best,omitempty"` // 该指标最优的股票代码，无方向、有效值不足两个或并列时为空
+}
+
+// Table 横向对比表
+type Table struct {
+	Stocks []Stock `json:"stocks"`
+	Rows   []Row   `json:"rows"`
+}
+
+// direction 指标的优劣方向
+type direction int
+
+const (
+	neutral        direction = iota
+	higherIsBetter           // 越高越好
+	lowerIsBetter            // 越低越好
+	positiveLower            // 仅比较正值，越低越好（亏损公司的PE/PB无意义）
+)
+
+// columns 对比表的指标定义
+var columns = []struct {
+	key, label, unit string
+	dir              direction
+	value            func(Peer) model.Metric
+}{
+	{"latest_price", "最新价", "元", neutral, func(p Peer) model.Metric { return p.Snapshot.Price.LatestPrice }},
+	{"market_cap", "总市值", "亿元", neutral, func(p Peer) model.Metric { return p.Snapshot.BasicInfo.MarketCap.HundredMillion() }},
+	{"pe_ttm", "市盈率(TTM)", "", positiveLower, func(p Peer) model.Metric { return p.Snapshot.BasicInfo.PETTM }},
+	{"pb", "市净率", "", positiveLower, func(p Peer) model.Metric { return p.Snapshot.BasicInfo.PB }},
+	{"roe", "ROE", "%", higherIsBetter, func(p Peer) model.Metric { return p.Snapshot.FinancialMetrics.ROE }},
+	{"roa", "ROA", "%", higherIsBetter, func(p Peer) model.Metric { return p.Snapshot.FinancialMetrics.ROA }},
+	{"gross_margin", "毛利率", "%", higherIsBetter, func(p Peer) model.Metric { return p.Snapshot.FinancialMetrics.GrossMargin }},
+	{"net_margin", "净利率", "%", higherIsBetter, func(p Peer) model.Metric { return p.Snapshot.FinancialMetrics.NetMargin }},
+	{"debt_ratio", "资产负债率", "%", lowerIsBetter, func(p Peer) model.Metric { return p.Snapshot.FinancialMetrics.DebtRatio }},
+	{"revenue_growth", "营收增长", "%", higherIsBetter, func(p Peer) model.Metric { return p.Snapshot.FinancialMetrics.RevenueGrowth }},
+	{"profit_growth", "净利润增长", "%", higherIsBetter, func(p Peer) model.Metric { return p.Snapshot.FinancialMetrics.ProfitGrowth }},
+	{"revenue_cagr", "营收复合增长", "%", higherIsBetter, trend(func(t *financials.Trends) model.Metric { return t.RevenueCAGR })},
+	{"profit_cagr", "净利润复合增长", "%", higherIsBetter, trend(func(t *financials.Trends) model.Metric { return t.ProfitCAGR })},
+	{"roe_mean", "ROE均值", "%", higherIsBetter, trend(func(t *financials.Trends) model.Metric { return t.ROEMean })},
+}
+
+// Build 生成对比表
+func Build(peers []Peer) *Table {
+	t := &Table{Stocks: make([]Stock, len(peers)), Rows: make([]Row, 0, len(columns))}
+	for i, p := range peers {
+		t.Stocks[i] = Stock{
+			Code:       p.Snapshot.Code,
+			Name:       p.Snapshot.Name,
+			Exchange:   string(p.Symbol.Exchange),
+			Board:      string(p.Symbol.Board),
+			Industry:   p.Snapshot.BasicInfo.Industry,
+			ReportDate: p.Snapshot.FinancialMetrics.ReportDate,
+		}
+	}
+
+	for _, col := range columns {
+		row := Row{Key: col.key, Label: col.label, Unit: col.unit, Values: make([]model.Metric, len(peers))}
+		for i, p := range peers {
+			row.Values[i] = col.value(p)
+		}
+		if best := bestIndex(row.Values, col.dir); best >= 0 {
+			row.Best = t.Stocks[best].Code
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// Text 以Markdown表格描述对比表，并注明行业与报告期口径差异，用于提示词
+func (t *Table) Text() string {
+	var sb strings.Builder
+	industries := make(map[string]bool)
+	reportDates := make(map[string]bool)
+	for _, s := range t.Stocks {
+		fmt.Fprintf(&sb, "- %s(%s): 行业 %s，%s，报告期 %s\n",
+			s.Name, s.Code, orMissing(s.Industry), orMissing(s.Board), orMissing(s.ReportDate))
+		industries[s.Industry] = true
+		reportDates[s.ReportDate] = true
+	}
+	if len(industries) > 1 {
+		sb.WriteString("- 注意: 各股票分属不同行业，估值与负债率等指标需结合行业特征比较\n")
+	}
+	if len(reportDates) > 1 {
+		sb.WriteString("- 注意: 各股票财务指标的报告期不一致，季报为年初至报告期末累计值\n")
+	}
+	sb.WriteString("\n| 指标 |")
+	for _, s := range t.Stocks {
+		fmt.Fprintf(&sb, " %s(%s) |", s.Name, s.Code)
+	}
+	sb.WriteString(" 最优 |\n|---|")
+	sb.WriteString(strings.Repeat("---|", len(t.Stocks)+1))
+	sb.WriteString("\n")
+
+	names := make(map[string]string, len(t.Stocks))
+	for _, s := range t.Stocks {
+		names[s.Code] = s.Name
+	}
+	for _, row := range t.Rows {
+		fmt.Fprintf(&sb, "| %s |", row.Label)
+		for _, v := range row.Values {
+			if v.Valid {
+				fmt.Fprintf(&sb, " %.2f%s |", v.Value, row.Unit)
+			} else {
+				fmt.Fprintf(&sb, " %s |", model.MissingText)
+			}
+		}
+		best := "-"
+		if row.Best != "" {
+			best = names[row.Best]
+		}
+		fmt.Fprintf(&sb, " %s |\n", best)
+	}
+	return sb.String()
+}
+
+// bestIndex 返回最优值的下标，无方向、有效值不足两个或最优值并列时返回-1
+func bestIndex(values []model.Metric, dir direction) int {
+	if dir == neutral {
+		return -1
+	}
+	best, count := -1, 0
+	for i, v := range values {
+		if !v.Valid || (dir == positiveLower && v.Value <= 0) {
+			continue
+		}
+		count++
+		if best < 0 ||
+			(dir == higherIsBetter && v.Value > values[best].Value) ||
+			(dir != higherIsBetter && v.Value < values[best].Value) {
+			best = i
+		}
+	}
+	if count < 2 {
+		return -1
+	}
+	// 最优值并列时不标注
+	for i, v := range values {
+		if i != best && v.Valid && v.Value == values[best].Value {
+			return -1
+		}
+	}
+	return best
+}
+
+// trend 取财务趋势中的指标，趋势不可用时为缺失值
+func trend(value func(*financials.Trends) model.Metric) func(Peer) model.Metric {
+	return func(p Peer) model.Metric {
+		if p.Trends == nil {
+			return model.Metric{}
+		}
+		return value(p.Trends)
+	}
+}
+
+func orMissing(s string) string {
+	if s == "" {
+		return model.MissingText
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"strings"
+	"time"
+)
+
+// Composite 按优先级组合多个数据源：以第一个成功返回的数据为准，
+// 其中缺失（零值）的字段依次用后续数据源的数据补齐
+type Composite struct {
+	providers []MarketDataProvider
+}
+
+func NewComposite(providers ...MarketDataProvider) *Composite {
+	return &Composite{providers: providers}
+}
+
+func (c *Composite) Name() string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (c *Composite) Snapshot(ctx context.Context, code string) (*model.PythonAnalysisResponse, error) {
+	return merge(ctx, c, "分析数据", func(p MarketDataProvider) (*model.PythonAnalysisResponse, error) {
+		return Snapshot(ctx, p, code)
+	})
+}
+
+func (c *Composite) BasicInfo(ctx context.Context, code string) (*model.BasicInfo, error) {
+	return merge(ctx, c, "基本信息", func(p MarketDataProvider) (*model.BasicInfo, error) {
+		return p.BasicInfo(ctx, code)
+	})
+}
+
+func (c *Composite) Quote(ctx context.Context, code string) (*model.PriceInfo, error) {
+	return merge(ctx, c, "行情", func(p MarketDataProvider) (*model.PriceInfo, error) {
+		return p.Quote(ctx, code)
+	})
+}
+
+func (c *Composite) Financials(ctx context.Context, code string) (*model.FinancialMetrics, error) {
+	return merge(ctx, c, "财务摘要", func(p MarketDataProvider) (*model.FinancialMetrics, error) {
+		return p.Financials(ctx, code)
+	})
+}
+
+// History K线不做逐字段合并，返回第一个有数据的数据源的结果
+func (c *Composite) History(ctx context.Context, code string, start, end time.Time) ([]model.Bar, error) {
+	var errs []error
+	for _, p := range c.providers {
+		bars, err := p.History(ctx, code, start, end)
+		if err == nil && len(bars) > 0 {
+			return bars, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil && !errors.Is(err, ErrNotSupported) {
+			log.Printf("数据源 %s 获取K线失败: %v", p.Name(), err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, ErrNotSupported
+}
+
+// Securities 证券列表不做合并，返回第一个有数据的数据源的结果
+func (c *Composite) Securities(ctx context.Context) ([]model.Security, error) {
+	var errs []error
+	for _, p := range c.providers {
+		securities, err := p.Securities(ctx)
+		if err == nil && len(securities) > 0 {
+			return securities, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil && !errors.Is(err, ErrNotSupported) {
+			log.Printf("数据源 %s 获取证券列表失败: %v", p.Name(), err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, ErrNotSupported
+}
+
+// Universe 全市场数据不做合并，返回第一个有数据的数据源的结果
+func (c *Composite) Universe(ctx context.Context) ([]model.PythonAnalysisResponse, error) {
+	var errs []error
+	for _, p := range c.providers {
+		stocks, err := p.Universe(ctx)
+		if err == nil && len(stocks) > 0 {
+			return stocks, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil && !errors.Is(err, ErrNotSupported) {
+			log.Printf("数据源 %s 获取全市场数据失败: %v", p.Name(), err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, ErrNotSupported
+}
+
+// merge 依次调用各数据源，以第一个成功结果为基础补齐零值字段。全部失败时返回所有错误
+func merge[T any](ctx context.Context, c *Composite, what string, fetch func(MarketDataProvider) (*T, error)) (*T, error) {
+	var result *T
+	var errs []error
+	for _, p := range c.providers {
+		v, err := fetch(p)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if !errors.Is(err, ErrNotSupported) {
+				log.Printf("数据源 %s 获取%s失败: %v", p.Name(), what, err)
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if result == nil {
+			// 复制一份再补齐，数据源返回的可能是共享的缓存数据
+			copied := *v
+			result = &copied
+			continue
+		}
+		fillZero(reflect.ValueOf(result).Elem(), reflect.ValueOf(v).Elem())
+	}
+
+	if result == nil {
+		if len(errs) == 0 {
+			return nil, ErrNotSupported
+		}
+		return nil, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+var metricType = reflect.TypeOf(model.Metric{})
+
+// fillZero 用 src 中的值填充 dst 中为零值的字段，嵌套结构体逐字段处理；
+// model.Metric 整体视为一个值，仅在缺失时补齐，有效的0不会被覆盖
+func fillZero(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		if field.Kind() == reflect.Struct && field.Type() != metricType {
+			fillZero(field, src.Field(i))
+			continue
+		}
+		if field.IsZero() {
+			field.Set(src.Field(i))
+		}
+	}
+}
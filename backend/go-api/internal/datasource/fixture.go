@@ -0,0 +1,216 @@
+package datasource
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FixtureProvider 读取本地静态文件的数据源，用于离线开发和测试：
+//   - <code>.json: 与Python服务 /analyze 响应相同结构的分析数据
+//   - <code>.csv:  日K线，表头为 date,open,high,low,close,volume
+//   - securities.json: 证券列表，model.Security 数组
+//
+// 全市场数据（选股）由目录下全部 <code>.json 组成
+//
+// 每次调用都重新读取文件，修改后无需重启服务
+type FixtureProvider struct {
+	dir string
+}
+
+func NewFixtureProvider(dir string) *FixtureProvider {
+	return &FixtureProvider{dir: dir}
+}
+
+func (f *FixtureProvider) Name() string {
+	return "fixture"
+}
+
+func (f *FixtureProvider) Snapshot(ctx context.Context, code string) (*model.PythonAnalysisResponse, error) {
+	data, err := os.ReadFile(f.path(code, ".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取数据文件失败: %w", err)
+	}
+
+	var snapshot model.PythonAnalysisResponse
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("解析数据文件 %s.json 失败: %w", code, err)
+	}
+	if snapshot.Code == "" {
+		snapshot.Code = code
+	}
+	if snapshot.BasicInfo.Code == "" {
+		snapshot.BasicInfo.Code = snapshot.Code
+	}
+	if snapshot.BasicInfo.Name == "" {
+		snapshot.BasicInfo.Name = snapshot.Name
+	}
+	return &snapshot, nil
+}
+
+func (f *FixtureProvider) BasicInfo(ctx context.Context, code string) (*model.BasicInfo, error) {
+	snapshot, err := f.Snapshot(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot.BasicInfo, nil
+}
+
+func (f *FixtureProvider) Quote(ctx context.Context, code string) (*model.PriceInfo, error) {
+	snapshot, err := f.Snapshot(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot.Price, nil
+}
+
+func (f *FixtureProvider) Financials(ctx context.Context, code string) (*model.FinancialMetrics, error) {
+	snapshot, err := f.Snapshot(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot.FinancialMetrics, nil
+}
+
+func (f *FixtureProvider) History(ctx context.Context, code string, start, end time.Time) ([]model.Bar, error) {
+	file, err := os.Open(f.path(code, ".csv"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取K线文件失败: %w", err)
+	}
+	defer file.Close()
+
+	bars, err := readBarsCSV(file)
+	if err != nil {
+		return nil, fmt.Errorf("解析K线文件 %s.csv 失败: %w", code, err)
+	}
+	return filterBars(bars, start, end), nil
+}
+
+func (f *FixtureProvider) Securities(ctx context.Context) ([]model.Security, error) {
+	data, err := os.ReadFile(filepath.Join(f.dir, "securities.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotSupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取证券列表失败: %w", err)
+	}
+
+	var securities []model.Security
+	if err := json.Unmarshal(data, &securities); err != nil {
+		return nil, fmt.Errorf("解析 securities.json 失败: %w", err)
+	}
+	return securities, nil
+}
+
+func (f *FixtureProvider) Universe(ctx context.Context) ([]model.PythonAnalysisResponse, error) {
+	files, err := filepath.Glob(filepath.Join(f.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("读取数据目录失败: %w", err)
+	}
+
+	var stocks []model.PythonAnalysisResponse
+	for _, file := range files {
+		code := strings.TrimSuffix(filepath.Base(file), ".json")
+		if code == "securities" {
+			continue
+		}
+		snapshot, err := f.Snapshot(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, *snapshot)
+	}
+	if len(stocks) == 0 {
+		return nil, ErrNotSupported
+	}
+	return stocks, nil
+}
+
+func (f *FixtureProvider) path(code, ext string) string {
+	// 只取文件名部分，防止代码中包含路径分隔符
+	return filepath.Join(f.dir, filepath.Base(code)+ext)
+}
+
+// readBarsCSV 解析 date,open,high,low,close,volume 格式的K线，列顺序以表头为准
+func readBarsCSV(r io.Reader) ([]model.Bar, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取表头失败: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "open", "high", "low", "close", "volume"} {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("缺少列: %s", name)
+		}
+	}
+
+	var bars []model.Bar
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]float64, 5)
+		for _, name := range []string{"open", "high", "low", "close", "volume"} {
+			v, err := strconv.ParseFloat(record[cols[name]], 64)
+			if err != nil {
+				return nil, fmt.Errorf("第%d行 %s 无效: %q", line, name, record[cols[name]])
+			}
+			values[name] = v
+		}
+		bars = append(bars, model.Bar{
+			Date:   record[cols["date"]],
+			Open:   values["open"],
+			High:   values["high"],
+			Low:    values["low"],
+			Close:  values["close"],
+			Volume: values["volume"],
+		})
+	}
+	return bars, nil
+}
+
+// filterBars 保留 [start, end] 区间内的K线，零值边界不限制
+func filterBars(bars []model.Bar, start, end time.Time) []model.Bar {
+	from, to := "", ""
+	if !start.IsZero() {
+		from = start.Format(time.DateOnly)
+	}
+	if !end.IsZero() {
+		to = end.Format(time.DateOnly)
+	}
+
+	filtered := make([]model.Bar, 0, len(bars))
+	for _, bar := range bars {
+		if (from != "" && bar.Date < from) || (to != "" && bar.Date > to) {
+			continue
+		}
+		filtered = append(filtered, bar)
+	}
+	return filtered
+}
@@ -0,0 +1,99 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/client"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotSupported 数据源不提供该类数据
+	ErrNotSupported = errors.New("数据源不支持该数据")
+	// ErrNotFound 数据源中没有该股票的数据
+	ErrNotFound = errors.New("未找到股票数据")
+)
+
+// MarketDataProvider 行情与基本面数据源
+type MarketDataProvider interface {
+	// Name 数据源名称，用于日志
+	Name() string
+	// BasicInfo 基本信息（名称、行业、市值、估值）
+	BasicInfo(ctx context.Context, code string) (*model.BasicInfo, error)
+	// Quote 最新行情
+	Quote(ctx context.Context, code string) (*model.PriceInfo, error)
+	// Financials 最新一期财务摘要
+	Financials(ctx context.Context, code string) (*model.FinancialMetrics, error)
+	// History [start, end] 区间内的日K线，按日期升序
+	History(ctx context.Context, code string, start, end time.Time) ([]model.Bar, error)
+	// Securities 全部A股证券列表，用于构建本地证券主数据
+	Securities(ctx context.Context) ([]model.Security, error)
+	// Universe 全部A股的行情、估值与最新一期财务指标，用于选股
+	Universe(ctx context.Context) ([]model.PythonAnalysisResponse, error)
+}
+
+// SnapshotProvider 能一次性返回完整分析数据的数据源（含风险提示），避免多次往返
+type SnapshotProvider interface {
+	Snapshot(ctx context.Context, code string) (*model.PythonAnalysisResponse, error)
+}
+
+// Snapshot 获取分析所需的完整数据。数据源未实现 SnapshotProvider 时分别获取各部分后组装
+func Snapshot(ctx context.Context, p MarketDataProvider, code string) (*model.PythonAnalysisResponse, error) {
+	if sp, ok := p.(SnapshotProvider); ok {
+		return sp.Snapshot(ctx, code)
+	}
+
+	info, err := p.BasicInfo(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("获取基本信息失败: %w", err)
+	}
+	quote, err := p.Quote(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("获取行情失败: %w", err)
+	}
+	financials, err := p.Financials(ctx, code)
+	if err != nil && !errors.Is(err, ErrNotSupported) {
+		return nil, fmt.Errorf("获取财务摘要失败: %w", err)
+	}
+
+	snapshot := &model.PythonAnalysisResponse{
+		Code:      info.Code,
+		Name:      info.Name,
+		BasicInfo: *info,
+		Price:     *quote,
+	}
+	if snapshot.Code == "" {
+		snapshot.Code = code
+	}
+	if financials != nil {
+		snapshot.FinancialMetrics = *financials
+	}
+	return snapshot, nil
+}
+
+// New 按名称创建数据源，多个名称以逗号分隔时按顺序组合为 Composite
+func New(names []string, pythonClient *client.PythonClient, fixtureDir string) (MarketDataProvider, error) {
+	providers := make([]MarketDataProvider, 0, len(names))
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case "python":
+			providers = append(providers, NewPythonProvider(pythonClient))
+		case "fixture":
+			providers = append(providers, NewFixtureProvider(fixtureDir))
+		default:
+			return nil, fmt.Errorf("不支持的行情数据源: %s (支持: python, fixture)", name)
+		}
+	}
+
+	switch len(providers) {
+	case 0:
+		return nil, fmt.Errorf("未配置行情数据源")
+	case 1:
+		return providers[0], nil
+	default:
+		return NewComposite(providers...), nil
+	}
+}
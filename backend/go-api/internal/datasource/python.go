@@ -0,0 +1,71 @@
+package datasource
+
+import (
+	"context"
+	"stock-analysis-api/backend/go-api/internal/client"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"time"
+)
+
+// PythonProvider 基于Python数据服务（akshare）的数据源。
+// 基本信息、行情和财务摘要均来自 /analyze 接口，PythonClient 的缓存保证同一股票只请求一次；K线来自 /history 接口
+type PythonProvider struct {
+	client *client.PythonClient
+}
+
+func NewPythonProvider(pythonClient *client.PythonClient) *PythonProvider {
+	return &PythonProvider{client: pythonClient}
+}
+
+func (p *PythonProvider) Name() string {
+	return "python"
+}
+
+func (p *PythonProvider) Snapshot(ctx context.Context, code string) (*model.PythonAnalysisResponse, error) {
+	return p.client.Analyze(ctx, code)
+}
+
+func (p *PythonProvider) BasicInfo(ctx context.Context, code string) (*model.BasicInfo, error) {
+	data, err := p.client.Analyze(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	info := data.BasicInfo
+	if info.Code == "" {
+		info.Code = data.Code
+	}
+	if info.Name == "" {
+		info.Name = data.Name
+	}
+	return &info, nil
+}
+
+func (p *PythonProvider) Quote(ctx context.Context, code string) (*model.PriceInfo, error) {
+	data, err := p.client.Analyze(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	quote := data.Price
+	return &quote, nil
+}
+
+func (p *PythonProvider) Financials(ctx context.Context, code string) (*model.FinancialMetrics, error) {
+	data, err := p.client.Analyze(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	metrics := data.FinancialMetrics
+	return &metrics, nil
+}
+
+func (p *PythonProvider) History(ctx context.Context, code string, start, end time.Time) ([]model.Bar, error) {
+	return p.client.History(ctx, code, start, end)
+}
+
+func (p *PythonProvider) Securities(ctx context.Context) ([]model.Security, error) {
+	return p.client.Securities(ctx)
+}
+
+func (p *PythonProvider) Universe(ctx context.Context) ([]model.PythonAnalysisResponse, error) {
+	return p.client.Universe(ctx)
+}
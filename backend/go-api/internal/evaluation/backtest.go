@@ -0,0 +1,347 @@
+// Package evaluation 用历史收盘价回测分析报告中最终决策（买入/持有/卖出）的准确率
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"stock-analysis-api/backend/go-api/internal/market"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultHorizons 默认的前瞻收益周期（交易日）
+var DefaultHorizons = []int{5, 20, 60}
+
+// DefaultHoldBand 默认的持有建议判定区间（±%）
+const DefaultHoldBand = 3.0
+
+// fetchConcurrency 同时获取K线的股票数
+const fetchConcurrency = 4
+
+// unrecorded 模型或提示词版本未记录时的分组名
+const unrecorded = "未记录"
+
+// Options 回测参数
+type Options struct {
+	Filter   store.ReportFilter // 按股票代码与报告时间筛选
+	Horizons []int              // 前瞻收益周期（交易日），为空时使用 DefaultHorizons
+	HoldBand float64            // 持有建议在区间收益绝对值不超过该值（%）时视为正确，为0时使用 DefaultHoldBand
+	Details  bool               // 是否返回每条建议的明细
+}
+
+// Sample 单条建议的回测结果
+type Sample struct {
+	store.Recommendation
+	EntryDate  string         `json:"entry_date,omitempty"` // 建仓日：报告生成后第一个收盘的交易日，以收盘价建仓
+	EntryPrice float64        `json:"entry_price,omitempty"`
+	Returns    []model.Metric `json:"returns"` // 与 Result.Horizons 对应的区间收益（%），未满周期时缺失
+}
+
+// Stats 一组建议在某个周期上的表现
+type Stats struct {
+	Days            int          `json:"days"`
+	Evaluated       int          `json:"evaluated"` // 已满周期、可计算收益的建议数
+	Hits            int          `json:"hits"`
+	HitRate         model.Metric `json:"hit_rate"`          // 命中率（%）
+	AvgReturn       model.Metric `json:"avg_return"`        // 区间收益均值（%）
+	AvgActionReturn model.Metric `json:"avg_action_return"` // 按建议方向操作的收益均值（%）：买入计收益，卖出计收益取反，不含持有
+}
+
+// Group 按某一维度分组的统计
+type Group struct {
+	Key      string  `json:"key"`
+	Count    int     `json:"count"`
+	Horizons []Stats `json:"horizons"`
+}
+
+// Result 回测结果
+type Result struct {
+	Horizons        []int     `json:"horizons"`
+	HoldBand        float64   `json:"hold_band"`
+	Total           int       `json:"total"`   // 参与回测的建议数
+	Skipped         int       `json:"skipped"` // 无法获取K线而跳过的建议数
+	Overall         Group     `json:"overall"`
+	ByAction        []Group   `json:"by_action"`
+	ByConfidence    []Group   `json:"by_confidence"`
+	ByModel         []Group   `json:"by_model"`
+	ByPromptVersion []Group   `json:"by_prompt_version"`
+	Samples         []*Sample `json:"samples,omitempty"`
+	GeneratedAt     time.Time `json:"generated_at"`
+}
+
+// ParseHorizons 解析逗号分隔的回测周期（交易日），为空时返回 nil（使用默认周期）。最多5个，每个不超过250
+func ParseHorizons(s string) ([]int, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) > 5 {
+		return nil, fmt.Errorf("回测周期最多5个: %s", s)
+	}
+	horizons := make([]int, 0, len(parts))
+	for _, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n <= 0 || n > 250 {
+			return nil, fmt.Errorf("回测周期应为1-250的交易日数: %s", p)
+		}
+		horizons = append(horizons, n)
+	}
+	return horizons, nil
+}
+
+// Run 读取已完成报告的最终决策，按报告生成后的收盘价计算各周期的前瞻收益并汇总命中率。
+// 买入在收益为正、卖出在收益为负、持有在收益绝对值不超过 HoldBand 时视为命中
+func Run(ctx context.Context, reports *store.ReportStore, provider datasource.MarketDataProvider, opts Options) (*Result, error) {
+	horizons := opts.Horizons
+	if len(horizons) == 0 {
+		horizons = DefaultHorizons
+	}
+	for _, h := range horizons {
+		if h <= 0 {
+			return nil, fmt.Errorf("回测周期必须为正数: %d", h)
+		}
+	}
+
+	if opts.HoldBand <= 0 {
+		opts.HoldBand = DefaultHoldBand
+	}
+
+	recs, err := reports.ListRecommendations(opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	bars, err := fetchHistory(ctx, provider, recs, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Horizons: horizons, HoldBand: opts.HoldBand, GeneratedAt: now}
+	samples := make([]*Sample, 0, len(recs))
+	for _, rec := range recs {
+		history, ok := bars[rec.Code]
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		samples = append(samples, evaluate(rec, history, horizons))
+	}
+	result.Total = len(samples)
+
+	aggregate := func(key func(*Sample) string, order []string) []Group {
+		return group(samples, horizons, opts.HoldBand, key, order)
+	}
+	result.Overall = summarize("全部", samples, horizons, opts.HoldBand)
+	result.ByAction = aggregate(func(s *Sample) string { return string(s.Action) },
+		[]string{string(llm.ActionBuy), string(llm.ActionHold), string(llm.ActionSell)})
+	result.ByConfidence = aggregate(func(s *Sample) string { return confidenceBucket(s.Confidence) }, confidenceBuckets)
+	result.ByModel = aggregate(func(s *Sample) string { return orUnrecorded(s.Model) }, nil)
+	result.ByPromptVersion = aggregate(func(s *Sample) string { return orUnrecorded(s.PromptVersion) }, nil)
+	if opts.Details {
+		result.Samples = samples
+	}
+	return result, nil
+}
+
+// fetchHistory 并发获取各股票从最早报告前一周至今的日K线，只保留 now 之前已收盘的K线。
+// 获取失败的股票不在返回结果中，其建议计为跳过
+func fetchHistory(ctx context.Context, provider datasource.MarketDataProvider, recs []*store.Recommendation, now time.Time) (map[string][]model.Bar, error) {
+	earliest := make(map[string]time.Time)
+	for _, rec := range recs {
+		if t, ok := earliest[rec.Code]; !ok || rec.StartedAt.Before(t) {
+			earliest[rec.Code] = rec.StartedAt
+		}
+	}
+
+	var mu sync.Mutex
+	bars := make(map[string][]model.Bar, len(earliest))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(fetchConcurrency)
+	for code, start := range earliest {
+		g.Go(func() error {
+			history, err := provider.History(gctx, code, start.In(market.Shanghai).AddDate(0, 0, -7), now.In(market.Shanghai))
+			if err != nil {
+				if gctx.Err() != nil {
+					return gctx.Err()
+				}
+				if !errors.Is(err, datasource.ErrNotSupported) {
+					log.Printf("回测获取K线失败，跳过: %s, %v", code, err)
+				}
+				return nil
+			}
+			closed := make([]model.Bar, 0, len(history))
+			for _, bar := range history {
+				if t, ok := closeTime(bar.Date); ok && !t.After(now) && bar.Close > 0 {
+					closed = append(closed, bar)
+				}
+			}
+			mu.Lock()
+			bars[code] = closed
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return bars, nil
+}
+
+// evaluate 计算单条建议的建仓价与各周期收益。报告在盘中生成时以当日收盘价建仓，收盘后生成时以下一交易日收盘价建仓
+func evaluate(rec *store.Recommendation, bars []model.Bar, horizons []int) *Sample {
+	s := &Sample{Recommendation: *rec, Returns: make([]model.Metric, len(horizons))}
+	entry := sort.Search(len(bars), func(i int) bool {
+		t, _ := closeTime(bars[i].Date)
+		return !t.Before(rec.StartedAt)
+	})
+	if entry == len(bars) {
+		return s
+	}
+	s.EntryDate, s.EntryPrice = bars[entry].Date, bars[entry].Close
+	for i, h := range horizons {
+		if entry+h < len(bars) {
+			s.Returns[i] = model.Val((bars[entry+h].Close/s.EntryPrice - 1) * 100)
+		}
+	}
+	return s
+}
+
+// group 按 key 分组统计，order 指定分组顺序（其余分组按名称排序附在后面）
+func group(samples []*Sample, horizons []int, holdBand float64, key func(*Sample) string, order []string) []Group {
+	byKey := make(map[string][]*Sample)
+	for _, s := range samples {
+		k := key(s)
+		byKey[k] = append(byKey[k], s)
+	}
+
+	keys := make([]string, 0, len(byKey))
+	seen := make(map[string]bool)
+	for _, k := range order {
+		if len(byKey[k]) > 0 {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	var rest []string
+	for k := range byKey {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	groups := make([]Group, 0, len(keys))
+	for _, k := range keys {
+		groups = append(groups, summarize(k, byKey[k], horizons, holdBand))
+	}
+	return groups
+}
+
+// summarize 统计一组建议在各周期上的表现
+func summarize(key string, samples []*Sample, horizons []int, holdBand float64) Group {
+	g := Group{Key: key, Count: len(samples), Horizons: make([]Stats, len(horizons))}
+	for i, days := range horizons {
+		g.Horizons[i] = stats(samples, i, days, holdBand)
+	}
+	return g
+}
+
+func stats(samples []*Sample, index, days int, holdBand float64) Stats {
+	st := Stats{Days: days}
+	var sum, actionSum float64
+	actionCount := 0
+	for _, s := range samples {
+		r := s.Returns[index]
+		if !r.Valid {
+			continue
+		}
+		st.Evaluated++
+		sum += r.Value
+		if hit(s.Action, r.Value, holdBand) {
+			st.Hits++
+		}
+		switch s.Action {
+		case llm.ActionBuy:
+			actionSum += r.Value
+			actionCount++
+		case llm.ActionSell:
+			actionSum -= r.Value
+			actionCount++
+		}
+	}
+	if st.Evaluated > 0 {
+		st.HitRate = model.Val(float64(st.Hits) / float64(st.Evaluated) * 100)
+		st.AvgReturn = model.Val(sum / float64(st.Evaluated))
+	}
+	if actionCount > 0 {
+		st.AvgActionReturn = model.Val(actionSum / float64(actionCount))
+	}
+	return st
+}
+
+// holdBandEpsilon 收益由价格相除得到，恰在区间边界（如 100→103）时会有浮点误差，判定时放宽该容差
+const holdBandEpsilon = 1e-9
+
+// hit 判断建议在区间收益 r（%）下是否正确
+func hit(action llm.Action, r, holdBand float64) bool {
+	switch action {
+	case llm.ActionBuy:
+		return r > 0
+	case llm.ActionSell:
+		return r < 0
+	case llm.ActionHold:
+		return math.Abs(r) <= holdBand+holdBandEpsilon
+	default:
+		return false
+	}
+}
+
+// confidenceBuckets 信心指数分组，按从低到高排列，决策未给出信心指数（0）的归入“未记录”
+var confidenceBuckets = []string{"1-59", "60-69", "70-79", "80-89", "90-100", unrecorded}
+
+func confidenceBucket(confidence int) string {
+	switch {
+	case confidence <= 0:
+		return unrecorded
+	case confidence < 60:
+		return confidenceBuckets[0]
+	case confidence < 70:
+		return confidenceBuckets[1]
+	case confidence < 80:
+		return confidenceBuckets[2]
+	case confidence < 90:
+		return confidenceBuckets[3]
+	default:
+		return confidenceBuckets[4]
+	}
+}
+
+// closeTime 交易日的收盘时间（北京时间15:00）
+func closeTime(date string) (time.Time, bool) {
+	day, err := time.ParseInLocation("2006-01-02", date, market.Shanghai)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day.Add(15 * time.Hour), true
+}
+
+func orUnrecorded(s string) string {
+	if s == "" {
+		return unrecorded
+	}
+	return s
+}
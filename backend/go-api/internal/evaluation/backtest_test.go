@@ -0,0 +1,149 @@
+package evaluation
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"stock-analysis-api/backend/go-api/internal/market"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/store"
+)
+
+// fakePrices 只实现 History 的数据源，未登记的股票返回 ErrNotSupported
+type fakePrices struct {
+	datasource.MarketDataProvider
+	bars map[string][]model.Bar
+}
+
+func (f *fakePrices) History(ctx context.Context, code string, start, end time.Time) ([]model.Bar, error) {
+	bars, ok := f.bars[code]
+	if !ok {
+		return nil, datasource.ErrNotSupported
+	}
+	return bars, nil
+}
+
+func shanghai(value string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, market.Shanghai)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRun(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	reports := store.NewReportStore(db)
+
+	// 国庆休市（10-01 至 10-07）期间没有K线，周期按交易日计算
+	provider := &fakePrices{bars: map[string][]model.Bar{
+		"600519": {
+			{Date: "2024-09-26", Close: 100},
+			{Date: "2024-09-27", Close: 102},
+			{Date: "2024-09-30", Close: 104},
+			{Date: "2024-10-08", Close: 110},
+			{Date: "2024-10-09", Close: 103},
+			{Date: "2024-10-10", Close: 99},
+		},
+	}}
+
+	recs := []struct {
+		code      string
+		action    llm.Action
+		startedAt string
+	}{
+		{"600519", llm.ActionHold, "2024-09-26 15:00"}, // 恰在收盘时生成，以当日收盘价建仓
+		{"600519", llm.ActionBuy, "2024-09-27 10:00"},  // 盘中生成，以当日收盘价建仓
+		{"600519", llm.ActionSell, "2024-09-30 16:00"}, // 节前收盘后生成，以节后首个交易日收盘价建仓
+		{"600519", llm.ActionBuy, "2024-10-10 16:00"},  // 之后没有收盘价，无法建仓
+		{"000858", llm.ActionBuy, "2024-09-27 10:00"},  // 没有K线，跳过
+	}
+	for _, r := range recs {
+		started := shanghai(r.startedAt)
+		err := reports.Save(&store.Report{
+			Code: r.code, Status: "completed", FinalAction: r.action, FinalConfidence: 75,
+			StartedAt: started, FinishedAt: started.Add(time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := Run(context.Background(), reports, provider, Options{Horizons: []int{1, 2}, Details: true})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if result.Total != 4 || result.Skipped != 1 {
+		t.Fatalf("total/skipped = %d/%d, want 4/1", result.Total, result.Skipped)
+	}
+
+	want := []struct {
+		entryDate  string
+		entryPrice float64
+		returns    []model.Metric
+	}{
+		{"2024-09-26", 100, []model.Metric{model.Val(2), model.Val(4)}},
+		{"2024-09-27", 102, []model.Metric{model.Val(104.0/102*100 - 100), model.Val(110.0/102*100 - 100)}},
+		{"2024-10-08", 110, []model.Metric{model.Val(103.0/110*100 - 100), model.Val(99.0/110*100 - 100)}},
+		{"", 0, []model.Metric{{}, {}}},
+	}
+	for i, w := range want {
+		s := result.Samples[i]
+		if s.EntryDate != w.entryDate || s.EntryPrice != w.entryPrice {
+			t.Errorf("samples[%d] entry = %s %.2f, want %s %.2f", i, s.EntryDate, s.EntryPrice, w.entryDate, w.entryPrice)
+		}
+		for j, r := range w.returns {
+			got := s.Returns[j]
+			if got.Valid != r.Valid || got.Valid && !nearly(got.Value, r.Value) {
+				t.Errorf("samples[%d].Returns[%d] = %+v, want %+v", i, j, got, r)
+			}
+		}
+	}
+
+	// 1日：持有+2%、买入+1.96%、卖出-6.36% 均命中；2日：持有+4%超出区间
+	overall := result.Overall.Horizons
+	if overall[0].Evaluated != 3 || overall[0].Hits != 3 || overall[1].Evaluated != 3 || overall[1].Hits != 2 {
+		t.Errorf("overall = %+v", overall)
+	}
+}
+
+func TestHitHoldBand(t *testing.T) {
+	tests := []struct {
+		name   string
+		action llm.Action
+		r      float64
+		want   bool
+	}{
+		{name: "hold upper edge", action: llm.ActionHold, r: (103.0/100 - 1) * 100, want: true},
+		{name: "hold lower edge", action: llm.ActionHold, r: (97.0/100 - 1) * 100, want: true},
+		{name: "hold above band", action: llm.ActionHold, r: 3.01, want: false},
+		{name: "hold below band", action: llm.ActionHold, r: -3.01, want: false},
+		{name: "hold flat", action: llm.ActionHold, r: 0, want: true},
+		{name: "buy flat", action: llm.ActionBuy, r: 0, want: false},
+		{name: "buy gain", action: llm.ActionBuy, r: 0.5, want: true},
+		{name: "sell flat", action: llm.ActionSell, r: 0, want: false},
+		{name: "sell loss", action: llm.ActionSell, r: -0.5, want: true},
+		{name: "unknown action", action: "", r: 1, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hit(tt.action, tt.r, DefaultHoldBand); got != tt.want {
+				t.Errorf("hit(%q, %v) = %v, want %v", tt.action, tt.r, got, tt.want)
+			}
+		})
+	}
+}
+
+func nearly(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
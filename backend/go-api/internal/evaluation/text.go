@@ -0,0 +1,58 @@
+package evaluation
+
+import (
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"strings"
+)
+
+// Text 以Markdown表格输出回测结果，供命令行使用
+func (r *Result) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "回测建议 %d 条（无K线跳过 %d 条），持有判定区间 ±%.2f%%，生成于 %s\n",
+		r.Total, r.Skipped, r.HoldBand, r.GeneratedAt.Format("2006-01-02 15:04:05"))
+	b.WriteString("命中率括号内为 命中/已满周期 条数；方向收益按建议方向操作（买入计收益、卖出取反，不含持有）\n")
+
+	sections := []struct {
+		title  string
+		groups []Group
+	}{
+		{"总体", []Group{r.Overall}},
+		{"按操作建议", r.ByAction},
+		{"按信心指数", r.ByConfidence},
+		{"按模型", r.ByModel},
+		{"按提示词版本", r.ByPromptVersion},
+	}
+	for _, sec := range sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", sec.title)
+		b.WriteString("| 分组 | 建议数 |")
+		for _, h := range r.Horizons {
+			fmt.Fprintf(&b, " %d日命中率 | %d日平均收益 | %d日方向收益 |", h, h, h)
+		}
+		b.WriteString("\n|------|------|")
+		b.WriteString(strings.Repeat("------|------|------|", len(r.Horizons)))
+		b.WriteString("\n")
+		for _, g := range sec.groups {
+			fmt.Fprintf(&b, "| %s | %d |", g.Key, g.Count)
+			for _, st := range g.Horizons {
+				fmt.Fprintf(&b, " %s | %s | %s |", hitText(st), percent(st.AvgReturn), percent(st.AvgActionReturn))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func hitText(st Stats) string {
+	if !st.HitRate.Valid {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%% (%d/%d)", st.HitRate.Value, st.Hits, st.Evaluated)
+}
+
+func percent(m model.Metric) string {
+	if !m.Valid {
+		return "-"
+	}
+	return fmt.Sprintf("%+.2f%%", m.Value)
+}
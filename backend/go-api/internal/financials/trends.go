@@ -0,0 +1,261 @@
+// Package financials 基于多期财务摘要计算趋势指标（CAGR、TTM、利润率变化、ROE稳定性），供LLM引用趋势而非单期数字
+package financials
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"strings"
+)
+
+// ErrInsufficientData 年度数据少于两期，无法计算趋势
+var ErrInsufficientData = errors.New("财务历史数据不足")
+
+// Trends 财务趋势。无法计算的指标为缺失值（如基期亏损时的CAGR、缺少上年同期时的TTM）
+type Trends struct {
+	Annual    []model.FinancialPeriod `json:"annual"`
+	Quarterly []model.FinancialPeriod `json:"quarterly"`
+
+	CAGRYears   int          `json:"cagr_years"`
+	RevenueCAGR model.Metric `json:"revenue_cagr"` // 营收年复合增长率（%）
+	ProfitCAGR  model.Metric `json:"profit_cagr"`  // 净利润年复合增长率（%）
+
+	TTMReport    string       `json:"ttm_report,omitempty"` // TTM截至的报告期
+	TTMRevenue   model.Metric `json:"ttm_revenue"`          // 亿元
+	TTMNetProfit model.Metric `json:"ttm_net_profit"`       // 亿元
+	TTMNetMargin model.Metric `json:"ttm_net_margin"`       // %
+
+	GrossMarginChange    model.Metric `json:"gross_margin_change"`     // 首末年度毛利率变化（百分点）
+	NetMarginChange      model.Metric `json:"net_margin_change"`       // 首末年度净利率变化（百分点）
+	GrossMarginChangeYoY model.Metric `json:"gross_margin_change_yoy"` // 最新报告期毛利率较上年同期变化（百分点）
+
+	ROEMean      model.Metric `json:"roe_mean"`
+	ROEStd       model.Metric `json:"roe_std"`
+	ROEMin       model.Metric `json:"roe_min"`
+	ROEStability string       `json:"roe_stability"` // 稳定/小幅波动/波动较大，有效年度不足两个时为空
+}
+
+// Analyze 根据多期财务摘要计算趋势。年度数据用于CAGR、利润率变化和ROE稳定性，报告期数据用于TTM和同比
+func Analyze(history model.FinancialHistory) (*Trends, error) {
+	annual := history.Annual
+	if len(annual) < 2 {
+		return nil, fmt.Errorf("%w: 需要至少2个年度，实际%d个", ErrInsufficientData, len(annual))
+	}
+	first, last := annual[0], annual[len(annual)-1]
+
+	t := &Trends{
+		Annual:            annual,
+		Quarterly:         history.Quarterly,
+		CAGRYears:         len(annual) - 1,
+		GrossMarginChange: diff(last.GrossMargin, first.GrossMargin),
+		NetMarginChange:   diff(last.NetMargin, first.NetMargin),
+	}
+	t.RevenueCAGR = cagr(first.Revenue, last.Revenue, t.CAGRYears)
+	t.ProfitCAGR = cagr(first.NetProfit, last.NetProfit, t.CAGRYears)
+
+	roes := make([]float64, 0, len(annual))
+	for _, p := range annual {
+		if p.ROE.Valid {
+			roes = append(roes, p.ROE.Value)
+		}
+	}
+	if len(roes) >= 2 {
+		mean, std, minimum := meanStd(roes)
+		t.ROEMean, t.ROEStd, t.ROEMin = model.Val(mean), model.Val(std), model.Val(minimum)
+		t.ROEStability = stability(mean, std)
+	}
+
+	t.ttm(history)
+	return t, nil
+}
+
+// ttm 用 最新累计值 + 上年年报 - 上年同期累计值 计算滚动十二个月数据
+func (t *Trends) ttm(history model.FinancialHistory) {
+	if len(history.Quarterly) == 0 {
+		return
+	}
+	latest := history.Quarterly[len(history.Quarterly)-1]
+	year, monthDay, ok := splitReportDate(latest.ReportDate)
+	if !ok {
+		return
+	}
+
+	if lastYear, ok := findPeriod(history, fmt.Sprintf("%d-%s", year-1, monthDay)); ok {
+		t.GrossMarginChangeYoY = diff(latest.GrossMargin, lastYear.GrossMargin)
+	}
+
+	revenue, profit := latest.Revenue, latest.NetProfit
+	if monthDay != "12-31" {
+		prevAnnual, ok1 := findPeriod(history, fmt.Sprintf("%d-12-31", year-1))
+		prevSame, ok2 := findPeriod(history, fmt.Sprintf("%d-%s", year-1, monthDay))
+		if !ok1 || !ok2 {
+			return
+		}
+		revenue = sum(revenue, prevAnnual.Revenue, neg(prevSame.Revenue))
+		profit = sum(profit, prevAnnual.NetProfit, neg(prevSame.NetProfit))
+	}
+	if !revenue.Valid && !profit.Valid {
+		return
+	}
+
+	t.TTMReport = latest.ReportDate
+	t.TTMRevenue = round(revenue)
+	t.TTMNetProfit = round(profit)
+	if revenue.Valid && profit.Valid && revenue.Value > 0 {
+		t.TTMNetMargin = model.Val(round2(profit.Value / revenue.Value * 100))
+	}
+}
+
+// Text 以文本形式描述财务趋势，用于提示词
+func (t *Trends) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- 年度营收(亿元): %s\n", series(t.Annual, func(p model.FinancialPeriod) model.Metric { return p.Revenue }, ""))
+	fmt.Fprintf(&sb, "- 年度净利润(亿元): %s\n", series(t.Annual, func(p model.FinancialPeriod) model.Metric { return p.NetProfit }, ""))
+	fmt.Fprintf(&sb, "- %d年复合增长: 营收 %s, 净利润 %s\n", t.CAGRYears, percent(t.RevenueCAGR), percent(t.ProfitCAGR))
+	if t.TTMReport != "" {
+		fmt.Fprintf(&sb, "- TTM(截至%s): 营收 %s, 净利润 %s, 净利率 %s\n",
+			t.TTMReport, withUnit(t.TTMRevenue, "亿元"), withUnit(t.TTMNetProfit, "亿元"), percent(t.TTMNetMargin))
+	}
+	fmt.Fprintf(&sb, "- 毛利率: %s（%s）\n",
+		series(t.Annual, func(p model.FinancialPeriod) model.Metric { return p.GrossMargin }, "%"), points(t.GrossMarginChange))
+	fmt.Fprintf(&sb, "- 净利率: %s（%s）\n",
+		series(t.Annual, func(p model.FinancialPeriod) model.Metric { return p.NetMargin }, "%"), points(t.NetMarginChange))
+	if t.GrossMarginChangeYoY.Valid {
+		fmt.Fprintf(&sb, "- 最新报告期毛利率较上年同期: %s\n", points(t.GrossMarginChangeYoY))
+	}
+	roeSeries := series(t.Annual, func(p model.FinancialPeriod) model.Metric { return p.ROE }, "%")
+	if t.ROEStability != "" {
+		fmt.Fprintf(&sb, "- ROE: %s, 均值 %.2f%%, 标准差 %.2f, 最低 %.2f%%（%s）\n",
+			roeSeries, t.ROEMean.Value, t.ROEStd.Value, t.ROEMin.Value, t.ROEStability)
+	} else {
+		fmt.Fprintf(&sb, "- ROE: %s\n", roeSeries)
+	}
+	return sb.String()
+}
+
+// cagr 年复合增长率（%）。首末期任一缺失或非正时无意义，返回缺失值
+func cagr(first, last model.Metric, years int) model.Metric {
+	if !first.Valid || !last.Valid || first.Value <= 0 || last.Value <= 0 || years <= 0 {
+		return model.Metric{}
+	}
+	return model.Val(round2((math.Pow(last.Value/first.Value, 1/float64(years)) - 1) * 100))
+}
+
+// diff 返回 a-b，任一缺失时返回缺失值
+func diff(a, b model.Metric) model.Metric {
+	if !a.Valid || !b.Valid {
+		return model.Metric{}
+	}
+	return model.Val(round2(a.Value - b.Value))
+}
+
+// sum 求和，任一缺失时返回缺失值
+func sum(values ...model.Metric) model.Metric {
+	var total float64
+	for _, v := range values {
+		if !v.Valid {
+			return model.Metric{}
+		}
+		total += v.Value
+	}
+	return model.Val(total)
+}
+
+func neg(m model.Metric) model.Metric {
+	m.Value = -m.Value
+	return m
+}
+
+func round(m model.Metric) model.Metric {
+	if m.Valid {
+		m.Value = round2(m.Value)
+	}
+	return m
+}
+
+func meanStd(values []float64) (mean, std, minimum float64) {
+	minimum = values[0]
+	for _, v := range values {
+		mean += v
+		minimum = math.Min(minimum, v)
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		std += (v - mean) * (v - mean)
+	}
+	std = math.Sqrt(std / float64(len(values)))
+	return round2(mean), round2(std), round2(minimum)
+}
+
+// stability 按变异系数（标准差/均值）划分ROE稳定性
+func stability(mean, std float64) string {
+	if mean <= 0 {
+		return "波动较大"
+	}
+	switch cv := std / mean; {
+	case cv < 0.1:
+		return "稳定"
+	case cv < 0.25:
+		return "小幅波动"
+	default:
+		return "波动较大"
+	}
+}
+
+func findPeriod(history model.FinancialHistory, date string) (model.FinancialPeriod, bool) {
+	for _, periods := range [][]model.FinancialPeriod{history.Quarterly, history.Annual} {
+		for _, p := range periods {
+			if p.ReportDate == date {
+				return p, true
+			}
+		}
+	}
+	return model.FinancialPeriod{}, false
+}
+
+// splitReportDate 将 YYYY-MM-DD 拆分为年份和 MM-DD
+func splitReportDate(date string) (int, string, bool) {
+	var year int
+	if len(date) != 10 || date[4] != '-' {
+		return 0, "", false
+	}
+	if _, err := fmt.Sscanf(date[:4], "%d", &year); err != nil {
+		return 0, "", false
+	}
+	return year, date[5:], true
+}
+
+// series 格式化为 "2021 1094.64 → 2022 1275.54"，缺失的年度显示“数据缺失”
+func series(periods []model.FinancialPeriod, value func(model.FinancialPeriod) model.Metric, unit string) string {
+	parts := make([]string, len(periods))
+	for i, p := range periods {
+		parts[i] = fmt.Sprintf("%s %s", p.ReportDate[:min(4, len(p.ReportDate))], withUnit(value(p), unit))
+	}
+	return strings.Join(parts, " → ")
+}
+
+func withUnit(m model.Metric, unit string) string {
+	if !m.Valid {
+		return model.MissingText
+	}
+	return fmt.Sprintf("%.2f%s", m.Value, unit)
+}
+
+func percent(m model.Metric) string {
+	if !m.Valid {
+		return "无法计算"
+	}
+	return fmt.Sprintf("%.2f%%", m.Value)
+}
+
+func points(m model.Metric) string {
+	if !m.Valid {
+		return "变化无法计算"
+	}
+	return fmt.Sprintf("%+.2f个百分点", m.Value)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,198 @@
+package financials
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"stock-analysis-api/backend/go-api/internal/model"
+)
+
+var missing = model.Metric{}
+
+func annual(year string, revenue, profit, roe model.Metric) model.FinancialPeriod {
+	return model.FinancialPeriod{ReportDate: year + "-12-31", Revenue: revenue, NetProfit: profit, ROE: roe}
+}
+
+func TestAnalyzeInsufficientData(t *testing.T) {
+	history := model.FinancialHistory{Annual: []model.FinancialPeriod{annual("2023", model.Val(100), model.Val(10), model.Val(15))}}
+	if _, err := Analyze(history); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("err = %v, want ErrInsufficientData", err)
+	}
+}
+
+func TestAnalyzeCAGR(t *testing.T) {
+	tests := []struct {
+		name        string
+		first, last [2]model.Metric // 营收、净利润
+		wantRevenue model.Metric
+		wantProfit  model.Metric
+	}{
+		{
+			name:        "growth",
+			first:       [2]model.Metric{model.Val(100), model.Val(10)},
+			last:        [2]model.Metric{model.Val(121), model.Val(14.4)},
+			wantRevenue: model.Val(10),
+			wantProfit:  model.Val(20),
+		},
+		{
+			name:        "zero base",
+			first:       [2]model.Metric{model.Val(100), model.Val(0)},
+			last:        [2]model.Metric{model.Val(100), model.Val(5)},
+			wantRevenue: model.Val(0),
+			wantProfit:  missing,
+		},
+		{
+			name:        "loss base",
+			first:       [2]model.Metric{model.Val(100), model.Val(-3)},
+			last:        [2]model.Metric{model.Val(100), model.Val(5)},
+			wantRevenue: model.Val(0),
+			wantProfit:  missing,
+		},
+		{
+			name:        "missing endpoint",
+			first:       [2]model.Metric{missing, model.Val(10)},
+			last:        [2]model.Metric{model.Val(121), missing},
+			wantRevenue: missing,
+			wantProfit:  missing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := model.FinancialHistory{Annual: []model.FinancialPeriod{
+				annual("2021", tt.first[0], tt.first[1], missing),
+				annual("2022", model.Val(110), model.Val(12), missing),
+				annual("2023", tt.last[0], tt.last[1], missing),
+			}}
+			got, err := Analyze(history)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if got.CAGRYears != 2 {
+				t.Errorf("CAGRYears = %d, want 2", got.CAGRYears)
+			}
+			if got.RevenueCAGR != tt.wantRevenue {
+				t.Errorf("RevenueCAGR = %+v, want %+v", got.RevenueCAGR, tt.wantRevenue)
+			}
+			if got.ProfitCAGR != tt.wantProfit {
+				t.Errorf("ProfitCAGR = %+v, want %+v", got.ProfitCAGR, tt.wantProfit)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTTM(t *testing.T) {
+	years := []model.FinancialPeriod{
+		annual("2022", model.Val(100), model.Val(18), model.Val(20)),
+		annual("2023", model.Val(110), model.Val(20), model.Val(22)),
+	}
+	period := func(date string, revenue, profit, grossMargin model.Metric) model.FinancialPeriod {
+		return model.FinancialPeriod{ReportDate: date, Revenue: revenue, NetProfit: profit, GrossMargin: grossMargin}
+	}
+
+	tests := []struct {
+		name          string
+		quarterly     []model.FinancialPeriod
+		wantReport    string
+		wantRevenue   model.Metric
+		wantProfit    model.Metric
+		wantMargin    model.Metric
+		wantGrossDiff model.Metric
+	}{
+		{
+			name: "half year",
+			quarterly: []model.FinancialPeriod{
+				period("2023-06-30", model.Val(50), model.Val(10), model.Val(90)),
+				period("2024-06-30", model.Val(60), model.Val(12), model.Val(91.5)),
+			},
+			wantReport:    "2024-06-30",
+			wantRevenue:   model.Val(120),
+			wantProfit:    model.Val(22),
+			wantMargin:    model.Val(18.33),
+			wantGrossDiff: model.Val(1.5),
+		},
+		{
+			name: "latest is annual report",
+			quarterly: []model.FinancialPeriod{
+				period("2023-09-30", model.Val(80), model.Val(15), missing),
+				period("2023-12-31", model.Val(110), model.Val(20), missing),
+			},
+			wantReport:  "2023-12-31",
+			wantRevenue: model.Val(110),
+			wantProfit:  model.Val(20),
+			wantMargin:  model.Val(18.18),
+		},
+		{
+			name: "missing same quarter last year",
+			quarterly: []model.FinancialPeriod{
+				period("2023-12-31", model.Val(110), model.Val(20), model.Val(90)),
+				period("2024-06-30", model.Val(60), model.Val(12), model.Val(91.5)),
+			},
+		},
+		{
+			name: "zero gross margin last year",
+			quarterly: []model.FinancialPeriod{
+				period("2023-06-30", model.Val(50), missing, model.Val(0)),
+				period("2024-06-30", model.Val(60), model.Val(12), model.Val(5)),
+			},
+			wantReport:    "2024-06-30",
+			wantRevenue:   model.Val(120),
+			wantProfit:    missing,
+			wantMargin:    missing,
+			wantGrossDiff: model.Val(5),
+		},
+		{
+			name: "no quarterly data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Analyze(model.FinancialHistory{Annual: years, Quarterly: tt.quarterly})
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if got.TTMReport != tt.wantReport {
+				t.Errorf("TTMReport = %q, want %q", got.TTMReport, tt.wantReport)
+			}
+			if got.TTMRevenue != tt.wantRevenue || got.TTMNetProfit != tt.wantProfit || got.TTMNetMargin != tt.wantMargin {
+				t.Errorf("TTM = %+v/%+v/%+v, want %+v/%+v/%+v",
+					got.TTMRevenue, got.TTMNetProfit, got.TTMNetMargin, tt.wantRevenue, tt.wantProfit, tt.wantMargin)
+			}
+			if got.GrossMarginChangeYoY != tt.wantGrossDiff {
+				t.Errorf("GrossMarginChangeYoY = %+v, want %+v", got.GrossMarginChangeYoY, tt.wantGrossDiff)
+			}
+		})
+	}
+}
+
+func TestAnalyzeROEStability(t *testing.T) {
+	tests := []struct {
+		name string
+		roes []model.Metric
+		want string
+	}{
+		{name: "stable", roes: []model.Metric{model.Val(20), model.Val(21), model.Val(20.5)}, want: "稳定"},
+		{name: "volatile", roes: []model.Metric{model.Val(5), model.Val(20), model.Val(12)}, want: "波动较大"},
+		{name: "missing years skipped", roes: []model.Metric{model.Val(20), missing, model.Val(20)}, want: "稳定"},
+		{name: "one valid year", roes: []model.Metric{missing, missing, model.Val(20)}, want: ""},
+		{name: "losses", roes: []model.Metric{model.Val(-5), model.Val(-4), model.Val(-6)}, want: "波动较大"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			periods := make([]model.FinancialPeriod, len(tt.roes))
+			for i, roe := range tt.roes {
+				periods[i] = annual(strconv.Itoa(2021+i), model.Val(100), model.Val(10), roe)
+			}
+			got, err := Analyze(model.FinancialHistory{Annual: periods})
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+			if got.ROEStability != tt.want {
+				t.Errorf("ROEStability = %q, want %q", got.ROEStability, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/alert"
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/securities"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhooks 每个用户的 Webhook 数量上限
+const maxWebhooks = 5
+
+type AlertHandler struct {
+	alerts     *store.AlertStore
+	webhooks   *store.WebhookStore
+	securities *securities.Master
+	maxRules   int
+	allowHosts []string // 允许指向内网地址的 Webhook 主机
+}
+
+func NewAlertHandler(alerts *store.AlertStore, webhooks *store.WebhookStore, master *securities.Master, maxRules int, allowHosts []string) *AlertHandler {
+	return &AlertHandler{alerts: alerts, webhooks: webhooks, securities: master, maxRules: maxRules, allowHosts: allowHosts}
+}
+
+// ListRules 当前用户的提醒规则
+func (h *AlertHandler) ListRules(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	rules, err := h.alerts.ListRules(userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"rules": rules})
+}
+
+// CreateRule 创建提醒规则
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	var req model.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	sym, err := resolveCode(h.securities, req.Code)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	rule := &store.AlertRule{
+		UserID:    userID,
+		Code:      sym.Code,
+		Name:      sym.Name,
+		Kind:      strings.TrimSpace(req.Kind),
+		Threshold: req.Threshold,
+	}
+	if rule.From, err = parseAction(req.From); err == nil {
+		rule.To, err = parseAction(req.To)
+	}
+	if err == nil {
+		err = alert.Validate(rule)
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	n, err := h.alerts.CountRules(userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if n >= h.maxRules {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("每个用户最多%d条提醒规则", h.maxRules)})
+		return
+	}
+	if err := h.alerts.CreateRule(rule); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, rule)
+}
+
+// DeleteRule 删除提醒规则
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "提醒规则")
+	if !ok {
+		return
+	}
+	if err := h.alerts.DeleteRule(userID, id); err != nil {
+		writeAlertError(c, err, "提醒规则不存在")
+		return
+	}
+	c.Status(204)
+}
+
+// ListEvents 当前用户最近触发的提醒及推送状态，查询参数: limit(默认20，最大100)
+func (h *AlertHandler) ListEvents(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	limit, err := queryInt(c, "limit", store.DefaultListLimit)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	limit = store.ClampLimit(limit)
+	events, err := h.alerts.ListEvents(userID, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"events": events, "limit": limit})
+}
+
+// ListWebhooks 当前用户的 Webhook，不返回密钥
+func (h *AlertHandler) ListWebhooks(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	webhooks, err := h.webhooks.List(userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"webhooks": webhooks})
+}
+
+// CreateWebhook 创建 Webhook。未提供密钥时自动生成，密钥仅在此响应中返回
+func (h *AlertHandler) CreateWebhook(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	var req model.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	webhook := &store.Webhook{
+		UserID: userID,
+		URL:    strings.TrimSpace(req.URL),
+		Format: strings.TrimSpace(req.Format),
+		Secret: strings.TrimSpace(req.Secret),
+	}
+	if err := alert.CheckURL(webhook.URL, h.allowHosts); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if webhook.Format == "" {
+		webhook.Format = alert.FormatJSON
+	}
+	if !alert.ValidFormat(webhook.Format) {
+		c.JSON(400, gin.H{"error": "不支持的推送格式: " + webhook.Format + " (支持: json, wecom, dingtalk)"})
+		return
+	}
+	// 企业微信机器人不支持签名，钉钉机器人仅在开启“加签”时需要密钥，均不自动生成
+	if webhook.Secret == "" && webhook.Format == alert.FormatJSON {
+		secret, err := alert.NewSecret()
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	existing, err := h.webhooks.List(userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	if len(existing) >= maxWebhooks {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("每个用户最多%d个Webhook", maxWebhooks)})
+		return
+	}
+	if err := h.webhooks.Create(webhook); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, webhook)
+}
+
+// DeleteWebhook 删除 Webhook，尚未完成的推送随之取消
+func (h *AlertHandler) DeleteWebhook(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "Webhook")
+	if !ok {
+		return
+	}
+	if err := h.webhooks.Delete(userID, id); err != nil {
+		writeAlertError(c, err, "Webhook不存在")
+		return
+	}
+	c.Status(204)
+}
+
+// parseAction 解析请求中的操作建议，空字符串表示任意
+func parseAction(s string) (llm.Action, error) {
+	if strings.TrimSpace(s) == "" {
+		return "", nil
+	}
+	if action := llm.ParseAction(s); action != "" {
+		return action, nil
+	}
+	return "", fmt.Errorf("无法识别的操作建议: %s", s)
+}
+
+func writeAlertError(c *gin.Context, err error, notFound string) {
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(404, gin.H{"error": notFound})
+		return
+	}
+	c.JSON(500, gin.H{"error": err.Error()})
+}
@@ -7,17 +7,33 @@ import (
 	"io"
 	"log"
 	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/portfolio"
+	"stock-analysis-api/backend/go-api/internal/securities"
 	"stock-analysis-api/backend/go-api/internal/service"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 横向对比的股票数量范围
+const (
+	minCompare = 2
+	maxCompare = 5
+)
+
+// maxHoldings 组合分析的持仓数量上限
+const maxHoldings = 20
+
 type AnalyzeHandler struct {
 	orchestrator *service.AnalysisOrchestrator
+	runs         *service.RunStore
+	securities   *securities.Master
 }
 
-func NewAnalyzeHandler(orchestrator *service.AnalysisOrchestrator) *AnalyzeHandler {
-	return &AnalyzeHandler{orchestrator: orchestrator}
+func NewAnalyzeHandler(orchestrator *service.AnalysisOrchestrator, runs *service.RunStore, master *securities.Master) *AnalyzeHandler {
+	return &AnalyzeHandler{orchestrator: orchestrator, runs: runs, securities: master}
 }
 
 // StreamAnalyze SSE流式分析接口
@@ -28,41 +44,194 @@ func (h *AnalyzeHandler) StreamAnalyze(c *gin.Context) {
 		return
 	}
 
+	// 在打开SSE流之前校验代码，无效输入直接返回400
+	sym, err := symbol.Parse(req.Code)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	sym = resolveName(h.securities, sym)
+
+	// 启动分析运行，运行生命周期独立于本次请求，客户端断线后可通过运行ID重连
+	run := h.runs.Start(sym.String(), func(ctx context.Context, eventChan chan<- service.SSEEvent) error {
+		return h.orchestrator.Analyze(ctx, sym, service.AnalyzeOptions{ForceRefresh: req.ForceRefresh}, eventChan)
+	})
+
+	h.streamRun(c, run, 0)
+}
+
+// StreamCompare SSE流式多股横向对比接口，事件格式与 StreamAnalyze 一致
+func (h *AnalyzeHandler) StreamCompare(c *gin.Context) {
+	var req model.StockCompareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if len(req.Codes) < minCompare || len(req.Codes) > maxCompare {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("对比需要%d-%d只股票，实际%d只", minCompare, maxCompare, len(req.Codes))})
+		return
+	}
+
+	syms := make([]symbol.Symbol, 0, len(req.Codes))
+	labels := make([]string, 0, len(req.Codes))
+	seen := make(map[string]bool, len(req.Codes))
+	for _, code := range req.Codes {
+		sym, err := symbol.Parse(code)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		sym = resolveName(h.securities, sym)
+		if seen[sym.String()] {
+			c.JSON(400, gin.H{"error": "对比的股票重复: " + sym.String()})
+			return
+		}
+		seen[sym.String()] = true
+		syms = append(syms, sym)
+		labels = append(labels, sym.String())
+	}
+
+	run := h.runs.Start(strings.Join(labels, ","), func(ctx context.Context, eventChan chan<- service.SSEEvent) error {
+		return h.orchestrator.Compare(ctx, syms, eventChan)
+	})
+
+	h.streamRun(c, run, 0)
+}
+
+// StreamPortfolio SSE流式持仓组合分析接口，事件格式与 StreamAnalyze 一致
+func (h *AnalyzeHandler) StreamPortfolio(c *gin.Context) {
+	var req model.PortfolioAnalyzeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if len(req.Holdings) == 0 || len(req.Holdings) > maxHoldings {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("持仓需要1-%d只股票，实际%d只", maxHoldings, len(req.Holdings))})
+		return
+	}
+
+	holdings := make([]portfolio.Holding, 0, len(req.Holdings))
+	labels := make([]string, 0, len(req.Holdings))
+	seen := make(map[string]bool, len(req.Holdings))
+	for _, item := range req.Holdings {
+		sym, err := symbol.Parse(item.Code)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		sym = resolveName(h.securities, sym)
+		if seen[sym.String()] {
+			c.JSON(400, gin.H{"error": "持仓股票重复: " + sym.String() + "，请合并为一笔持仓"})
+			return
+		}
+		seen[sym.String()] = true
+		if !(item.Shares > 0) {
+			c.JSON(400, gin.H{"error": "持股数必须为正数: " + sym.String()})
+			return
+		}
+		if !(item.Cost >= 0) {
+			c.JSON(400, gin.H{"error": "成本价不能为负数: " + sym.String()})
+			return
+		}
+		holdings = append(holdings, portfolio.Holding{Symbol: sym, Shares: item.Shares, Cost: item.Cost})
+		labels = append(labels, sym.String())
+	}
+
+	run := h.runs.Start(strings.Join(labels, ","), func(ctx context.Context, eventChan chan<- service.SSEEvent) error {
+		return h.orchestrator.AnalyzePortfolio(ctx, holdings, eventChan)
+	})
+
+	h.streamRun(c, run, 0)
+}
+
+// resolveName 名称输入优先用本地证券列表解析为代码，未命中时交由数据服务查找
+func resolveName(master *securities.Master, sym symbol.Symbol) symbol.Symbol {
+	if !sym.IsName() {
+		return sym
+	}
+	sec, ok := master.Resolve(sym.Name)
+	if !ok {
+		return sym
+	}
+	resolved, err := symbol.FromCode(sec.Code)
+	if err != nil {
+		return sym
+	}
+	resolved.Name = sec.Name
+	return resolved
+}
+
+// ResumeEvents 断线重连接口，根据 Last-Event-ID 回放遗漏事件后继续推送实时事件
+func (h *AnalyzeHandler) ResumeEvents(c *gin.Context) {
+	run, ok := h.runs.Get(c.Param("runId"))
+	if !ok {
+		c.JSON(404, gin.H{"error": "分析运行不存在或已过期"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		// 小程序等无法自定义重连头的客户端可使用查询参数
+		lastEventID = c.Query("last_event_id")
+	}
+
+	var lastID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(400, gin.H{"error": "Last-Event-ID 无效: " + lastEventID})
+			return
+		}
+		lastID = id
+	}
+
+	h.streamRun(c, run, lastID)
+}
+
+// streamRun 从lastID之后开始向客户端推送运行事件，直到运行结束或客户端断开
+func (h *AnalyzeHandler) streamRun(c *gin.Context, run *service.Run, lastID int64) {
 	// 设置SSE响应头
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Access-Control-Expose-Headers", "X-Run-ID")
+	c.Header("X-Run-ID", run.ID)
 
-	// 创建事件通道
-	eventChan := make(chan service.SSEEvent, 10)
+	run.Subscribe()
+	defer run.Unsubscribe()
 
-	// 启动分析
-	ctx := context.Background()
-	go func() {
-		if err := h.orchestrator.Analyze(ctx, req.Code, eventChan); err != nil {
-			log.Printf("分析失败: %v", err)
-			// 错误已经在orchestrator中发送到eventChan，这里只记录日志
-		}
-	}()
+	ctx := c.Request.Context()
 
 	// 流式发送事件
 	c.Stream(func(w io.Writer) bool {
-		event, ok := <-eventChan
-		if !ok {
-			return false
+		events, finished, notify := run.EventsAfter(lastID)
+		if len(events) == 0 {
+			if finished {
+				return false
+			}
+			select {
+			case <-notify:
+				return true
+			case <-ctx.Done():
+				return false
+			}
 		}
 
-		// 序列化数据
-		dataJSON, err := json.Marshal(event.Data)
-		if err != nil {
-			log.Printf("序列化失败: %v", err)
-			return false
-		}
+		for _, event := range events {
+			// 序列化数据
+			dataJSON, err := json.Marshal(event.Data)
+			if err != nil {
+				log.Printf("序列化失败: %v", err)
+				return false
+			}
 
-		// 发送SSE格式
-		fmt.Fprintf(w, "event: %s\n", event.Event)
-		fmt.Fprintf(w, "data: %s\n\n", dataJSON)
+			// 发送SSE格式
+			fmt.Fprintf(w, "id: %d\n", event.ID)
+			fmt.Fprintf(w, "event: %s\n", event.Event)
+			fmt.Fprintf(w, "data: %s\n\n", dataJSON)
+			lastID = event.ID
+		}
 		c.Writer.Flush()
 
 		return true
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/evaluation"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EvaluationHandler struct {
+	reports  *store.ReportStore
+	provider datasource.MarketDataProvider
+}
+
+func NewEvaluationHandler(reports *store.ReportStore, provider datasource.MarketDataProvider) *EvaluationHandler {
+	return &EvaluationHandler{reports: reports, provider: provider}
+}
+
+// Evaluate 回测历史报告最终决策的前瞻收益与命中率
+// 查询参数: code, from/to(2006-01-02，to含当天), horizons(逗号分隔的交易日数，默认5,20,60), hold_band(%，默认3), details(true返回明细)
+func (h *EvaluationHandler) Evaluate(c *gin.Context) {
+	opts := evaluation.Options{
+		Filter:  store.ReportFilter{Code: c.Query("code")},
+		Details: c.Query("details") == "true",
+	}
+
+	if from := c.Query("from"); from != "" {
+		day, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "from 格式应为 YYYY-MM-DD"})
+			return
+		}
+		opts.Filter.From = day
+	}
+	if to := c.Query("to"); to != "" {
+		day, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "to 格式应为 YYYY-MM-DD"})
+			return
+		}
+		opts.Filter.To = day.AddDate(0, 0, 1)
+	}
+
+	var err error
+	if opts.Horizons, err = evaluation.ParseHorizons(c.Query("horizons")); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if band := c.Query("hold_band"); band != "" {
+		if opts.HoldBand, err = strconv.ParseFloat(band, 64); err != nil || opts.HoldBand <= 0 {
+			c.JSON(400, gin.H{"error": "hold_band 参数无效"})
+			return
+		}
+	}
+
+	result, err := evaluation.Run(c.Request.Context(), h.reports, h.provider, opts)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, result)
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	reports *store.ReportStore
+}
+
+func NewReportHandler(reports *store.ReportStore) *ReportHandler {
+	return &ReportHandler{reports: reports}
+}
+
+// ListReports 历史报告列表，支持按股票代码、状态和日期过滤
+// 查询参数: code, status, date(2006-01-02), from/to(2006-01-02，to含当天), limit(默认20，最大100), offset
+func (h *ReportHandler) ListReports(c *gin.Context) {
+	filter := store.ReportFilter{
+		Code:   c.Query("code"),
+		Status: c.Query("status"),
+	}
+
+	var err error
+	if filter.Limit, err = queryInt(c, "limit", store.DefaultListLimit); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// 响应中返回实际生效的条数
+	filter.Limit = store.ClampLimit(filter.Limit)
+	if filter.Offset, err = queryInt(c, "offset", 0); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if date := c.Query("date"); date != "" {
+		day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "date 格式应为 YYYY-MM-DD"})
+			return
+		}
+		filter.From, filter.To = day, day.AddDate(0, 0, 1)
+	}
+	if from := c.Query("from"); from != "" {
+		day, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "from 格式应为 YYYY-MM-DD"})
+			return
+		}
+		filter.From = day
+	}
+	if to := c.Query("to"); to != "" {
+		day, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "to 格式应为 YYYY-MM-DD"})
+			return
+		}
+		filter.To = day.AddDate(0, 0, 1)
+	}
+
+	reports, err := h.reports.List(filter)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"reports": reports, "limit": filter.Limit, "offset": filter.Offset})
+}
+
+// GetReport 报告详情
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "报告ID无效"})
+		return
+	}
+
+	report, err := h.reports.Get(id)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "报告不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, report)
+}
+
+// queryInt 读取整数查询参数，缺省时返回默认值
+func queryInt(c *gin.Context, key string, defaultVal int) (int, error) {
+	val := c.Query(key)
+	if val == "" {
+		return defaultVal, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 0 {
+		return 0, errors.New(key + " 参数无效")
+	}
+	return n, nil
+}
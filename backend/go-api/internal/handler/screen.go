@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/screener"
+	"stock-analysis-api/backend/go-api/internal/service"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 选股分页与批量分析上限
+const (
+	defaultScreenPageSize = 20
+	maxScreenPageSize     = 100
+	maxScreenPage         = 10000
+	maxScreenAnalyze      = 5
+)
+
+type ScreenHandler struct {
+	screener     *screener.Screener
+	orchestrator *service.AnalysisOrchestrator
+	runs         *service.RunStore
+}
+
+func NewScreenHandler(s *screener.Screener, orchestrator *service.AnalysisOrchestrator, runs *service.RunStore) *ScreenHandler {
+	return &ScreenHandler{screener: s, orchestrator: orchestrator, runs: runs}
+}
+
+// screenRun 为选股结果发起的分析运行，通过 /analyze/{run_id}/events 获取事件
+type screenRun struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	RunID string `json:"run_id"`
+}
+
+// Screen 条件选股：按筛选表达式过滤全市场股票，排序分页后返回；
+// analyze_top>0 时对排序后的前N只股票发起分析，返回各运行ID
+func (h *ScreenHandler) Screen(c *gin.Context) {
+	var req model.ScreenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	var asc bool
+	switch strings.ToLower(req.Order) {
+	case "", "desc":
+	case "asc":
+		asc = true
+	default:
+		c.JSON(400, gin.H{"error": "order 只能为 asc 或 desc"})
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultScreenPageSize
+	}
+	if req.Page < 0 || req.PageSize < 0 {
+		c.JSON(400, gin.H{"error": "page、page_size 不能为负数"})
+		return
+	}
+	if req.Page > maxScreenPage {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("page 不能超过%d", maxScreenPage)})
+		return
+	}
+	req.PageSize = min(req.PageSize, maxScreenPageSize)
+	if req.AnalyzeTop < 0 || req.AnalyzeTop > maxScreenAnalyze {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("analyze_top 需要在0-%d之间", maxScreenAnalyze)})
+		return
+	}
+
+	filter, err := screener.Parse(req.Filter)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := h.screener.Screen(screener.Query{
+		Filter:   filter,
+		Sort:     req.Sort,
+		Asc:      asc,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Top:      req.AnalyzeTop,
+	})
+	switch {
+	case errors.Is(err, screener.ErrInvalidQuery):
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, screener.ErrNotReady):
+		c.JSON(503, gin.H{"error": "全市场数据加载中，请稍后再试"})
+		return
+	case err != nil:
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 运行生命周期独立于本次请求，分析结果照常保存为报告
+	runs := make([]screenRun, 0, len(result.Top))
+	for _, stock := range result.Top {
+		sym, err := symbol.FromCode(stock.Code)
+		if err != nil {
+			continue
+		}
+		sym.Name = stock.Name
+		run := h.runs.Start(sym.String(), func(ctx context.Context, eventChan chan<- service.SSEEvent) error {
+			return h.orchestrator.Analyze(ctx, sym, service.AnalyzeOptions{}, eventChan)
+		})
+		runs = append(runs, screenRun{Code: stock.Code, Name: stock.Name, RunID: run.ID})
+	}
+
+	c.JSON(200, gin.H{
+		"total":      result.Total,
+		"page":       result.Page,
+		"page_size":  result.PageSize,
+		"stocks":     result.Stocks,
+		"universe":   result.Universe,
+		"updated_at": result.UpdatedAt,
+		"runs":       runs,
+	})
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+	"stock-analysis-api/backend/go-api/internal/securities"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockHandler struct {
+	securities *securities.Master
+}
+
+func NewStockHandler(master *securities.Master) *StockHandler {
+	return &StockHandler{securities: master}
+}
+
+// SearchStocks 股票搜索，支持代码、名称、拼音首字母及模糊匹配，结果按匹配度排序
+// 查询参数: q, limit(默认10，最大50)
+func (h *StockHandler) SearchStocks(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(400, gin.H{"error": "q 不能为空"})
+		return
+	}
+	limit, err := queryInt(c, "limit", 10)
+	if err != nil || limit == 0 {
+		c.JSON(400, gin.H{"error": "limit 参数无效"})
+		return
+	}
+	limit = min(limit, 50)
+
+	results, err := h.securities.Search(query, limit)
+	if errors.Is(err, securities.ErrNotReady) {
+		c.JSON(503, gin.H{"error": "证券列表加载中，请稍后再试"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"query": query, "results": results, "updated_at": h.securities.UpdatedAt()})
+}
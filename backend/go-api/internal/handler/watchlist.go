@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/securities"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userIDPattern 用户标识由网关或前端（如小程序openid）通过 X-User-ID 请求头传入
+var userIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]{1,64}$`)
+
+const defaultWatchlistName = "自选股"
+
+type WatchlistHandler struct {
+	watchlists *store.WatchlistStore
+	batches    *store.BatchStore
+	securities *securities.Master
+	maxStocks  int
+}
+
+func NewWatchlistHandler(watchlists *store.WatchlistStore, batches *store.BatchStore, master *securities.Master, maxStocks int) *WatchlistHandler {
+	return &WatchlistHandler{watchlists: watchlists, batches: batches, securities: master, maxStocks: maxStocks}
+}
+
+// ListWatchlists 当前用户的自选股列表
+func (h *WatchlistHandler) ListWatchlists(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	lists, err := h.watchlists.List(userID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"watchlists": lists})
+}
+
+// CreateWatchlist 创建自选股列表，名称缺省为“自选股”
+func (h *WatchlistHandler) CreateWatchlist(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	var req model.WatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	name, err := watchlistName(req.Name)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if name == "" {
+		name = defaultWatchlistName
+	}
+	stocks, err := h.resolveStocks(req.Codes)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := h.watchlists.Create(userID, name, stocks)
+	if err != nil {
+		writeWatchlistError(c, err)
+		return
+	}
+	c.JSON(201, w)
+}
+
+// GetWatchlist 自选股列表详情，每只股票附带最近一次批量分析结果（latest.changed 表示建议较上次变化）
+func (h *WatchlistHandler) GetWatchlist(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "自选股列表")
+	if !ok {
+		return
+	}
+	w, err := h.watchlists.Get(userID, id)
+	if err != nil {
+		writeWatchlistError(c, err)
+		return
+	}
+	c.JSON(200, w)
+}
+
+// UpdateWatchlist 重命名自选股列表，提供 codes 时整体替换股票
+func (h *WatchlistHandler) UpdateWatchlist(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "自选股列表")
+	if !ok {
+		return
+	}
+	var req model.WatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	name, err := watchlistName(req.Name)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var stocks []store.WatchlistStock
+	if req.Codes != nil {
+		if stocks, err = h.resolveStocks(req.Codes); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if stocks == nil {
+			stocks = []store.WatchlistStock{}
+		}
+	}
+
+	w, err := h.watchlists.Update(userID, id, name, stocks)
+	if err != nil {
+		writeWatchlistError(c, err)
+		return
+	}
+	c.JSON(200, w)
+}
+
+// DeleteWatchlist 删除自选股列表
+func (h *WatchlistHandler) DeleteWatchlist(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "自选股列表")
+	if !ok {
+		return
+	}
+	if err := h.watchlists.Delete(userID, id); err != nil {
+		writeWatchlistError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// AddStock 向自选股列表添加一只股票
+func (h *WatchlistHandler) AddStock(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "自选股列表")
+	if !ok {
+		return
+	}
+	var req model.WatchlistStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	stocks, err := h.resolveStocks([]string{req.Code})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.watchlists.AddStock(userID, id, stocks[0], h.maxStocks); err != nil {
+		writeWatchlistError(c, err)
+		return
+	}
+
+	w, err := h.watchlists.Get(userID, id)
+	if err != nil {
+		writeWatchlistError(c, err)
+		return
+	}
+	c.JSON(200, w)
+}
+
+// RemoveStock 从自选股列表移除一只股票，:code 为6位代码
+func (h *WatchlistHandler) RemoveStock(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "自选股列表")
+	if !ok {
+		return
+	}
+	sym, err := symbol.Parse(c.Param("code"))
+	if err != nil || sym.IsName() {
+		c.JSON(400, gin.H{"error": "股票代码无效: " + c.Param("code")})
+		return
+	}
+	if err := h.watchlists.RemoveStock(userID, id, sym.Code); err != nil {
+		writeWatchlistError(c, err)
+		return
+	}
+	c.Status(204)
+}
+
+// ListBatchRuns 最近的自选股批量分析记录（仅统计信息），查询参数: limit(默认20，最大100)
+func (h *WatchlistHandler) ListBatchRuns(c *gin.Context) {
+	limit, err := queryInt(c, "limit", store.DefaultListLimit)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	limit = store.ClampLimit(limit)
+	runs, err := h.batches.ListRuns(limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"runs": runs, "limit": limit})
+}
+
+// resolveStocks 解析股票代码或名称并去重
+func (h *WatchlistHandler) resolveStocks(codes []string) ([]store.WatchlistStock, error) {
+	if len(codes) > h.maxStocks {
+		return nil, fmt.Errorf("自选股列表最多%d只股票，实际%d只", h.maxStocks, len(codes))
+	}
+	var stocks []store.WatchlistStock
+	seen := make(map[string]bool, len(codes))
+	for _, input := range codes {
+		sym, err := resolveCode(h.securities, input)
+		if err != nil {
+			return nil, err
+		}
+		if seen[sym.Code] {
+			continue
+		}
+		seen[sym.Code] = true
+		stocks = append(stocks, store.WatchlistStock{Code: sym.Code, Name: sym.Name})
+	}
+	return stocks, nil
+}
+
+// resolveCode 解析股票代码或名称，名称须能在本地证券列表中找到，代码的名称由证券列表补全
+func resolveCode(master *securities.Master, input string) (symbol.Symbol, error) {
+	sym, err := symbol.Parse(input)
+	if err != nil {
+		return sym, err
+	}
+	sym = resolveName(master, sym)
+	if sym.IsName() {
+		return sym, fmt.Errorf("%w: 未找到名称为 %s 的股票，请使用代码", symbol.ErrInvalid, sym.Name)
+	}
+	if sec, ok := master.Lookup(sym.Code); ok {
+		sym.Name = sec.Name
+	}
+	return sym, nil
+}
+
+// requireUser 读取 X-User-ID 请求头，缺失或格式无效时返回401
+func requireUser(c *gin.Context) (string, bool) {
+	userID := strings.TrimSpace(c.GetHeader("X-User-ID"))
+	if !userIDPattern.MatchString(userID) {
+		c.JSON(401, gin.H{"error": "缺少或无效的用户标识 X-User-ID"})
+		return "", false
+	}
+	return userID, true
+}
+
+// pathID 解析路径参数 :id，无效时返回400
+func pathID(c *gin.Context, what string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": what + "ID无效"})
+		return 0, false
+	}
+	return id, true
+}
+
+// watchlistName 校验列表名称：去除首尾空格后不超过32个字符
+func watchlistName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > 32 {
+		return "", errors.New("自选股列表名称不能超过32个字符")
+	}
+	return name, nil
+}
+
+func writeWatchlistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(404, gin.H{"error": "自选股列表或股票不存在"})
+	case errors.Is(err, store.ErrConflict):
+		c.JSON(409, gin.H{"error": err.Error()})
+	case errors.Is(err, store.ErrLimitExceeded):
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		c.JSON(500, gin.H{"error": err.Error()})
+	}
+}
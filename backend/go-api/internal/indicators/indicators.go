@@ -0,0 +1,221 @@
+// Package indicators 计算常用技术指标。
+// 所有函数返回与输入等长的序列，数据不足以计算的位置为 NaN；参数沿用国内行情软件的默认口径
+package indicators
+
+import (
+	"math"
+	"stock-analysis-api/backend/go-api/internal/model"
+)
+
+// SMA 简单移动平均
+func SMA(values []float64, n int) []float64 {
+	out := nanSeries(len(values))
+	if n <= 0 {
+		return out
+	}
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= n {
+			sum -= values[i-n]
+		}
+		if i >= n-1 {
+			out[i] = sum / float64(n)
+		}
+	}
+	return out
+}
+
+// EMA 指数移动平均，α = 2/(n+1)，以首个值为初值
+func EMA(values []float64, n int) []float64 {
+	out := nanSeries(len(values))
+	if n <= 0 || len(values) == 0 {
+		return out
+	}
+	alpha := 2 / float64(n+1)
+	out[0] = values[0]
+	for i := 1; i < len(values); i++ {
+		out[i] = alpha*values[i] + (1-alpha)*out[i-1]
+	}
+	return out
+}
+
+// MACDResult MACD指标，Hist 采用国内口径 2×(DIF-DEA)
+type MACDResult struct {
+	DIF  []float64
+	DEA  []float64
+	Hist []float64
+}
+
+// MACD 默认参数 (12, 26, 9)
+func MACD(closes []float64, fast, slow, signal int) MACDResult {
+	emaFast := EMA(closes, fast)
+	emaSlow := EMA(closes, slow)
+
+	dif := make([]float64, len(closes))
+	for i := range closes {
+		dif[i] = emaFast[i] - emaSlow[i]
+	}
+	dea := EMA(dif, signal)
+
+	hist := make([]float64, len(closes))
+	for i := range closes {
+		hist[i] = 2 * (dif[i] - dea[i])
+	}
+	return MACDResult{DIF: dif, DEA: dea, Hist: hist}
+}
+
+// RSI 相对强弱指标，采用Wilder平滑（与同花顺、通达信一致的SMA(x,n,1)）
+func RSI(closes []float64, n int) []float64 {
+	out := nanSeries(len(closes))
+	if n <= 0 || len(closes) <= n {
+		return out
+	}
+
+	var gain, loss float64
+	for i := 1; i <= n; i++ {
+		diff := closes[i] - closes[i-1]
+		if diff > 0 {
+			gain += diff
+		} else {
+			loss -= diff
+		}
+	}
+	gain /= float64(n)
+	loss /= float64(n)
+	out[n] = rsiValue(gain, loss)
+
+	for i := n + 1; i < len(closes); i++ {
+		diff := closes[i] - closes[i-1]
+		up, down := 0.0, 0.0
+		if diff > 0 {
+			up = diff
+		} else {
+			down = -diff
+		}
+		gain = (gain*float64(n-1) + up) / float64(n)
+		loss = (loss*float64(n-1) + down) / float64(n)
+		out[i] = rsiValue(gain, loss)
+	}
+	return out
+}
+
+func rsiValue(gain, loss float64) float64 {
+	if gain+loss == 0 {
+		return 50
+	}
+	return 100 * gain / (gain + loss)
+}
+
+// KDJResult KDJ随机指标
+type KDJResult struct {
+	K []float64
+	D []float64
+	J []float64
+}
+
+// KDJ 默认参数 (9, 3, 3)，K、D 初值为50
+func KDJ(bars []model.Bar, n, m1, m2 int) KDJResult {
+	size := len(bars)
+	result := KDJResult{K: nanSeries(size), D: nanSeries(size), J: nanSeries(size)}
+	if n <= 0 || size < n {
+		return result
+	}
+
+	k, d := 50.0, 50.0
+	for i := n - 1; i < size; i++ {
+		low, high := bars[i].Low, bars[i].High
+		for j := i - n + 1; j < i; j++ {
+			low = math.Min(low, bars[j].Low)
+			high = math.Max(high, bars[j].High)
+		}
+		rsv := 50.0
+		if high > low {
+			rsv = (bars[i].Close - low) / (high - low) * 100
+		}
+		k = (float64(m1-1)*k + rsv) / float64(m1)
+		d = (float64(m2-1)*d + k) / float64(m2)
+		result.K[i] = k
+		result.D[i] = d
+		result.J[i] = 3*k - 2*d
+	}
+	return result
+}
+
+// BollResult 布林带
+type BollResult struct {
+	Upper []float64
+	Mid   []float64
+	Lower []float64
+}
+
+// Bollinger 默认参数 (20, 2)，标准差为总体标准差
+func Bollinger(closes []float64, n int, k float64) BollResult {
+	mid := SMA(closes, n)
+	result := BollResult{Upper: nanSeries(len(closes)), Mid: mid, Lower: nanSeries(len(closes))}
+	if n <= 0 {
+		return result
+	}
+	for i := n - 1; i < len(closes); i++ {
+		var variance float64
+		for j := i - n + 1; j <= i; j++ {
+			diff := closes[j] - mid[i]
+			variance += diff * diff
+		}
+		std := math.Sqrt(variance / float64(n))
+		result.Upper[i] = mid[i] + k*std
+		result.Lower[i] = mid[i] - k*std
+	}
+	return result
+}
+
+// ATR 平均真实波幅，采用Wilder平滑，默认周期14
+func ATR(bars []model.Bar, n int) []float64 {
+	out := nanSeries(len(bars))
+	if n <= 0 || len(bars) <= n {
+		return out
+	}
+
+	tr := make([]float64, len(bars))
+	tr[0] = bars[0].High - bars[0].Low
+	for i := 1; i < len(bars); i++ {
+		prevClose := bars[i-1].Close
+		tr[i] = math.Max(bars[i].High-bars[i].Low,
+			math.Max(math.Abs(bars[i].High-prevClose), math.Abs(bars[i].Low-prevClose)))
+	}
+
+	var atr float64
+	for i := 1; i <= n; i++ {
+		atr += tr[i]
+	}
+	atr /= float64(n)
+	out[n] = atr
+	for i := n + 1; i < len(bars); i++ {
+		atr = (atr*float64(n-1) + tr[i]) / float64(n)
+		out[i] = atr
+	}
+	return out
+}
+
+// VolumeRatio 当日成交量与前 n 日平均成交量之比（日线口径的量比）
+func VolumeRatio(volumes []float64, n int) []float64 {
+	out := nanSeries(len(volumes))
+	if n <= 0 {
+		return out
+	}
+	avg := SMA(volumes, n)
+	for i := n; i < len(volumes); i++ {
+		if avg[i-1] > 0 {
+			out[i] = volumes[i] / avg[i-1]
+		}
+	}
+	return out
+}
+
+func nanSeries(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package indicators
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"stock-analysis-api/backend/go-api/internal/model"
+)
+
+var nan = math.NaN()
+
+// assertSeries 逐点比较序列，NaN 表示该位置应为数据不足
+func assertSeries(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: len = %d, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.IsNaN(want[i]) {
+			if !math.IsNaN(got[i]) {
+				t.Errorf("%s[%d] = %v, want NaN", name, i, got[i])
+			}
+			continue
+		}
+		if math.Abs(got[i]-want[i]) > 1e-4 {
+			t.Errorf("%s[%d] = %v, want %v", name, i, got[i], want[i])
+		}
+	}
+}
+
+func TestMACD(t *testing.T) {
+	tests := []struct {
+		name               string
+		closes             []float64
+		fast, slow, signal int
+		wantDIF, wantDEA   []float64
+		wantHist           []float64
+	}{
+		{
+			name:   "rising series",
+			closes: []float64{10, 11, 12},
+			fast:   1, slow: 3, signal: 3,
+			wantDIF:  []float64{0, 0.5, 0.75},
+			wantDEA:  []float64{0, 0.25, 0.5},
+			wantHist: []float64{0, 0.5, 0.5},
+		},
+		{
+			name:   "flat series",
+			closes: []float64{8, 8, 8, 8},
+			fast:   12, slow: 26, signal: 9,
+			wantDIF:  []float64{0, 0, 0, 0},
+			wantDEA:  []float64{0, 0, 0, 0},
+			wantHist: []float64{0, 0, 0, 0},
+		},
+		{
+			name: "empty series",
+			fast: 12, slow: 26, signal: 9,
+			wantDIF:  []float64{},
+			wantDEA:  []float64{},
+			wantHist: []float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MACD(tt.closes, tt.fast, tt.slow, tt.signal)
+			assertSeries(t, "DIF", got.DIF, tt.wantDIF)
+			assertSeries(t, "DEA", got.DEA, tt.wantDEA)
+			assertSeries(t, "Hist", got.Hist, tt.wantHist)
+		})
+	}
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		name   string
+		closes []float64
+		n      int
+		want   []float64
+	}{
+		{
+			name:   "wilder smoothing",
+			closes: []float64{1, 2, 1, 3},
+			n:      2,
+			want:   []float64{nan, nan, 50, 83.3333},
+		},
+		{
+			name:   "only gains",
+			closes: []float64{1, 2, 3, 4},
+			n:      2,
+			want:   []float64{nan, nan, 100, 100},
+		},
+		{
+			name:   "flat series",
+			closes: []float64{5, 5, 5},
+			n:      2,
+			want:   []float64{nan, nan, 50},
+		},
+		{
+			name:   "shorter than period",
+			closes: []float64{1, 2},
+			n:      2,
+			want:   []float64{nan, nan},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSeries(t, "RSI", RSI(tt.closes, tt.n), tt.want)
+		})
+	}
+}
+
+func TestKDJ(t *testing.T) {
+	tests := []struct {
+		name         string
+		bars         []model.Bar
+		wantK, wantD []float64
+		wantJ        []float64
+	}{
+		{
+			name: "known values",
+			bars: []model.Bar{
+				{High: 10, Low: 8, Close: 9},
+				{High: 11, Low: 9, Close: 10},
+				{High: 12, Low: 10, Close: 12},
+				{High: 12, Low: 11, Close: 11},
+			},
+			wantK: []float64{nan, nan, 66.6667, 66.6667},
+			wantD: []float64{nan, nan, 55.5556, 59.2593},
+			wantJ: []float64{nan, nan, 88.8889, 81.4815},
+		},
+		{
+			name: "no range keeps rsv at 50",
+			bars: []model.Bar{
+				{High: 5, Low: 5, Close: 5},
+				{High: 5, Low: 5, Close: 5},
+				{High: 5, Low: 5, Close: 5},
+			},
+			wantK: []float64{nan, nan, 50},
+			wantD: []float64{nan, nan, 50},
+			wantJ: []float64{nan, nan, 50},
+		},
+		{
+			name: "shorter than period",
+			bars: []model.Bar{
+				{High: 10, Low: 8, Close: 9},
+				{High: 11, Low: 9, Close: 10},
+			},
+			wantK: []float64{nan, nan},
+			wantD: []float64{nan, nan},
+			wantJ: []float64{nan, nan},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := KDJ(tt.bars, 3, 3, 3)
+			assertSeries(t, "K", got.K, tt.wantK)
+			assertSeries(t, "D", got.D, tt.wantD)
+			assertSeries(t, "J", got.J, tt.wantJ)
+		})
+	}
+}
+
+func TestSummarizeInsufficientData(t *testing.T) {
+	bars := make([]model.Bar, MinBars-1)
+	for i := range bars {
+		bars[i] = model.Bar{High: 11, Low: 9, Close: 10, Volume: 100}
+	}
+	if _, err := Summarize(bars); !errors.Is(err, ErrInsufficientData) {
+		t.Fatalf("err = %v, want ErrInsufficientData", err)
+	}
+}
@@ -0,0 +1,238 @@
+package indicators
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"strings"
+)
+
+// MinBars 生成技术指标摘要所需的最少K线数量（MA60及60日涨跌幅）
+const MinBars = 61
+
+// ErrInsufficientData K线数量不足
+var ErrInsufficientData = errors.New("K线数据不足")
+
+// Summary 最新交易日的技术指标摘要，供LLM分析使用。价格保留两位小数，涨跌幅为百分比
+type Summary struct {
+	Date  string  `json:"date"`
+	Bars  int     `json:"bars"` // 参与计算的K线数量
+	Close float64 `json:"close"`
+
+	MA5   float64 `json:"ma5"`
+	MA10  float64 `json:"ma10"`
+	MA20  float64 `json:"ma20"`
+	MA60  float64 `json:"ma60"`
+	EMA12 float64 `json:"ema12"`
+	EMA26 float64 `json:"ema26"`
+
+	DIF      float64 `json:"macd_dif"`
+	DEA      float64 `json:"macd_dea"`
+	MACDHist float64 `json:"macd_hist"`
+	RSI6     float64 `json:"rsi6"`
+	RSI14    float64 `json:"rsi14"`
+	K        float64 `json:"kdj_k"`
+	D        float64 `json:"kdj_d"`
+	J        float64 `json:"kdj_j"`
+
+	BollUpper   float64 `json:"boll_upper"`
+	BollMid     float64 `json:"boll_mid"`
+	BollLower   float64 `json:"boll_lower"`
+	ATR14       float64 `json:"atr14"`
+	ATRPct      float64 `json:"atr_pct"`      // ATR占收盘价百分比
+	VolumeRatio float64 `json:"volume_ratio"` // 当日成交量/前5日均量
+
+	Change5d  float64 `json:"change_5d"`
+	Change20d float64 `json:"change_20d"`
+	Change60d float64 `json:"change_60d"`
+	High20    float64 `json:"high_20"` // 近20日最高价（短期压力位）
+	Low20     float64 `json:"low_20"`  // 近20日最低价（短期支撑位）
+	High60    float64 `json:"high_60"`
+	Low60     float64 `json:"low_60"`
+
+	Trend   string   `json:"trend"`   // 均线排列：多头排列/空头排列/均线交织
+	Signals []string `json:"signals"` // 金叉、超买超卖、突破等信号
+}
+
+// Summarize 根据按日期升序的日K线计算技术指标摘要
+func Summarize(bars []model.Bar) (*Summary, error) {
+	if len(bars) < MinBars {
+		return nil, fmt.Errorf("%w: 需要至少%d根，实际%d根", ErrInsufficientData, MinBars, len(bars))
+	}
+
+	closes := make([]float64, len(bars))
+	volumes := make([]float64, len(bars))
+	for i, bar := range bars {
+		closes[i] = bar.Close
+		volumes[i] = bar.Volume
+	}
+	last := len(bars) - 1
+
+	ma5, ma10, ma20, ma60 := SMA(closes, 5), SMA(closes, 10), SMA(closes, 20), SMA(closes, 60)
+	macd := MACD(closes, 12, 26, 9)
+	rsi6, rsi14 := RSI(closes, 6), RSI(closes, 14)
+	kdj := KDJ(bars, 9, 3, 3)
+	boll := Bollinger(closes, 20, 2)
+	atr := ATR(bars, 14)
+	volRatio := VolumeRatio(volumes, 5)
+
+	s := &Summary{
+		Date:  bars[last].Date,
+		Bars:  len(bars),
+		Close: round2(closes[last]),
+
+		MA5:   round2(ma5[last]),
+		MA10:  round2(ma10[last]),
+		MA20:  round2(ma20[last]),
+		MA60:  round2(ma60[last]),
+		EMA12: round2(EMA(closes, 12)[last]),
+		EMA26: round2(EMA(closes, 26)[last]),
+
+		DIF:      round2(macd.DIF[last]),
+		DEA:      round2(macd.DEA[last]),
+		MACDHist: round2(macd.Hist[last]),
+		RSI6:     round2(rsi6[last]),
+		RSI14:    round2(rsi14[last]),
+		K:        round2(kdj.K[last]),
+		D:        round2(kdj.D[last]),
+		J:        round2(kdj.J[last]),
+
+		BollUpper:   round2(boll.Upper[last]),
+		BollMid:     round2(boll.Mid[last]),
+		BollLower:   round2(boll.Lower[last]),
+		ATR14:       round2(atr[last]),
+		ATRPct:      round2(atr[last] / closes[last] * 100),
+		VolumeRatio: round2(volRatio[last]),
+
+		Change5d:  round2(change(closes, 5)),
+		Change20d: round2(change(closes, 20)),
+		Change60d: round2(change(closes, 60)),
+	}
+	s.High20, s.Low20 = priceRange(bars[len(bars)-20:])
+	s.High60, s.Low60 = priceRange(bars[len(bars)-60:])
+
+	switch {
+	case ma5[last] > ma10[last] && ma10[last] > ma20[last] && ma20[last] > ma60[last]:
+		s.Trend = "多头排列"
+	case ma5[last] < ma10[last] && ma10[last] < ma20[last] && ma20[last] < ma60[last]:
+		s.Trend = "空头排列"
+	default:
+		s.Trend = "均线交织"
+	}
+
+	s.Signals = signals(closes, macd, kdj, boll, ma20, ma60, rsi14, volRatio)
+	return s, nil
+}
+
+// signals 识别最新交易日的典型技术信号
+func signals(closes []float64, macd MACDResult, kdj KDJResult, boll BollResult, ma20, ma60, rsi14, volRatio []float64) []string {
+	last := len(closes) - 1
+	prev := last - 1
+	out := make([]string, 0)
+
+	if crossUp(macd.DIF, macd.DEA, last) {
+		out = append(out, "MACD金叉")
+	} else if crossDown(macd.DIF, macd.DEA, last) {
+		out = append(out, "MACD死叉")
+	}
+	if macd.DIF[last] > 0 && macd.DEA[last] > 0 {
+		out = append(out, "MACD位于零轴上方")
+	} else if macd.DIF[last] < 0 && macd.DEA[last] < 0 {
+		out = append(out, "MACD位于零轴下方")
+	}
+
+	if crossUp(kdj.K, kdj.D, last) {
+		out = append(out, "KDJ金叉")
+	} else if crossDown(kdj.K, kdj.D, last) {
+		out = append(out, "KDJ死叉")
+	}
+	if kdj.J[last] > 100 {
+		out = append(out, "KDJ超买(J>100)")
+	} else if kdj.J[last] < 0 {
+		out = append(out, "KDJ超卖(J<0)")
+	}
+
+	if rsi14[last] > 70 {
+		out = append(out, "RSI超买(>70)")
+	} else if rsi14[last] < 30 {
+		out = append(out, "RSI超卖(<30)")
+	}
+
+	if closes[last] > boll.Upper[last] {
+		out = append(out, "收盘价突破布林上轨")
+	} else if closes[last] < boll.Lower[last] {
+		out = append(out, "收盘价跌破布林下轨")
+	}
+
+	for _, ma := range []struct {
+		name   string
+		values []float64
+	}{{"MA20", ma20}, {"MA60", ma60}} {
+		if closes[prev] <= ma.values[prev] && closes[last] > ma.values[last] {
+			out = append(out, "站上"+ma.name)
+		} else if closes[prev] >= ma.values[prev] && closes[last] < ma.values[last] {
+			out = append(out, "跌破"+ma.name)
+		}
+	}
+
+	if volRatio[last] >= 2 {
+		out = append(out, "明显放量(量比≥2)")
+	} else if volRatio[last] <= 0.5 {
+		out = append(out, "明显缩量(量比≤0.5)")
+	}
+	return out
+}
+
+// Text 以文本形式描述指标摘要，用于提示词
+func (s *Summary) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- 数据截至: %s（%d个交易日）, 收盘价: %.2f元\n", s.Date, s.Bars, s.Close)
+	fmt.Fprintf(&sb, "- 区间涨跌: 5日 %.2f%%, 20日 %.2f%%, 60日 %.2f%%\n", s.Change5d, s.Change20d, s.Change60d)
+	fmt.Fprintf(&sb, "- 均线: MA5 %.2f, MA10 %.2f, MA20 %.2f, MA60 %.2f（%s）\n", s.MA5, s.MA10, s.MA20, s.MA60, s.Trend)
+	fmt.Fprintf(&sb, "- MACD: DIF %.2f, DEA %.2f, 柱 %.2f\n", s.DIF, s.DEA, s.MACDHist)
+	fmt.Fprintf(&sb, "- RSI: RSI6 %.2f, RSI14 %.2f; KDJ: K %.2f, D %.2f, J %.2f\n", s.RSI6, s.RSI14, s.K, s.D, s.J)
+	fmt.Fprintf(&sb, "- 布林带(20,2): 上轨 %.2f, 中轨 %.2f, 下轨 %.2f\n", s.BollUpper, s.BollMid, s.BollLower)
+	fmt.Fprintf(&sb, "- ATR14: %.2f（%.2f%%）, 量比: %.2f\n", s.ATR14, s.ATRPct, s.VolumeRatio)
+	fmt.Fprintf(&sb, "- 近20日高/低: %.2f / %.2f; 近60日高/低: %.2f / %.2f\n", s.High20, s.Low20, s.High60, s.Low60)
+	if len(s.Signals) > 0 {
+		fmt.Fprintf(&sb, "- 信号: %s\n", strings.Join(s.Signals, "、"))
+	} else {
+		sb.WriteString("- 信号: 无明显信号\n")
+	}
+	return sb.String()
+}
+
+func crossUp(a, b []float64, i int) bool {
+	return i > 0 && a[i-1] <= b[i-1] && a[i] > b[i]
+}
+
+func crossDown(a, b []float64, i int) bool {
+	return i > 0 && a[i-1] >= b[i-1] && a[i] < b[i]
+}
+
+// change 最近 n 个交易日的涨跌幅（%）
+func change(closes []float64, n int) float64 {
+	last := len(closes) - 1
+	if last-n < 0 || closes[last-n] == 0 {
+		return math.NaN()
+	}
+	return (closes[last]/closes[last-n] - 1) * 100
+}
+
+func priceRange(bars []model.Bar) (high, low float64) {
+	high, low = bars[0].High, bars[0].Low
+	for _, bar := range bars[1:] {
+		high = math.Max(high, bar.High)
+		low = math.Min(low, bar.Low)
+	}
+	return round2(high), round2(low)
+}
+
+// round2 保留两位小数；NaN/Inf（理论上在 MinBars 保证下不会出现）按0处理，保证可序列化为JSON
+func round2(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return math.Round(v*100) / 100
+}
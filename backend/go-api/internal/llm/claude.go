@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"stock-analysis-api/backend/go-api/config"
 
@@ -11,13 +12,25 @@ import (
 
 type ClaudeClient struct {
 	client anthropic.Client
+	opts   ModelOptions
 }
 
 func NewClaudeClient() *ClaudeClient {
 	client := anthropic.NewClient(
 		option.WithAPIKey(config.AppConfig.ClaudeAPIKey),
 	)
-	return &ClaudeClient{client: client}
+	return &ClaudeClient{
+		client: client,
+		opts: ModelOptions{
+			Model:       string(anthropic.ModelClaudeSonnet4_5),
+			Temperature: 0.7,
+			MaxTokens:   800,
+		},
+	}
+}
+
+func (c *ClaudeClient) ModelName(step AnalysisStep) string {
+	return "Claude/" + c.opts.Model
 }
 
 func (c *ClaudeClient) StreamAnalyze(ctx context.Context, step AnalysisStep, data map[string]interface{}, callback StreamCallback) error {
@@ -25,8 +38,8 @@ func (c *ClaudeClient) StreamAnalyze(ctx context.Context, step AnalysisStep, dat
 	userPrompt := BuildUserPrompt(step, data)
 
 	stream := c.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.ModelClaudeSonnet4_5,
-		MaxTokens: 800,
+		Model:     anthropic.Model(c.opts.Model),
+		MaxTokens: int64(c.opts.MaxTokens),
 		System: []anthropic.TextBlockParam{
 			{
 				Text: systemPrompt,
@@ -37,13 +50,27 @@ func (c *ClaudeClient) StreamAnalyze(ctx context.Context, step AnalysisStep, dat
 				anthropic.NewTextBlock(userPrompt),
 			),
 		},
-		Temperature: anthropic.Float(0.7),
+		Temperature: anthropic.Float(c.opts.Temperature),
 	})
 
-	// 处理流式响应
+	usage := Usage{Provider: "Claude", Model: c.opts.Model}
+	defer func() { reportUsage(ctx, usage) }()
+
+	// 处理流式响应，未收到message_stop就结束说明连接被截断
+	stopped := false
 	for stream.Next() {
 		event := stream.Current()
 
+		// 记录用量：message_start携带输入token，message_delta携带累计输出token
+		switch event.Type {
+		case "message_start":
+			usage.PromptTokens = int(event.AsMessageStart().Message.Usage.InputTokens)
+		case "message_delta":
+			usage.CompletionTokens = int(event.AsMessageDelta().Usage.OutputTokens)
+		case "message_stop":
+			stopped = true
+		}
+
 		// 处理内容增量 - 检查事件类型
 		if event.Type == "content_block_delta" {
 			deltaEvent := event.AsContentBlockDelta()
@@ -59,6 +86,63 @@ func (c *ClaudeClient) StreamAnalyze(ctx context.Context, step AnalysisStep, dat
 	if err := stream.Err(); err != nil {
 		return fmt.Errorf("流式处理失败: %w", err)
 	}
+	if !stopped {
+		return errStreamTruncated
+	}
 
 	return nil
 }
+
+// ExtractDecision 通过强制工具调用将决策文本转换为结构化对象
+func (c *ClaudeClient) ExtractDecision(ctx context.Context, step AnalysisStep, text string) (*Decision, error) {
+	const toolName = "record_decision"
+
+	message, err := c.client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(c.opts.Model),
+		MaxTokens: 600,
+		System: []anthropic.TextBlockParam{
+			{
+				Text: decisionSystemPrompt,
+			},
+		},
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(
+				anthropic.NewTextBlock(text),
+			),
+		},
+		Tools: []anthropic.ToolUnionParam{
+			anthropic.ToolUnionParamOfTool(anthropic.ToolInputSchemaParam{
+				Properties: decisionSchema,
+				Required:   []string{"action"},
+			}, toolName),
+		},
+		ToolChoice:  anthropic.ToolChoiceParamOfTool(toolName),
+		Temperature: anthropic.Float(0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("结构化决策调用失败: %w", err)
+	}
+
+	reportUsage(ctx, Usage{
+		Provider:         "Claude",
+		Model:            c.opts.Model,
+		PromptTokens:     int(message.Usage.InputTokens),
+		CompletionTokens: int(message.Usage.OutputTokens),
+	})
+
+	for _, block := range message.Content {
+		if block.Type != "tool_use" {
+			continue
+		}
+		var d Decision
+		if err := json.Unmarshal(block.AsToolUse().Input, &d); err != nil {
+			return nil, fmt.Errorf("解析工具调用参数失败: %w", err)
+		}
+		if err := d.Normalize(); err != nil {
+			return nil, err
+		}
+		return &d, nil
+	}
+
+	return nil, fmt.Errorf("Claude 未返回工具调用")
+}
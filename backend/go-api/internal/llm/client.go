@@ -7,17 +7,91 @@ type AnalysisStep string
 
 const (
 	StepComprehensive AnalysisStep = "comprehensive"
+	StepTechnical     AnalysisStep = "technical"
 	StepDebateBull    AnalysisStep = "debate_bull"
 	StepDebateBear    AnalysisStep = "debate_bear"
 	StepTrader        AnalysisStep = "trader"
 	StepFinal         AnalysisStep = "final"
+
+	// 多股横向对比
+	StepCompare        AnalysisStep = "compare"
+	StepCompareVerdict AnalysisStep = "compare_verdict"
+
+	// 持仓组合分析
+	StepPortfolio AnalysisStep = "portfolio"
 )
 
+// RequiredInputs 内置提示词引用的前序步骤输出键，流水线中使用内置步骤时必须声明并由其他步骤产出
+func RequiredInputs(step AnalysisStep) []string {
+	switch step {
+	case StepDebateBull, StepDebateBear:
+		return []string{"comprehensive_analysis"}
+	case StepTrader:
+		return []string{"comprehensive_analysis", "bull_case", "bear_case"}
+	case StepFinal:
+		return []string{"comprehensive_analysis", "bull_case", "bear_case", "trader_decision"}
+	case StepCompareVerdict:
+		return []string{"compare_analysis"}
+	default:
+		return nil
+	}
+}
+
 // StreamCallback 流式响应回调
 type StreamCallback func(content string) error
 
+// ResetFunc 步骤输出被重置时的通知（例如重试或切换提供商后重新生成），reason 为展示给用户的原因
+type ResetFunc func(reason string)
+
+type resetHookKey struct{}
+
+// WithResetHook 在ctx中注册输出重置通知，供FallbackClient在已输出部分内容后重新生成时调用
+func WithResetHook(ctx context.Context, hook ResetFunc) context.Context {
+	return context.WithValue(ctx, resetHookKey{}, hook)
+}
+
+func notifyReset(ctx context.Context, reason string) {
+	if hook, ok := ctx.Value(resetHookKey{}).(ResetFunc); ok && hook != nil {
+		hook(reason)
+	}
+}
+
 // LLMClient LLM客户端接口
 type LLMClient interface {
 	// StreamAnalyze 流式分析
 	StreamAnalyze(ctx context.Context, step AnalysisStep, data map[string]interface{}, callback StreamCallback) error
 }
+
+// ModelDescriber 报告步骤实际使用的提供商与模型，如 "GLM/glm-4-plus"，用于结果缓存键
+type ModelDescriber interface {
+	ModelName(step AnalysisStep) string
+}
+
+// DescribeModel 返回客户端在指定步骤使用的模型，客户端未实现 ModelDescriber 时返回 "unknown"
+func DescribeModel(client LLMClient, step AnalysisStep) string {
+	if d, ok := client.(ModelDescriber); ok {
+		return d.ModelName(step)
+	}
+	return "unknown"
+}
+
+// ModelOptions 模型调用参数，零值字段表示沿用提供商默认值
+type ModelOptions struct {
+	Model       string  `yaml:"model,omitempty"`
+	Temperature float64 `yaml:"temperature,omitempty"`
+	MaxTokens   int     `yaml:"max_tokens,omitempty"`
+}
+
+// merge 用非零字段覆盖默认参数
+func (o ModelOptions) merge(override ModelOptions) ModelOptions {
+	if override.Model != "" {
+		o.Model = override.Model
+	}
+	if override.Temperature != 0 {
+		o.Temperature = override.Temperature
+	}
+	if override.MaxTokens != 0 {
+		o.MaxTokens = override.MaxTokens
+	}
+	return o
+}
@@ -0,0 +1,281 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Action 操作方向
+type Action string
+
+const (
+	ActionBuy  Action = "buy"
+	ActionHold Action = "hold"
+	ActionSell Action = "sell"
+)
+
+// Label 操作方向的中文名称
+func (a Action) Label() string {
+	switch a {
+	case ActionBuy:
+		return "买入"
+	case ActionHold:
+		return "持有"
+	case ActionSell:
+		return "卖出"
+	default:
+		return string(a)
+	}
+}
+
+// ParseAction 解析操作方向，支持 buy/hold/sell 及买入、持有、卖出等中文表述，无法识别时返回空
+func ParseAction(s string) Action {
+	return normalizeAction(s)
+}
+
+// RiskLevel 风险等级
+type RiskLevel string
+
+const (
+	RiskLow    RiskLevel = "low"
+	RiskMedium RiskLevel = "medium"
+	RiskHigh   RiskLevel = "high"
+)
+
+// PriceRange 价格区间
+type PriceRange struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// Decision 交易员/最终决策步骤的结构化输出
+type Decision struct {
+	RiskLevel     RiskLevel   `json:"risk_level,omitempty"`
+	Action        Action      `json:"action"`
+	Confidence    int         `json:"confidence,omitempty"`    // 1-100，0 表示文本未给出
+	PositionSize  string      `json:"position_size,omitempty"` // 建议仓位，如 "10%-20%"
+	EntryRange    *PriceRange `json:"entry_range,omitempty"`
+	StopLoss      float64     `json:"stop_loss,omitempty"`
+	HoldingPeriod string      `json:"holding_period,omitempty"`
+	Rationale     string      `json:"rationale,omitempty"`
+}
+
+// UnmarshalJSON 兼容模型输出的小数或 null 信心指数：小数四舍五入，null 视为未给出
+func (d *Decision) UnmarshalJSON(data []byte) error {
+	type plain Decision
+	var raw struct {
+		*plain
+		Confidence *float64 `json:"confidence"`
+	}
+	raw.plain = (*plain)(d)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	d.Confidence = 0
+	if raw.Confidence != nil {
+		d.Confidence = int(math.Round(*raw.Confidence))
+	}
+	return nil
+}
+
+// DecisionExtractor 支持JSON模式或工具调用的客户端实现，从步骤输出中抽取结构化决策
+type DecisionExtractor interface {
+	ExtractDecision(ctx context.Context, step AnalysisStep, text string) (*Decision, error)
+}
+
+// decisionSchema 结构化决策的JSON Schema，供工具调用和JSON模式提示使用
+var decisionSchema = map[string]any{
+	"risk_level":     map[string]any{"type": "string", "enum": []string{"low", "medium", "high"}, "description": "风险等级"},
+	"action":         map[string]any{"type": "string", "enum": []string{"buy", "hold", "sell"}, "description": "操作方向"},
+	"confidence":     map[string]any{"type": "number", "minimum": 0, "maximum": 100, "description": "信心指数，文本未给出时省略"},
+	"position_size":  map[string]any{"type": "string", "description": "建议仓位，如 10%-20%"},
+	"entry_range":    map[string]any{"type": "object", "properties": map[string]any{"low": map[string]any{"type": "number"}, "high": map[string]any{"type": "number"}}},
+	"stop_loss":      map[string]any{"type": "number", "description": "止损价（元）"},
+	"holding_period": map[string]any{"type": "string", "description": "预期持有周期"},
+	"rationale":      map[string]any{"type": "string", "description": "决策理由，100字以内"},
+}
+
+const decisionSystemPrompt = `你是结构化信息抽取器。请从用户给出的投资决策文本中抽取字段，只输出一个JSON对象，不要输出其他内容。
+字段：
+- risk_level: "low" | "medium" | "high"（高风险/中风险/低风险）
+- action: "buy" | "hold" | "sell"（买入/持有/卖出）
+- confidence: 0-100的整数（信心指数），文本未明确给出时输出 null，不要推测
+- position_size: 建议仓位字符串，如 "10%-20%"
+- entry_range: {"low": 数字, "high": 数字}，参考买入区间（元）
+- stop_loss: 止损价（元）
+- holding_period: 预期持有周期
+- rationale: 决策理由，100字以内
+文本中没有的字段省略。`
+
+// Normalize 规范化枚举取值并校验，action缺失时返回错误
+func (d *Decision) Normalize() error {
+	d.Action = normalizeAction(string(d.Action))
+	d.RiskLevel = normalizeRiskLevel(string(d.RiskLevel))
+
+	if d.Confidence < 0 {
+		d.Confidence = 0
+	}
+	if d.Confidence > 100 {
+		d.Confidence = 100
+	}
+	if d.EntryRange != nil {
+		if d.EntryRange.Low > d.EntryRange.High {
+			d.EntryRange.Low, d.EntryRange.High = d.EntryRange.High, d.EntryRange.Low
+		}
+		if d.EntryRange.High <= 0 {
+			d.EntryRange = nil
+		}
+	}
+	if d.StopLoss < 0 {
+		d.StopLoss = 0
+	}
+	d.Rationale = strings.TrimSpace(d.Rationale)
+
+	if d.Action == "" {
+		return fmt.Errorf("决策缺少操作方向")
+	}
+	return nil
+}
+
+// actionWords 中文操作词及其被否定时的含义，如“不建议买入”视为观望（持有），“不建议继续持有”视为卖出
+var actionWords = []struct {
+	word    string
+	action  Action
+	negated Action
+}{
+	{"买入", ActionBuy, ActionHold},
+	{"增持", ActionBuy, ActionHold},
+	{"卖出", ActionSell, ActionHold},
+	{"减持", ActionSell, ActionHold},
+	{"持有", ActionHold, ActionSell},
+	{"观望", ActionHold, ActionHold},
+}
+
+// negationMarkers 出现在操作词之前（同一分句内）时表示否定
+var negationMarkers = []string{"不", "勿", "别", "避免", "暂缓"}
+
+// normalizeAction 取文本中最先出现的操作词，并按其所在分句中前面是否有否定词确定操作方向
+func normalizeAction(s string) Action {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "buy":
+		return ActionBuy
+	case "sell":
+		return ActionSell
+	case "hold":
+		return ActionHold
+	}
+
+	first, pos := -1, len(s)
+	for i, w := range actionWords {
+		if idx := strings.Index(s, w.word); idx >= 0 && idx < pos {
+			first, pos = i, idx
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	clause := s[:pos]
+	if cut := strings.LastIndexAny(clause, "，,。；;、 \n"); cut >= 0 {
+		clause = clause[cut:]
+	}
+	for _, marker := range negationMarkers {
+		if strings.Contains(clause, marker) {
+			return actionWords[first].negated
+		}
+	}
+	return actionWords[first].action
+}
+
+func normalizeRiskLevel(s string) RiskLevel {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "high" || strings.HasPrefix(s, "高"):
+		return RiskHigh
+	case s == "medium" || strings.HasPrefix(s, "中"):
+		return RiskMedium
+	case s == "low" || strings.HasPrefix(s, "低"):
+		return RiskLow
+	default:
+		return ""
+	}
+}
+
+// parseDecisionJSON 解析模型返回的JSON，容忍代码块包裹和前后多余文字
+func parseDecisionJSON(text string) (*Decision, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("未找到JSON对象")
+	}
+
+	var d Decision
+	if err := json.Unmarshal([]byte(text[start:end+1]), &d); err != nil {
+		return nil, fmt.Errorf("解析决策JSON失败: %w", err)
+	}
+	if err := d.Normalize(); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// 文本抽取使用的标签模式，兼容 "**风险等级评估：** 中风险" 等Markdown写法
+const labelSep = `[\s*]*[:：][\s*]*`
+
+var (
+	riskPattern       = regexp.MustCompile(`风险等级(?:评估)?` + labelSep + `(高|中|低)`)
+	actionPattern     = regexp.MustCompile(`(?:综合投资建议|投资建议|操作方向|操作建议)` + labelSep + `((?:不建议|不宜|暂不|不再|不|避免)?\s*(?:继续)?(?:买入|持有|卖出|增持|减持|观望))`)
+	confidencePattern = regexp.MustCompile(`信心指数` + labelSep + `(\d{1,3})`)
+	positionPattern   = regexp.MustCompile(`(?:建议仓位|仓位)` + labelSep + `([^\n，。；;]+)`)
+	entryPattern      = regexp.MustCompile(`(?:参考买入区间|买入区间|买入价位|参考买入价位区间)` + labelSep + `([\d.]+)\s*(?:元)?\s*[-~～—至到]+\s*([\d.]+)`)
+	stopLossPattern   = regexp.MustCompile(`止损(?:位|价)?` + labelSep + `([\d.]+)`)
+	holdingPattern    = regexp.MustCompile(`(?:预期持有周期|持有周期)` + labelSep + `([^\n，。；;]+)`)
+	rationalePattern  = regexp.MustCompile(`(?s)(?:决策理由总结|核心逻辑|决策理由)` + labelSep + `(.+)`)
+)
+
+// ParseDecisionText 宽松抽取器：优先解析文本中的JSON，否则按中文标签抽取字段。
+// 用于不支持JSON模式/工具调用的提供商，以及结构化调用失败时的兜底
+func ParseDecisionText(text string) (*Decision, error) {
+	if d, err := parseDecisionJSON(text); err == nil {
+		return d, nil
+	}
+
+	d := &Decision{}
+	if m := riskPattern.FindStringSubmatch(text); m != nil {
+		d.RiskLevel = RiskLevel(m[1])
+	}
+	if m := actionPattern.FindStringSubmatch(text); m != nil {
+		d.Action = Action(m[1])
+	}
+	if m := confidencePattern.FindStringSubmatch(text); m != nil {
+		d.Confidence, _ = strconv.Atoi(m[1])
+	}
+	if m := positionPattern.FindStringSubmatch(text); m != nil {
+		d.PositionSize = strings.TrimSpace(m[1])
+	}
+	if m := entryPattern.FindStringSubmatch(text); m != nil {
+		low, _ := strconv.ParseFloat(m[1], 64)
+		high, _ := strconv.ParseFloat(m[2], 64)
+		d.EntryRange = &PriceRange{Low: low, High: high}
+	}
+	if m := stopLossPattern.FindStringSubmatch(text); m != nil {
+		d.StopLoss, _ = strconv.ParseFloat(m[1], 64)
+	}
+	if m := holdingPattern.FindStringSubmatch(text); m != nil {
+		d.HoldingPeriod = strings.TrimSpace(m[1])
+	}
+	if m := rationalePattern.FindStringSubmatch(text); m != nil {
+		d.Rationale = strings.Trim(strings.TrimSpace(m[1]), "*[] ")
+	}
+
+	if err := d.Normalize(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
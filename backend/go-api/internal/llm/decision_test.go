@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"testing"
+)
+
+func TestNormalizeAction(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Action
+	}{
+		{in: "buy", want: ActionBuy},
+		{in: " SELL ", want: ActionSell},
+		{in: "Hold", want: ActionHold},
+		{in: "买入", want: ActionBuy},
+		{in: "建议增持", want: ActionBuy},
+		{in: "减持", want: ActionSell},
+		{in: "观望", want: ActionHold},
+		{in: "不建议买入", want: ActionHold},
+		{in: "暂不买入", want: ActionHold},
+		{in: "不宜卖出", want: ActionHold},
+		{in: "不建议继续持有", want: ActionSell},
+		{in: "估值不低，建议持有", want: ActionHold},
+		{in: "买入，但不宜追高", want: ActionBuy},
+		{in: "中性", want: ""},
+		{in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeAction(tt.in); got != tt.want {
+			t.Errorf("normalizeAction(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecisionText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Decision
+		wantErr bool
+	}{
+		{
+			name: "json in code block",
+			text: "```json\n{\"action\": \"buy\", \"risk_level\": \"中风险\", \"confidence\": 75, \"stop_loss\": 1450}\n```",
+			want: Decision{Action: ActionBuy, RiskLevel: RiskMedium, Confidence: 75, StopLoss: 1450},
+		},
+		{
+			name: "fractional confidence is rounded",
+			text: `{"action": "hold", "confidence": 75.5}`,
+			want: Decision{Action: ActionHold, Confidence: 76},
+		},
+		{
+			name: "null confidence stays unset",
+			text: `{"action": "sell", "confidence": null}`,
+			want: Decision{Action: ActionSell},
+		},
+		{
+			name: "confidence clamped",
+			text: `{"action": "buy", "confidence": 130}`,
+			want: Decision{Action: ActionBuy, Confidence: 100},
+		},
+		{
+			name: "negated action in json",
+			text: `{"action": "不建议买入"}`,
+			want: Decision{Action: ActionHold},
+		},
+		{
+			name: "markdown labels",
+			text: "**风险等级评估：** 高风险\n**综合投资建议：** 卖出\n**信心指数：** 80\n**止损位：** 1400元\n**决策理由总结：** 估值过高",
+			want: Decision{Action: ActionSell, RiskLevel: RiskHigh, Confidence: 80, StopLoss: 1400, Rationale: "估值过高"},
+		},
+		{
+			name: "negated label value",
+			text: "投资建议：不建议买入\n风险等级：中",
+			want: Decision{Action: ActionHold, RiskLevel: RiskMedium},
+		},
+		{
+			name: "entry range and position",
+			text: "操作方向：买入\n建议仓位：10%-20%\n参考买入区间：1500-1520元\n持有周期：3-6个月",
+			want: Decision{Action: ActionBuy, PositionSize: "10%-20%", EntryRange: &PriceRange{Low: 1500, High: 1520}, HoldingPeriod: "3-6个月"},
+		},
+		{
+			name: "reversed entry range",
+			text: `{"action": "buy", "entry_range": {"low": 1520, "high": 1500}}`,
+			want: Decision{Action: ActionBuy, EntryRange: &PriceRange{Low: 1500, High: 1520}},
+		},
+		{
+			name: "no confidence in text",
+			text: "操作建议：持有",
+			want: Decision{Action: ActionHold},
+		},
+		{
+			name:    "missing action",
+			text:    "风险等级：低\n信心指数：60",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDecisionText(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDecisionText: %v", err)
+			}
+			if got.Action != tt.want.Action || got.RiskLevel != tt.want.RiskLevel || got.Confidence != tt.want.Confidence ||
+				got.StopLoss != tt.want.StopLoss || got.PositionSize != tt.want.PositionSize ||
+				got.HoldingPeriod != tt.want.HoldingPeriod || got.Rationale != tt.want.Rationale {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+			if (got.EntryRange == nil) != (tt.want.EntryRange == nil) ||
+				got.EntryRange != nil && *got.EntryRange != *tt.want.EntryRange {
+				t.Errorf("entry range = %+v, want %+v", got.EntryRange, tt.want.EntryRange)
+			}
+		})
+	}
+}
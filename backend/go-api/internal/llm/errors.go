@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// APIError LLM提供商返回的非200响应
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API错误 (状态码 %d): %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// errStreamTruncated 连接在结束标记前被关闭，响应内容不完整，按连接中断处理以触发重试和故障转移
+var errStreamTruncated = fmt.Errorf("流式响应在结束前中断: %w", io.ErrUnexpectedEOF)
+
+// StreamError 流式响应中途返回的错误事件（HTTP状态码已是200）
+type StreamError struct {
+	Provider string
+	Code     string
+	Message  string
+}
+
+func (e *StreamError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%s 流式响应错误: %s", e.Provider, e.Message)
+	}
+	return fmt.Sprintf("%s 流式响应错误 (%s): %s", e.Provider, e.Code, e.Message)
+}
+
+// transient 按错误码判断：HTTP状态码形式的429/5xx、限流/过载类字符串码，
+// 以及智谱的并发超限(1302)、频率超限(1303)、服务过载(1305)
+func (e *StreamError) transient() bool {
+	if code, err := strconv.Atoi(e.Code); err == nil {
+		if code >= 100 && code < 600 {
+			return isTransientStatus(code)
+		}
+		return code == 1302 || code == 1303 || code == 1305
+	}
+	code := strings.ToLower(e.Code + " " + e.Message)
+	for _, keyword := range []string{"rate_limit", "overloaded", "server_error", "timeout", "unavailable", "busy"} {
+		if strings.Contains(code, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTransient 判断错误是否值得重试：限流、服务端错误、连接重置和超时
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return isTransientStatus(apiErr.StatusCode)
+	}
+
+	var streamErr *StreamError
+	if errors.As(err, &streamErr) {
+		return streamErr.transient()
+	}
+
+	var claudeErr *anthropic.Error
+	if errors.As(err, &claudeErr) {
+		return isTransientStatus(claudeErr.StatusCode)
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isTransientStatus(code int) bool {
+	return code == 429 || code >= 500
+}
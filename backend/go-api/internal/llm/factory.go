@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"fmt"
+	"stock-analysis-api/backend/go-api/config"
+)
+
+// NewClient 按提供商名称创建LLM客户端，opts 中的非零字段覆盖该提供商的默认模型参数
+func NewClient(provider string, opts ModelOptions) (LLMClient, error) {
+	switch provider {
+	case "claude":
+		if config.AppConfig.ClaudeAPIKey == "" {
+			return nil, fmt.Errorf("CLAUDE_API_KEY未配置")
+		}
+		c := NewClaudeClient()
+		c.opts = c.opts.merge(opts)
+		return c, nil
+	case "glm":
+		if config.AppConfig.GLMAPIKey == "" {
+			return nil, fmt.Errorf("GLM_API_KEY未配置")
+		}
+		c := NewGLMClient()
+		c.applyOptions(opts)
+		return c, nil
+	case "deepseek":
+		if config.AppConfig.DeepSeekAPIKey == "" {
+			return nil, fmt.Errorf("DEEPSEEK_API_KEY未配置")
+		}
+		c := NewDeepSeekClient()
+		c.applyOptions(opts)
+		return c, nil
+	case "openai":
+		c := NewOpenAIClient()
+		c.applyOptions(opts)
+		if c.cfg.Model == "" {
+			return nil, fmt.Errorf("OPENAI_MODEL未配置")
+		}
+		return c, nil
+	case "ollama":
+		c := NewOllamaClient()
+		c.opts = c.opts.merge(opts)
+		return c, nil
+	case "llamacpp":
+		c := NewLlamaCppClient()
+		c.opts = c.opts.merge(opts)
+		return c, nil
+	default:
+		return nil, fmt.Errorf("不支持的LLM提供商: %s (支持: claude, glm, deepseek, openai, ollama, llamacpp)", provider)
+	}
+}
@@ -0,0 +1,188 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"stock-analysis-api/backend/go-api/internal/resilience"
+)
+
+// FallbackTarget 故障转移链中的一个提供商
+type FallbackTarget struct {
+	Name    string
+	Client  LLMClient
+	Breaker *resilience.CircuitBreaker // 同一提供商的多个客户端共享熔断器
+}
+
+// FallbackClient 按顺序尝试多个提供商：对瞬时错误（429、5xx、连接重置）带退避重试，
+// 提供商熔断或持续失败时切换到下一个。若失败前已输出部分内容，会通过 ResetFunc 通知调用方重置该步骤输出
+type FallbackClient struct {
+	targets    []FallbackTarget
+	maxRetries int
+	backoff    resilience.Backoff
+}
+
+func NewFallbackClient(targets []FallbackTarget, maxRetries int, backoff resilience.Backoff) *FallbackClient {
+	return &FallbackClient{
+		targets:    targets,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+	}
+}
+
+// callbackError 包装调用方回调返回的错误，与提供商错误区分，避免被当作可重试错误
+type callbackError struct {
+	err error
+}
+
+func (e *callbackError) Error() string { return e.err.Error() }
+func (e *callbackError) Unwrap() error { return e.err }
+
+// ModelName 返回首选提供商的模型，故障转移到备用提供商时实际模型可能不同
+func (f *FallbackClient) ModelName(step AnalysisStep) string {
+	if len(f.targets) == 0 {
+		return "unknown"
+	}
+	return DescribeModel(f.targets[0].Client, step)
+}
+
+func (f *FallbackClient) StreamAnalyze(ctx context.Context, step AnalysisStep, data map[string]interface{}, callback StreamCallback) error {
+	var lastErr error
+
+	for i, target := range f.targets {
+		if !target.Breaker.Allow() {
+			log.Printf("[%s] %s 已熔断，跳过", step, target.Name)
+			lastErr = fmt.Errorf("%s 已熔断", target.Name)
+			continue
+		}
+
+		for attempt := 0; attempt <= f.maxRetries; attempt++ {
+			emitted, err := f.tryTarget(ctx, target, step, data, callback)
+			if err == nil {
+				return nil
+			}
+
+			var cbErr *callbackError
+			if errors.As(err, &cbErr) {
+				return cbErr.err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			lastErr = err
+			log.Printf("[%s] %s 第%d次调用失败: %v", step, target.Name, attempt+1, err)
+
+			// 熔断器已打开（含半开探测失败）时不再重试；Closed 不会占用半开探测名额
+			retry := IsTransient(err) && attempt < f.maxRetries && target.Breaker.Closed()
+			if emitted {
+				// 已向前端输出部分内容，重新生成前通知重置
+				if retry {
+					notifyReset(ctx, fmt.Sprintf("%s 响应中断，正在重试", target.Name))
+				} else if f.fallbackReady(i) {
+					notifyReset(ctx, fmt.Sprintf("%s 调用失败，切换到备用提供商", target.Name))
+				}
+			}
+			if !retry {
+				break
+			}
+
+			if err := f.backoff.Sleep(ctx, attempt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("所有LLM提供商均失败: %w", lastErr)
+}
+
+// fallbackReady 判断第i个之后是否还有未熔断的备用提供商，不占用半开探测名额
+func (f *FallbackClient) fallbackReady(i int) bool {
+	for _, target := range f.targets[i+1:] {
+		if target.Breaker.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// tryTarget 调用一次提供商并把结果记入熔断器。回调错误、ctx 取消或 panic 时不计成败，
+// 通过 Release 归还半开探测名额，避免熔断器永久停留在半开状态
+func (f *FallbackClient) tryTarget(ctx context.Context, target FallbackTarget, step AnalysisStep, data map[string]interface{}, callback StreamCallback) (emitted bool, err error) {
+	settled := false
+	defer func() {
+		if !settled {
+			target.Breaker.Release()
+		}
+	}()
+
+	err = target.Client.StreamAnalyze(ctx, step, data, func(content string) error {
+		emitted = true
+		if err := callback(content); err != nil {
+			return &callbackError{err: err}
+		}
+		return nil
+	})
+	if err == nil {
+		target.Breaker.Success()
+		settled = true
+		return emitted, nil
+	}
+
+	var cbErr *callbackError
+	if errors.As(err, &cbErr) || ctx.Err() != nil {
+		return emitted, err
+	}
+
+	settled = true
+	if target.Breaker.Failure() {
+		log.Printf("[%s] %s 连续失败，熔断器打开", step, target.Name)
+	}
+	return emitted, err
+}
+
+// ExtractDecision 依次尝试支持结构化输出且未熔断的提供商
+func (f *FallbackClient) ExtractDecision(ctx context.Context, step AnalysisStep, text string) (*Decision, error) {
+	lastErr := fmt.Errorf("没有支持结构化输出的提供商")
+	for _, target := range f.targets {
+		extractor, ok := target.Client.(DecisionExtractor)
+		if !ok || !target.Breaker.Allow() {
+			continue
+		}
+
+		decision, err := extractDecision(ctx, target, extractor, step, text)
+		if err == nil {
+			return decision, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// extractDecision 调用一次结构化输出并记入熔断器，ctx 取消时归还半开探测名额
+func extractDecision(ctx context.Context, target FallbackTarget, extractor DecisionExtractor, step AnalysisStep, text string) (*Decision, error) {
+	settled := false
+	defer func() {
+		if !settled {
+			target.Breaker.Release()
+		}
+	}()
+
+	decision, err := extractor.ExtractDecision(ctx, step, text)
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+
+	settled = true
+	// 仅提供商自身的故障计入熔断，内容解析失败说明提供商可用
+	if err != nil && IsTransient(err) {
+		target.Breaker.Failure()
+	} else {
+		target.Breaker.Success()
+	}
+	return decision, err
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"stock-analysis-api/backend/go-api/internal/resilience"
+)
+
+// stubClient 先输出 partial，再返回 err
+type stubClient struct {
+	partial string
+	err     error
+}
+
+func (s *stubClient) StreamAnalyze(ctx context.Context, step AnalysisStep, data map[string]interface{}, callback StreamCallback) error {
+	if s.partial != "" {
+		if err := callback(s.partial); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func (s *stubClient) ModelName(step AnalysisStep) string { return "stub" }
+
+func TestFallbackResetOnlyWhenBackupReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		backupOpen bool
+		wantResets int
+		wantErr    bool
+	}{
+		{name: "backup available", backupOpen: false, wantResets: 1},
+		{name: "backup breaker open", backupOpen: true, wantResets: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backup := resilience.NewCircuitBreaker("backup", 1, time.Hour)
+			if tt.backupOpen {
+				backup.Failure()
+			}
+			client := NewFallbackClient([]FallbackTarget{
+				{Name: "primary", Client: &stubClient{partial: "半截", err: errors.New("invalid request")}, Breaker: resilience.NewCircuitBreaker("primary", 5, time.Hour)},
+				{Name: "backup", Client: &stubClient{}, Breaker: backup},
+			}, 0, resilience.Backoff{})
+
+			resets := 0
+			ctx := WithResetHook(context.Background(), func(string) { resets++ })
+			err := client.StreamAnalyze(ctx, StepTrader, nil, func(string) error { return nil })
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if resets != tt.wantResets {
+				t.Fatalf("resets = %d, want %d", resets, tt.wantResets)
+			}
+		})
+	}
+}
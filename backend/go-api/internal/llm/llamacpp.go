@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"stock-analysis-api/backend/go-api/config"
+	"strings"
+)
+
+// LlamaCppClient llama.cpp server，调用原生 /completion 流式接口
+type LlamaCppClient struct {
+	baseURL string
+	opts    ModelOptions // 模型由server启动参数决定，Model字段（LLAMACPP_MODEL）用于用量统计和报告中的模型标识
+	client  *http.Client
+}
+
+type llamaCppRequest struct {
+	Prompt      string   `json:"prompt"`
+	NPredict    int      `json:"n_predict"`
+	Temperature float64  `json:"temperature"`
+	Stream      bool     `json:"stream"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type llamaCppStreamResponse struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	TokensEvaluated int    `json:"tokens_evaluated,omitempty"`
+	TokensPredicted int    `json:"tokens_predicted,omitempty"`
+}
+
+func NewLlamaCppClient() *LlamaCppClient {
+	return &LlamaCppClient{
+		baseURL: strings.TrimRight(config.AppConfig.LlamaCppBaseURL, "/"),
+		opts: ModelOptions{
+			Model:       config.AppConfig.LlamaCppModel,
+			Temperature: 0.7,
+			MaxTokens:   800,
+		},
+		client: &http.Client{},
+	}
+}
+
+func (l *LlamaCppClient) ModelName(step AnalysisStep) string {
+	return "llama.cpp/" + l.opts.Model
+}
+
+func (l *LlamaCppClient) StreamAnalyze(ctx context.Context, step AnalysisStep, data map[string]interface{}, callback StreamCallback) error {
+	systemPrompt := GetSystemPrompt(step)
+	userPrompt := BuildUserPrompt(step, data)
+
+	// /completion 不做对话模板处理，这里拼接为通用的指令格式
+	reqBody := llamaCppRequest{
+		Prompt:      fmt.Sprintf("### 系统\n%s\n\n### 用户\n%s\n\n### 助手\n", systemPrompt, userPrompt),
+		NPredict:    l.opts.MaxTokens,
+		Temperature: l.opts.Temperature,
+		Stream:      true,
+		Stop:        []string{"### 用户"},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("JSON序列化失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", l.baseURL+"/completion", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{Provider: "llama.cpp", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	// SSE格式: data: {"content": "...", "stop": false}
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("读取响应失败: %w", err)
+		}
+
+		line = bytes.TrimSpace(line)
+		if bytes.HasPrefix(line, []byte("data:")) {
+			payload := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+
+			var streamResp llamaCppStreamResponse
+			if jsonErr := json.Unmarshal(payload, &streamResp); jsonErr == nil {
+				if streamResp.Content != "" {
+					if cbErr := callback(streamResp.Content); cbErr != nil {
+						return cbErr
+					}
+				}
+				if streamResp.Stop {
+					reportUsage(ctx, Usage{
+						Provider:         "llama.cpp",
+						Model:            l.opts.Model,
+						PromptTokens:     streamResp.TokensEvaluated,
+						CompletionTokens: streamResp.TokensPredicted,
+					})
+					return nil
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return errStreamTruncated
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"stock-analysis-api/backend/go-api/config"
+	"strings"
+)
+
+// OllamaClient 本地Ollama模型，调用 /api/chat 流式接口（NDJSON）
+type OllamaClient struct {
+	baseURL string
+	opts    ModelOptions
+	client  *http.Client
+}
+
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Format   string        `json:"format,omitempty"` // "json" 启用JSON模式
+	Options  ollamaOptions `json:"options"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type ollamaStreamResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error,omitempty"`
+	PromptEvalCount int    `json:"prompt_eval_count,omitempty"`
+	EvalCount       int    `json:"eval_count,omitempty"`
+}
+
+func NewOllamaClient() *OllamaClient {
+	return &OllamaClient{
+		baseURL: strings.TrimRight(config.AppConfig.OllamaBaseURL, "/"),
+		opts: ModelOptions{
+			Model:       config.AppConfig.OllamaModel,
+			Temperature: 0.7,
+			MaxTokens:   800,
+		},
+		client: &http.Client{},
+	}
+}
+
+func (o *OllamaClient) ModelName(step AnalysisStep) string {
+	return "Ollama/" + o.opts.Model
+}
+
+func (o *OllamaClient) StreamAnalyze(ctx context.Context, step AnalysisStep, data map[string]interface{}, callback StreamCallback) error {
+	systemPrompt := GetSystemPrompt(step)
+	userPrompt := BuildUserPrompt(step, data)
+
+	reqBody := ollamaRequest{
+		Model: o.opts.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Stream: true,
+		Options: ollamaOptions{
+			Temperature: o.opts.Temperature,
+			NumPredict:  o.opts.MaxTokens,
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("JSON序列化失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{Provider: "Ollama", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	// 每行一个JSON对象
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("读取响应失败: %w", err)
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var streamResp ollamaStreamResponse
+			if jsonErr := json.Unmarshal(line, &streamResp); jsonErr == nil {
+				if streamResp.Error != "" {
+					return &StreamError{Provider: "Ollama", Message: streamResp.Error}
+				}
+				if streamResp.Message.Content != "" {
+					if cbErr := callback(streamResp.Message.Content); cbErr != nil {
+						return cbErr
+					}
+				}
+				if streamResp.Done {
+					reportUsage(ctx, Usage{
+						Provider:         "Ollama",
+						Model:            o.opts.Model,
+						PromptTokens:     streamResp.PromptEvalCount,
+						CompletionTokens: streamResp.EvalCount,
+					})
+					return nil
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return errStreamTruncated
+		}
+	}
+}
+
+// ExtractDecision 使用Ollama的JSON模式将决策文本转换为结构化对象
+func (o *OllamaClient) ExtractDecision(ctx context.Context, step AnalysisStep, text string) (*Decision, error) {
+	reqBody := ollamaRequest{
+		Model: o.opts.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: decisionSystemPrompt},
+			{Role: "user", Content: text},
+		},
+		Stream: false,
+		Format: "json",
+		Options: ollamaOptions{
+			Temperature: 0.1,
+			NumPredict:  400,
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("JSON序列化失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{Provider: "Ollama", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result ollamaStreamResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	reportUsage(ctx, Usage{
+		Provider:         "Ollama",
+		Model:            o.opts.Model,
+		PromptTokens:     result.PromptEvalCount,
+		CompletionTokens: result.EvalCount,
+	})
+
+	return parseDecisionJSON(result.Message.Content)
+}
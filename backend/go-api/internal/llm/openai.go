@@ -0,0 +1,14 @@
+package llm
+
+import "stock-analysis-api/backend/go-api/config"
+
+// NewOpenAIClient 任意OpenAI兼容供应商（Qwen、Moonshot、本地vLLM等），完全由配置决定
+func NewOpenAIClient() *OpenAICompatClient {
+	return NewOpenAICompatClient(OpenAICompatConfig{
+		Name:    config.AppConfig.OpenAIName,
+		BaseURL: config.AppConfig.OpenAIBaseURL,
+		Model:   config.AppConfig.OpenAIModel,
+		APIKey:  config.AppConfig.OpenAIAPIKey,
+		Headers: config.AppConfig.OpenAIHeaders,
+	})
+}
@@ -0,0 +1,309 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAICompatConfig OpenAI兼容（/chat/completions）接口配置
+type OpenAICompatConfig struct {
+	Name        string // 供应商名称，仅用于日志和错误信息
+	BaseURL     string // 例如 https://api.deepseek.com/v1
+	Model       string
+	APIKey      string
+	Headers     map[string]string // 额外请求头
+	Temperature float64
+	MaxTokens   int
+}
+
+// OpenAICompatClient 通用的OpenAI兼容流式客户端，GLM、DeepSeek、Qwen、Moonshot、vLLM等均可复用
+type OpenAICompatClient struct {
+	cfg    OpenAICompatConfig
+	client *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Stream      bool          `json:"stream"`
+	// 请求在流末尾返回用量（OpenAI/DeepSeek/vLLM支持，GLM默认即返回）
+	StreamOptions *chatStreamOptions `json:"stream_options,omitempty"`
+	// JSON模式，用于结构化决策抽取
+	ResponseFormat *chatResponseFormat `json:"response_format,omitempty"`
+}
+
+type chatResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage,omitempty"`
+}
+
+type chatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type chatStreamResponse struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+		Code    any    `json:"code"`
+	} `json:"error,omitempty"`
+}
+
+func NewOpenAICompatClient(cfg OpenAICompatConfig) *OpenAICompatClient {
+	if cfg.Name == "" {
+		cfg.Name = "OpenAI兼容"
+	}
+	if cfg.Temperature == 0 {
+		cfg.Temperature = 0.7
+	}
+	if cfg.MaxTokens == 0 {
+		cfg.MaxTokens = 800
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &OpenAICompatClient{
+		cfg:    cfg,
+		client: &http.Client{},
+	}
+}
+
+// applyOptions 用非零字段覆盖模型参数
+func (o *OpenAICompatClient) applyOptions(opts ModelOptions) {
+	merged := ModelOptions{
+		Model:       o.cfg.Model,
+		Temperature: o.cfg.Temperature,
+		MaxTokens:   o.cfg.MaxTokens,
+	}.merge(opts)
+	o.cfg.Model = merged.Model
+	o.cfg.Temperature = merged.Temperature
+	o.cfg.MaxTokens = merged.MaxTokens
+}
+
+func (o *OpenAICompatClient) ModelName(step AnalysisStep) string {
+	return o.cfg.Name + "/" + o.cfg.Model
+}
+
+func (o *OpenAICompatClient) StreamAnalyze(ctx context.Context, step AnalysisStep, data map[string]interface{}, callback StreamCallback) error {
+	systemPrompt := GetSystemPrompt(step)
+	userPrompt := BuildUserPrompt(step, data)
+
+	reqBody := chatRequest{
+		Model: o.cfg.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Temperature:   o.cfg.Temperature,
+		MaxTokens:     o.cfg.MaxTokens,
+		Stream:        true,
+		StreamOptions: &chatStreamOptions{IncludeUsage: true},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("JSON序列化失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.cfg.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if o.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.cfg.APIKey)
+	}
+	for k, v := range o.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{Provider: o.cfg.Name, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	usage := Usage{Provider: o.cfg.Name, Model: o.cfg.Model}
+	err = parseChatStream(resp.Body, callback, &usage)
+	reportUsage(ctx, usage)
+	return err
+}
+
+// ExtractDecision 使用JSON模式将决策文本转换为结构化对象
+func (o *OpenAICompatClient) ExtractDecision(ctx context.Context, step AnalysisStep, text string) (*Decision, error) {
+	reqBody := chatRequest{
+		Model: o.cfg.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: decisionSystemPrompt},
+			{Role: "user", Content: text},
+		},
+		Temperature:    0.1,
+		MaxTokens:      400,
+		ResponseFormat: &chatResponseFormat{Type: "json_object"},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("JSON序列化失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.cfg.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if o.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.cfg.APIKey)
+	}
+	for k, v := range o.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{Provider: o.cfg.Name, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if result.Usage != nil {
+		reportUsage(ctx, Usage{
+			Provider:         o.cfg.Name,
+			Model:            o.cfg.Model,
+			PromptTokens:     result.Usage.PromptTokens,
+			CompletionTokens: result.Usage.CompletionTokens,
+		})
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("%s 未返回内容", o.cfg.Name)
+	}
+
+	return parseDecisionJSON(result.Choices[0].Message.Content)
+}
+
+// parseChatStream 解析chat/completions的SSE流。
+// 兼容 "data: {...}"、"data:{...}" 以及部分供应商直接输出的裸JSON行，忽略注释和其他SSE字段。
+// 未收到 [DONE] 或 finish_reason 就遇到EOF说明连接被截断，返回瞬时错误
+func parseChatStream(body io.Reader, callback StreamCallback, usage *Usage) error {
+	reader := bufio.NewReader(body)
+	finished := false
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			done, cbErr := handleChatStreamLine(line, callback, usage, &finished)
+			if cbErr != nil {
+				return cbErr
+			}
+			if done {
+				return nil
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				if finished {
+					return nil
+				}
+				return errStreamTruncated
+			}
+			return fmt.Errorf("读取响应失败: %w", err)
+		}
+	}
+}
+
+func handleChatStreamLine(line []byte, callback StreamCallback, usage *Usage, finished *bool) (bool, error) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return false, nil
+	}
+
+	if bytes.HasPrefix(line, []byte("data:")) {
+		line = bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+	}
+
+	if bytes.Equal(line, []byte("[DONE]")) {
+		return true, nil
+	}
+
+	// 跳过 ": keep-alive" 注释、event:/id: 等非数据行
+	if len(line) == 0 || line[0] != '{' {
+		return false, nil
+	}
+
+	var streamResp chatStreamResponse
+	if err := json.Unmarshal(line, &streamResp); err != nil {
+		// 忽略解析错误，继续处理下一行
+		return false, nil
+	}
+
+	if streamResp.Error != nil {
+		code := ""
+		if streamResp.Error.Code != nil {
+			code = fmt.Sprint(streamResp.Error.Code)
+		}
+		return false, &StreamError{Provider: usage.Provider, Code: code, Message: streamResp.Error.Message}
+	}
+
+	if streamResp.Usage != nil {
+		usage.PromptTokens = streamResp.Usage.PromptTokens
+		usage.CompletionTokens = streamResp.Usage.CompletionTokens
+	}
+
+	if len(streamResp.Choices) > 0 {
+		if streamResp.Choices[0].FinishReason != nil && *streamResp.Choices[0].FinishReason != "" {
+			*finished = true
+		}
+		content := streamResp.Choices[0].Delta.Content
+		if content != "" {
+			if err := callback(content); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseChatStream(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantText      string
+		wantErr       bool
+		wantTransient bool
+	}{
+		{
+			name:     "done marker",
+			body:     "data: {\"choices\":[{\"delta\":{\"content\":\"看多\"}}]}\n\ndata: [DONE]\n",
+			wantText: "看多",
+		},
+		{
+			name:     "finish reason without done marker",
+			body:     "data: {\"choices\":[{\"delta\":{\"content\":\"看多\"},\"finish_reason\":\"stop\"}]}\n",
+			wantText: "看多",
+		},
+		{
+			name:          "truncated before done",
+			body:          "data: {\"choices\":[{\"delta\":{\"content\":\"看\"}}]}\n",
+			wantText:      "看",
+			wantErr:       true,
+			wantTransient: true,
+		},
+		{
+			name:          "rate limited error event",
+			body:          "data: {\"error\":{\"message\":\"too many requests\",\"code\":\"1302\"}}\n",
+			wantErr:       true,
+			wantTransient: true,
+		},
+		{
+			name:    "content filter error event",
+			body:    "data: {\"error\":{\"message\":\"content blocked\",\"code\":\"1301\"}}\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var text strings.Builder
+			usage := Usage{Provider: "test"}
+			err := parseChatStream(strings.NewReader(tt.body), func(content string) error {
+				text.WriteString(content)
+				return nil
+			}, &usage)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := IsTransient(err); got != tt.wantTransient {
+				t.Fatalf("IsTransient(%v) = %v, want %v", err, got, tt.wantTransient)
+			}
+			if text.String() != tt.wantText {
+				t.Fatalf("text = %q, want %q", text.String(), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestParseChatStreamTruncatedIsUnexpectedEOF(t *testing.T) {
+	err := parseChatStream(strings.NewReader(""), func(string) error { return nil }, &Usage{})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
@@ -1,6 +1,59 @@
 package llm
 
-import "fmt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// PromptVersion 内置提示词版本。修改内置系统提示词或用户提示词时需递增，使缓存的分析结果失效
+const PromptVersion = "2026.10.5"
+
+// customPrompt 通过流水线定义注册的自定义步骤提示词
+type customPrompt struct {
+	system string
+	user   *template.Template
+	digest string // 提示词原文摘要，参与结果缓存键
+}
+
+var (
+	customPromptsMu sync.RWMutex
+	customPrompts   = make(map[AnalysisStep]customPrompt)
+)
+
+// RegisterPrompt 为自定义分析步骤注册提示词，userTemplate 为 text/template 模板
+func RegisterPrompt(step AnalysisStep, systemPrompt, userTemplate string) error {
+	tmpl, err := template.New(string(step)).Option("missingkey=zero").Parse(userTemplate)
+	if err != nil {
+		return fmt.Errorf("解析用户提示词模板失败: %w", err)
+	}
+
+	customPromptsMu.Lock()
+	defer customPromptsMu.Unlock()
+	sum := sha256.Sum256([]byte(systemPrompt + "\x00" + userTemplate))
+	customPrompts[step] = customPrompt{system: systemPrompt, user: tmpl, digest: hex.EncodeToString(sum[:6])}
+	return nil
+}
+
+// PromptVersionFor 返回步骤提示词版本：内置步骤为 PromptVersion，自定义步骤附加提示词内容摘要
+func PromptVersionFor(step AnalysisStep) string {
+	if p, ok := lookupCustomPrompt(step); ok {
+		return PromptVersion + "+" + p.digest
+	}
+	return PromptVersion
+}
+
+func lookupCustomPrompt(step AnalysisStep) (customPrompt, bool) {
+	customPromptsMu.RLock()
+	defer customPromptsMu.RUnlock()
+	p, ok := customPrompts[step]
+	return p, ok
+}
 
 // GetSystemPrompt 获取系统提示词
 func GetSystemPrompt(step AnalysisStep) string {
@@ -36,6 +89,7 @@ func GetSystemPrompt(step AnalysisStep) string {
    - **客观中立**: 保持独立判断，不掺杂个人情感或市场流行观点，平衡呈现优势与风险。
    - **全面审慎**: 分析需覆盖多个维度，对任何异常数据或潜在风险点保持高度敏感。
    - **结论有据**: 每一个判断和观点都应有相应的数据或逻辑支持，避免空泛陈述。
+   - **看趋势而非单点**: 评价盈利与成长时优先引用多期趋势（营收/净利润复合增长、TTM、毛利率与ROE的变化），单期数字仅作佐证。
 
 2. **行为准则**：
    - **结构清晰**: 分析报告遵循固定的逻辑框架，确保条理分明，便于阅读与理解。
@@ -59,6 +113,52 @@ func GetSystemPrompt(step AnalysisStep) string {
 ## Initialization
 作为资深A股投资分析师，你必须遵守上述Rules，按照Workflows执行任务。现在，请基于我提供的财务数据，开始你的综合分析。`,
 
+		StepTechnical: `# Role：A股技术分析师
+
+## Background：用户已获得公司的基本面分析，需要从价格走势与成交量的角度判断当前所处的趋势阶段、动能强弱以及关键价位，为后续的多空辩论和交易决策提供技术面依据。
+
+## Attention：技术分析的价值在于给出可验证的价位与条件，而不是预测涨跌。每一个判断都必须对应提供的指标数值，避免用模糊的形态描述代替数据。
+
+## Profile：
+- Author: 投资研究团队
+- Version: 1.0
+- Language: 中文
+- Description: 一名专注A股日线级别技术分析的分析师，熟悉均线系统、MACD、RSI、KDJ、布林带、ATR及量价关系，擅长将多项指标交叉验证后给出结构化结论。
+
+### Skills:
+- 通过均线排列与区间涨跌幅判断趋势方向与强度
+- 通过MACD、RSI、KDJ判断动能变化及超买超卖状态
+- 结合布林带、近期高低点和均线位置确定支撑位与压力位
+- 运用ATR衡量波动率，为止损幅度提供参考
+- 结合量比判断价格变动是否得到成交量确认
+
+## Goals:
+- 明确判断当前趋势（上升/下降/震荡）及其强弱
+- 评估短期动能，指出是否存在超买、超卖或背离风险
+- 给出2-3个关键支撑位和压力位，并说明依据
+- 基于ATR给出合理的波动区间或止损参考幅度
+
+## Constrains:
+- 只能使用提供的指标数据，严禁编造未提供的指标或历史形态
+- 支撑位与压力位必须是具体价格，并注明对应的指标或区间高低点
+- 指标相互矛盾时需明确指出，不得只挑选有利信号
+- 若未提供技术指标数据，需直接说明无法进行技术分析，不得臆测
+- 输出内容控制在150-200字之间
+
+## Workflow:
+1. **趋势判断**：依据均线排列、价格相对MA20/MA60的位置及5/20/60日涨跌幅判断趋势。
+2. **动能评估**：结合MACD零轴位置与金叉死叉、RSI及KDJ数值判断动能与超买超卖。
+3. **关键价位**：综合布林带上下轨、均线与近20/60日高低点，确定支撑位和压力位。
+4. **波动与量能**：依据ATR占比评估波动水平，依据量比判断量价配合情况。
+5. **归纳输出**：整合为一段结论明确的技术面分析。
+
+## OutputFormat:
+- 纯文本段落，依次覆盖趋势、动能、支撑位/压力位、波动与量能
+- 价格保留两位小数，直接引用指标数值作为论据
+
+## Initialization
+作为A股技术分析师，你必须遵守上述约束条件，使用中文输出。请基于我提供的技术指标数据开始分析。`,
+
 		StepDebateBull: `# Role：乐观多头投资分析师
 
 ## Background：用户需要从积极乐观的多头视角，对特定股票或投资标的进行投资价值分析。这通常发生在用户已经初步了解某标的，但希望获得一个结构化、积极且基于数据的买入理由，以辅助投资决策或增强持股信心。用户可能是一名个人投资者、投资顾问，或正在准备投资推介材料。
@@ -283,9 +383,75 @@ func GetSystemPrompt(step AnalysisStep) string {
 
 ## Initialization
 作为投资决策委员会首席风险管理官，你必须遵守Constrains，使用默认中文与用户交流。请用户提供需要评估的投资标的及相关信息，我将开始我的专业分析流程。`,
+
+		StepCompare: `# Role：A股同业比较分析师
+
+## Profile：
+- Language: 中文
+- Description: 专注于同行业或跨行业上市公司横向比较的研究员，擅长用统一口径的估值与财务指标判断谁更便宜、谁更赚钱、谁成长更快、谁更稳健。
+
+## Goals:
+- 基于对比表，从估值、盈利能力、成长性、财务稳健性四个维度逐一比较各标的，指出每个维度的领先者与落后者。
+- 结合多期财务趋势判断各标的优势是否可持续，而非只比较单期数字。
+- 说明估值差异是否被盈利能力与成长性的差异所解释（如高PE是否对应更高的ROE和增速）。
+
+## Constrains:
+- 只使用给出的数据，严禁编造数据；标注“数据缺失”的指标不得视为0，也不得据此得出结论。
+- 报告期不一致或行业不同时，需提示口径差异对比较结论的影响（如银行与消费股的负债率不可直接比较）。
+- 不给出买卖建议和排名，排名由后续步骤完成。
+- 输出控制在300-400字，按四个维度分段，每段先给结论再给数据依据。`,
+
+		StepCompareVerdict: `# Role：组合配置决策人
+
+## Profile：
+- Language: 中文
+- Description: 负责在多个候选标的之间分配资金的投资经理，擅长综合性价比、确定性与风险，对候选标的给出明确的优先级排序。
+
+## Goals:
+- 基于横向对比分析和对比表，对全部候选标的给出从优到劣的排名。
+- 为每个标的给出操作建议（买入/持有/卖出）、0-100的综合评分及一句话核心理由。
+- 给出一段总结，说明排名第一的标的胜出的关键原因以及排名靠后标的的主要短板。
+
+## Constrains:
+- 必须对每个候选标的排名，排名不得并列，评分需与排名一致（排名靠前者评分不低于靠后者）。
+- 理由须引用对比中的具体指标，不得编造数据。
+- 输出必须严格遵循以下格式，表格每行一个标的，股票列写成“名称(6位代码)”：
+
+| 排名 | 股票 | 建议 | 评分 | 核心理由 |
+|---|---|---|---|---|
+| 1 | 贵州茅台(600519) | 买入 | 80 | 一句话理由 |
+
+**结论：** 100-150字的总结。`,
+
+		StepPortfolio: `# Role：A股组合基金经理
+
+## Profile：
+- Language: 中文
+- Description: 管理多只A股持仓的组合经理，从组合整体而非单只股票出发，关注仓位集中度、行业暴露、估值水平与持仓质量，擅长给出可执行的调仓方案。
+
+## Goals:
+- 评估组合当前状况：盈亏来源、仓位与行业集中度、加权估值是否偏高、整体财务质量与成长性。
+- 识别组合层面的主要风险（单一股票或行业过度集中、高估值或亏损公司仓位过重、个股风险提示）。
+- 给出具体的调仓建议：每只持仓的操作（加仓/持有/减仓/清仓）和目标权重，以及可考虑补充的行业方向。
+
+## Constrains:
+- 只使用给出的数据，严禁编造数据；标注“数据缺失”的指标不得视为0，覆盖率低的加权指标需谨慎解读。
+- 调仓建议以权重表达，目标权重合计不超过100%，剩余部分视为现金；不得推荐具体的新股票代码。
+- 浮动盈亏只说明历史成本，不应作为加减仓的主要依据（避免“摊低成本”式建议），需基于基本面、估值和组合风险给出理由。
+- 输出控制在500-700字，依次为：组合诊断、主要风险、调仓建议表、执行要点。调仓建议表格式如下，股票列写成“名称(6位代码)”：
+
+| 股票 | 当前权重 | 操作 | 目标权重 | 理由 |
+|---|---|---|---|---|
+| 贵州茅台(600519) | 45.0% | 减仓 | 30% | 一句话理由 |`,
 	}
 
-	return prompts[step]
+	if prompt, ok := prompts[step]; ok {
+		return prompt
+	}
+	if custom, ok := lookupCustomPrompt(step); ok {
+		return custom.system
+	}
+	return ""
 }
 
 // func GetSystemPrompt(step AnalysisStep) string {
@@ -333,10 +499,78 @@ func GetSystemPrompt(step AnalysisStep) string {
 // 	return prompts[step]
 // }
 
-// BuildUserPrompt 构建用户提示词
+// extraInputsKey 步骤数据中保存内置提示词未渲染的已声明输入，见 WithExtraInputs
+const extraInputsKey = "_extra_inputs"
+
+// PromptInput 步骤声明的输入键及其在提示词中的标题
+type PromptInput struct {
+	Key   string
+	Title string
+}
+
+// builtinPromptKeys 内置用户提示词会渲染的数据键，其余已声明的输入作为附加段落追加到提示词末尾
+var builtinPromptKeys = map[AnalysisStep][]string{
+	StepComprehensive: {"name", "code", "industry", "board", "exchange", "price_limit", "market_cap", "latest_price",
+		"pe_ttm", "pb", "report_date", "roe", "roa", "gross_margin", "net_margin", "debt_ratio", "revenue_growth",
+		"profit_growth", "financial_trend_summary", "financial_trends", "data_quality_summary", "risks"},
+	StepTechnical:      {"name", "code", "latest_price", "technical_summary", "technical"},
+	StepDebateBull:     {"name", "comprehensive_analysis", "technical_analysis", "financial_trend_summary", "financial_trends", "roe", "debt_ratio", "revenue_growth"},
+	StepDebateBear:     {"name", "comprehensive_analysis", "technical_analysis", "financial_trend_summary", "financial_trends", "roe", "debt_ratio", "revenue_growth"},
+	StepTrader:         {"name", "comprehensive_analysis", "technical_analysis", "bull_case", "bear_case", "latest_price", "board", "exchange", "price_limit"},
+	StepFinal:          {"name", "comprehensive_analysis", "technical_analysis", "bull_case", "bear_case", "trader_decision"},
+	StepCompare:        {"name", "comparison_table", "peer_trends"},
+	StepCompareVerdict: {"name", "code", "comparison_table", "compare_analysis"},
+	StepPortfolio:      {"name", "code", "portfolio_summary", "holding_trends"},
+}
+
+// UncoveredInputs 返回内置步骤的提示词不会渲染的输入键。自定义步骤的模板可引用任意数据键，返回 nil
+func UncoveredInputs(step AnalysisStep, inputs []string) []string {
+	covered, ok := builtinPromptKeys[step]
+	if !ok {
+		return nil
+	}
+	var uncovered []string
+	for _, input := range inputs {
+		if !slices.Contains(covered, input) {
+			uncovered = append(uncovered, input)
+		}
+	}
+	return uncovered
+}
+
+// WithExtraInputs 在步骤数据中登记需要追加到内置提示词末尾的输入，使自定义步骤的输出能传递给内置步骤
+func WithExtraInputs(data map[string]interface{}, inputs []PromptInput) {
+	if len(inputs) > 0 {
+		data[extraInputsKey] = inputs
+	}
+}
+
+// BuildUserPrompt 构建用户提示词，内置步骤之后追加 WithExtraInputs 登记的输入
 func BuildUserPrompt(step AnalysisStep, data map[string]interface{}) string {
-	name := data["name"].(string)
-	code := data["code"].(string)
+	prompt := buildUserPrompt(step, data)
+	inputs, _ := data[extraInputsKey].([]PromptInput)
+	if len(inputs) == 0 {
+		return prompt
+	}
+
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	sb.WriteString("\n\n")
+	for _, input := range inputs {
+		switch v := data[input.Key].(type) {
+		case nil:
+		case string:
+			sb.WriteString(optionalSection(data, input.Key, input.Title))
+		default:
+			fmt.Fprintf(&sb, "【%s】\n%v\n\n", input.Title, v)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func buildUserPrompt(step AnalysisStep, data map[string]interface{}) string {
+	name, _ := data["name"].(string)
+	code, _ := data["code"].(string)
 
 	switch step {
 	case StepComprehensive:
@@ -344,51 +578,78 @@ func BuildUserPrompt(step AnalysisStep, data map[string]interface{}) string {
 
 【基本信息】
 - 行业: %v
-- 市值: %.2f亿元
-- 最新价: %.2f元
-- PE: %.2f, PB: %.2f
-
-【财务指标】
-- ROE: %.2f%%
-- 资产负债率: %.2f%%
-- 营收增长: %.2f%%
-- 净利润增长: %.2f%%
-
-【风险信号】
+- 板块: %s
+- 市值: %s
+- 最新价: %s
+- PE: %s, PB: %s
+
+【最新财务指标】（报告期 %v，季报为累计值）
+- ROE: %s, ROA: %s
+- 毛利率: %s, 净利率: %s
+- 资产负债率: %s
+- 营收增长: %s
+- 净利润增长: %s
+
+%s%s【风险信号】
 %v
 
-请进行综合分析。`,
+请进行综合分析，财务判断请引用多期趋势（复合增长、TTM、利润率与ROE变化）而非单期数字。`,
 			name, code,
 			data["industry"],
-			data["market_cap"],
-			data["latest_price"],
-			data["pe_ttm"],
-			data["pb"],
-			data["roe"],
-			data["debt_ratio"],
-			data["revenue_growth"],
-			data["profit_growth"],
+			boardText(data),
+			metric(data["market_cap"], "亿元"),
+			metric(data["latest_price"], "元"),
+			metric(data["pe_ttm"], ""),
+			metric(data["pb"], ""),
+			data["report_date"],
+			metric(data["roe"], "%"),
+			metric(data["roa"], "%"),
+			metric(data["gross_margin"], "%"),
+			metric(data["net_margin"], "%"),
+			metric(data["debt_ratio"], "%"),
+			metric(data["revenue_growth"], "%"),
+			metric(data["profit_growth"], "%"),
+			optionalSection(data, "financial_trend_summary", "财务趋势"),
+			optionalSection(data, "data_quality_summary", "数据质量"),
 			data["risks"])
 
+	case StepTechnical:
+		technical, _ := data["technical_summary"].(string)
+		if technical == "" {
+			technical = "暂无历史K线数据，无法计算技术指标。\n"
+		}
+		return fmt.Sprintf(`请对【%s(%s)】进行技术面分析：
+
+【最新价】%s
+
+【技术指标】
+%s
+请给出趋势、动能、关键支撑位与压力位的判断。`,
+			name, code,
+			metric(data["latest_price"], "元"),
+			technical)
+
 	case StepDebateBull, StepDebateBear:
-		previous := data["comprehensive_analysis"].(string)
+		previous, _ := data["comprehensive_analysis"].(string)
 		return fmt.Sprintf(`基于以下综合分析，请给出【%s】的看%s观点：
 
 【综合分析】
 %s
 
-【关键数据】
-- ROE: %.2f%%
-- 资产负债率: %.2f%%
-- 营收增长: %.2f%%
+%s%s【关键数据】
+- ROE: %s
+- 资产负债率: %s
+- 营收增长: %s
 
-请从%s角度分析。`,
+请从%s角度分析，引用财务数据时以多期趋势为依据。`,
 			name,
 			map[AnalysisStep]string{StepDebateBull: "多", StepDebateBear: "空"}[step],
 			previous,
-			data["roe"],
-			data["debt_ratio"],
-			data["revenue_growth"],
+			optionalSection(data, "technical_analysis", "技术分析"),
+			optionalSection(data, "financial_trend_summary", "财务趋势"),
+			metric(data["roe"], "%"),
+			metric(data["debt_ratio"], "%"),
+			metric(data["revenue_growth"], "%"),
 			map[AnalysisStep]string{StepDebateBull: "多头", StepDebateBear: "空头"}[step])
 
 	case StepTrader:
@@ -397,20 +658,23 @@ func BuildUserPrompt(step AnalysisStep, data map[string]interface{}) string {
 【综合分析】
 %s
 
-【多头观点】
+%s【多头观点】
 %s
 
 【空头观点】
 %s
 
-【当前价格】%.2f元
+【当前价格】%s
+【交易规则】%s
 
-请给出具体的交易建议。`,
+请给出具体的交易建议，入场区间和止损位需符合涨跌幅限制。`,
 			name,
 			data["comprehensive_analysis"],
+			optionalSection(data, "technical_analysis", "技术分析"),
 			data["bull_case"],
 			data["bear_case"],
-			data["latest_price"])
+			metric(data["latest_price"], "元"),
+			boardText(data))
 
 	case StepFinal:
 		return fmt.Sprintf(`基于完整分析链，给出【%s】的最终投资建议：
@@ -418,7 +682,7 @@ func BuildUserPrompt(step AnalysisStep, data map[string]interface{}) string {
 【综合分析】
 %s
 
-【多头观点】
+%s【多头观点】
 %s
 
 【空头观点】
@@ -430,11 +694,90 @@ func BuildUserPrompt(step AnalysisStep, data map[string]interface{}) string {
 请给出最终决策（包含：风险等级、投资建议、信心指数、理由）。`,
 			name,
 			data["comprehensive_analysis"],
+			optionalSection(data, "technical_analysis", "技术分析"),
 			data["bull_case"],
 			data["bear_case"],
 			data["trader_decision"])
 
+	case StepCompare:
+		return fmt.Sprintf(`请对以下股票进行横向对比分析：%s
+
+【对比表】
+%s
+%s请从估值、盈利能力、成长性、财务稳健性四个维度进行比较。`,
+			name,
+			data["comparison_table"],
+			optionalSection(data, "peer_trends", "财务趋势"))
+
+	case StepCompareVerdict:
+		return fmt.Sprintf(`基于以下横向对比，对【%s】给出排名结论：
+
+【对比表】
+%s
+【横向对比分析】
+%s
+
+请按规定格式输出排名表和结论，候选代码: %s。`,
+			name,
+			data["comparison_table"],
+			data["compare_analysis"],
+			code)
+
+	case StepPortfolio:
+		return fmt.Sprintf(`请对以下持仓组合进行诊断并给出调仓建议：%s
+
+【组合概况】
+%s
+%s请按规定结构输出，调仓建议需覆盖全部持仓: %s。`,
+			name,
+			data["portfolio_summary"],
+			optionalSection(data, "holding_trends", "持仓财务趋势"),
+			code)
+
 	default:
+		if custom, ok := lookupCustomPrompt(step); ok && custom.user != nil {
+			var sb strings.Builder
+			if err := custom.user.Execute(&sb, data); err == nil && sb.Len() > 0 {
+				return sb.String()
+			}
+		}
 		return "请进行分析"
 	}
 }
+
+// boardText 描述上市板块和日涨跌幅限制，如 "上海主板，涨跌幅限制±10%"
+func boardText(data map[string]interface{}) string {
+	board, _ := data["board"].(string)
+	if board == "" {
+		return model.MissingText
+	}
+	exchange := map[string]string{"SH": "上海", "SZ": "深圳"}[fmt.Sprint(data["exchange"])]
+	if limit, ok := data["price_limit"].(int); ok && limit > 0 {
+		return fmt.Sprintf("%s%s，涨跌幅限制±%d%%", exchange, board, limit)
+	}
+	return exchange + board
+}
+
+// metric 格式化数值指标并附加单位，缺失的指标显示“数据缺失”，避免LLM把缺失值当作0分析
+func metric(v interface{}, unit string) string {
+	switch m := v.(type) {
+	case model.Metric:
+		if !m.Valid {
+			return model.MissingText
+		}
+		return fmt.Sprintf("%.2f%s", m.Value, unit)
+	case float64:
+		return fmt.Sprintf("%.2f%s", m, unit)
+	default:
+		return model.MissingText
+	}
+}
+
+// optionalSection 数据中存在 key 时返回带标题的段落，否则返回空串，使不含该步骤的自定义流水线仍可复用内置提示词
+func optionalSection(data map[string]interface{}, key, title string) string {
+	content, ok := data[key].(string)
+	if !ok || content == "" {
+		return ""
+	}
+	return fmt.Sprintf("【%s】\n%s\n\n", title, strings.TrimRight(content, "\n"))
+}
@@ -0,0 +1,49 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// Router 按分析步骤路由到不同的LLM客户端，未配置路由的步骤使用默认客户端
+type Router struct {
+	defaultClient LLMClient
+	routes        map[AnalysisStep]LLMClient
+}
+
+func NewRouter(defaultClient LLMClient) *Router {
+	return &Router{
+		defaultClient: defaultClient,
+		routes:        make(map[AnalysisStep]LLMClient),
+	}
+}
+
+// Route 为指定步骤设置专用客户端
+func (r *Router) Route(step AnalysisStep, client LLMClient) {
+	r.routes[step] = client
+}
+
+// ClientFor 返回步骤实际使用的客户端
+func (r *Router) ClientFor(step AnalysisStep) LLMClient {
+	if client, ok := r.routes[step]; ok {
+		return client
+	}
+	return r.defaultClient
+}
+
+// ModelName 返回步骤路由到的模型
+func (r *Router) ModelName(step AnalysisStep) string {
+	return DescribeModel(r.ClientFor(step), step)
+}
+
+func (r *Router) StreamAnalyze(ctx context.Context, step AnalysisStep, data map[string]interface{}, callback StreamCallback) error {
+	return r.ClientFor(step).StreamAnalyze(ctx, step, data, callback)
+}
+
+// ExtractDecision 委托给步骤对应的客户端，客户端不支持结构化输出时返回错误
+func (r *Router) ExtractDecision(ctx context.Context, step AnalysisStep, text string) (*Decision, error) {
+	if extractor, ok := r.ClientFor(step).(DecisionExtractor); ok {
+		return extractor.ExtractDecision(ctx, step, text)
+	}
+	return nil, fmt.Errorf("步骤 %s 的LLM不支持结构化输出", step)
+}
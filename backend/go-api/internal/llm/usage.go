@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Usage 单次LLM调用的token用量
+type Usage struct {
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// UsageFunc 用量上报回调
+type UsageFunc func(usage Usage)
+
+type usageHookKey struct{}
+
+// WithUsageHook 在ctx中注册用量上报回调。客户端在流结束（包括失败的重试）时上报实际用量
+func WithUsageHook(ctx context.Context, hook UsageFunc) context.Context {
+	return context.WithValue(ctx, usageHookKey{}, hook)
+}
+
+func reportUsage(ctx context.Context, usage Usage) {
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		return
+	}
+	if hook, ok := ctx.Value(usageHookKey{}).(UsageFunc); ok && hook != nil {
+		hook(usage)
+	}
+}
+
+// Price 每百万token价格（人民币元）
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+var (
+	priceMu sync.RWMutex
+	// 默认价格表，按模型名匹配；美元计价的模型按 1 USD ≈ 7.2 CNY 折算，仅用于成本估算
+	priceTable = map[string]Price{
+		"glm-4-plus":        {Input: 5, Output: 5},
+		"glm-4-air":         {Input: 0.5, Output: 0.5},
+		"glm-4-flash":       {Input: 0, Output: 0},
+		"deepseek-chat":     {Input: 2, Output: 8},
+		"deepseek-reasoner": {Input: 4, Output: 16},
+		"claude-sonnet-4-5": {Input: 21.6, Output: 108},
+		"qwen-plus":         {Input: 0.8, Output: 2},
+		"moonshot-v1-8k":    {Input: 12, Output: 12},
+	}
+	// 本地模型不产生费用
+	freeProviders = map[string]bool{"Ollama": true, "llama.cpp": true}
+)
+
+// SetPrices 覆盖或新增价格，格式 "model=input:output,..."（元/百万token）
+func SetPrices(spec string) {
+	priceMu.Lock()
+	defer priceMu.Unlock()
+
+	for _, item := range strings.Split(spec, ",") {
+		model, prices, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+		in, out, ok := strings.Cut(prices, ":")
+		if !ok {
+			continue
+		}
+		inPrice, err1 := strconv.ParseFloat(strings.TrimSpace(in), 64)
+		outPrice, err2 := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		priceTable[strings.TrimSpace(model)] = Price{Input: inPrice, Output: outPrice}
+	}
+}
+
+// EstimateCost 估算本次用量的费用（元），未知模型返回 known=false
+func EstimateCost(u Usage) (cost float64, known bool) {
+	if freeProviders[u.Provider] {
+		return 0, true
+	}
+
+	priceMu.RLock()
+	price, ok := priceTable[u.Model]
+	priceMu.RUnlock()
+	if !ok {
+		return 0, false
+	}
+
+	return (float64(u.PromptTokens)*price.Input + float64(u.CompletionTokens)*price.Output) / 1e6, true
+}
@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ranking 横向对比中单只股票的排名
+type Ranking struct {
+	Rank   int    `json:"rank"`
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Action Action `json:"action"`
+	Score  int    `json:"score"` // 0-100
+	Reason string `json:"reason,omitempty"`
+}
+
+// Verdict 横向对比的结构化结论，Rankings 按排名升序
+type Verdict struct {
+	Rankings []Ranking `json:"rankings"`
+	Summary  string    `json:"summary,omitempty"`
+}
+
+var (
+	tableCodePattern     = regexp.MustCompile(`\d{6}`)
+	tableSeparatorRegexp = regexp.MustCompile(`^[\s|:\-]+$`)
+	verdictSummaryRegexp = regexp.MustCompile(`(?s)(?:对比结论|结论|总结)` + labelSep + `(.+)`)
+)
+
+// ParseVerdictText 从对比结论步骤的输出中抽取排名。优先解析JSON，否则解析
+// “| 排名 | 股票 | 建议 | 评分 | 核心理由 |”格式的Markdown表格。
+// names 为参与对比的代码到名称的映射，表格中的代码不在其中的行被忽略
+func ParseVerdictText(text string, names map[string]string) (*Verdict, error) {
+	v, err := parseVerdictJSON(text)
+	if err != nil || len(v.Rankings) == 0 {
+		v = parseVerdictTable(text)
+	}
+	if err := v.normalize(names); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func parseVerdictJSON(text string) (*Verdict, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("未找到JSON对象")
+	}
+	var v Verdict
+	if err := json.Unmarshal([]byte(text[start:end+1]), &v); err != nil {
+		return nil, fmt.Errorf("解析对比结论JSON失败: %w", err)
+	}
+	return &v, nil
+}
+
+func parseVerdictTable(text string) *Verdict {
+	v := &Verdict{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") || tableSeparatorRegexp.MatchString(line) {
+			continue
+		}
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		if len(cells) < 4 {
+			continue
+		}
+		for i := range cells {
+			cells[i] = strings.Trim(strings.TrimSpace(cells[i]), "*")
+		}
+		rank, err := strconv.Atoi(strings.TrimPrefix(cells[0], "第"))
+		if err != nil {
+			// 表头或无法识别的行
+			continue
+		}
+		r := Ranking{Rank: rank, Code: tableCodePattern.FindString(cells[1]), Action: Action(cells[2])}
+		r.Score, _ = strconv.Atoi(strings.TrimSuffix(cells[3], "分"))
+		if len(cells) > 4 {
+			r.Reason = strings.Join(cells[4:], "|")
+		}
+		v.Rankings = append(v.Rankings, r)
+	}
+	if m := verdictSummaryRegexp.FindStringSubmatch(text); m != nil {
+		v.Summary = strings.Trim(strings.TrimSpace(m[1]), "*[] ")
+	}
+	return v
+}
+
+// normalize 过滤未参与对比和重复的代码，补全名称，规范化操作方向与评分，并按排名重新编号
+func (v *Verdict) normalize(names map[string]string) error {
+	seen := make(map[string]bool, len(v.Rankings))
+	rankings := v.Rankings[:0]
+	for _, r := range v.Rankings {
+		name, ok := names[r.Code]
+		if !ok || seen[r.Code] {
+			continue
+		}
+		seen[r.Code] = true
+		r.Name = name
+		r.Action = normalizeAction(string(r.Action))
+		r.Score = max(0, min(r.Score, 100))
+		r.Reason = strings.TrimSpace(r.Reason)
+		rankings = append(rankings, r)
+	}
+	if len(rankings) < 2 {
+		return fmt.Errorf("对比结论中可识别的排名少于2个")
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool { return rankings[i].Rank < rankings[j].Rank })
+	for i := range rankings {
+		rankings[i].Rank = i + 1
+	}
+	v.Rankings = rankings
+	v.Summary = strings.TrimSpace(v.Summary)
+	return nil
+}
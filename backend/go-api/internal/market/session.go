@@ -0,0 +1,109 @@
+package market
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Shanghai A股交易所所在时区（UTC+8，无夏令时）
+var Shanghai = time.FixedZone("CST", 8*3600)
+
+// 连续竞价时段（北京时间，分钟数）
+var sessions = [][2]int{
+	{9*60 + 30, 11*60 + 30},
+	{13 * 60, 15 * 60},
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+var (
+	holidaysMu sync.RWMutex
+	holidays   = make(map[string]bool)
+)
+
+// SetHolidays 设置工作日中的休市日期（YYYY-MM-DD，法定节假日），替换之前的设置
+func SetHolidays(dates []string) error {
+	set := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("休市日期格式应为 YYYY-MM-DD: %s", date)
+		}
+		set[date] = true
+	}
+	holidaysMu.Lock()
+	holidays = set
+	holidaysMu.Unlock()
+	return nil
+}
+
+// isTradingDay 判断北京时间 t 所在日期是否开市：工作日且不在 SetHolidays 设置的休市日期中
+func isTradingDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	holidaysMu.RLock()
+	defer holidaysMu.RUnlock()
+	return !holidays[t.Format("2006-01-02")]
+}
+
+// IsTradingDay 判断 t 所在日期是否为交易日，法定节假日需通过 SetHolidays 设置
+func IsTradingDay(t time.Time) bool {
+	return isTradingDay(t.In(Shanghai))
+}
+
+// IsTradingTime 判断是否处于A股连续竞价时段
+func IsTradingTime(t time.Time) bool {
+	t = t.In(Shanghai)
+	if !isTradingDay(t) {
+		return false
+	}
+	m := minuteOfDay(t)
+	for _, s := range sessions {
+		if m >= s[0] && m < s[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen 返回 t 之后（不含当前所在时段）下一个交易时段的开始时间
+func NextOpen(t time.Time) time.Time {
+	t = t.In(Shanghai)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Shanghai)
+	for {
+		if isTradingDay(day) {
+			for _, s := range sessions {
+				open := day.Add(time.Duration(s[0]) * time.Minute)
+				if open.After(t) {
+					return open
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
+
+// Expiry 计算行情相关数据的失效时间：交易时段内数据持续变化，有效期为 intraday；
+// 休市期间（午间、收盘后、周末及节假日）数据不变，有效至下一交易时段开盘
+func Expiry(now time.Time, intraday time.Duration) time.Time {
+	if IsTradingTime(now) {
+		return now.Add(intraday)
+	}
+	return NextOpen(now)
+}
+
+// LastTradingDay 返回 t 时刻最近一个已开盘的交易日（YYYY-MM-DD）：交易日开盘后为当天，否则为前一个交易日
+func LastTradingDay(t time.Time) string {
+	t = t.In(Shanghai)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, Shanghai)
+	if minuteOfDay(t) < sessions[0][0] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for !isTradingDay(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day.Format("2006-01-02")
+}
@@ -0,0 +1,89 @@
+package market
+
+import (
+	"testing"
+	"time"
+)
+
+func at(value string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, Shanghai)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// withHolidays 设置测试用休市日期，测试结束后清空
+func withHolidays(t *testing.T, dates ...string) {
+	t.Helper()
+	if err := SetHolidays(dates); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetHolidays(nil) })
+}
+
+func TestExpiry(t *testing.T) {
+	// 2024-06-07 为周五，2024-06-10 端午节休市
+	withHolidays(t, "2024-06-10")
+	const intraday = 5 * time.Minute
+
+	tests := []struct {
+		name string
+		now  string
+		want string
+	}{
+		{name: "morning session", now: "2024-06-06 10:00", want: "2024-06-06 10:05"},
+		{name: "afternoon session", now: "2024-06-06 14:58", want: "2024-06-06 15:03"},
+		{name: "before open", now: "2024-06-06 08:00", want: "2024-06-06 09:30"},
+		{name: "lunch break starts", now: "2024-06-06 11:30", want: "2024-06-06 13:00"},
+		{name: "lunch break", now: "2024-06-06 12:15", want: "2024-06-06 13:00"},
+		{name: "at close", now: "2024-06-06 15:00", want: "2024-06-07 09:30"},
+		{name: "evening", now: "2024-06-06 20:00", want: "2024-06-07 09:30"},
+		{name: "friday close before holiday monday", now: "2024-06-07 15:00", want: "2024-06-11 09:30"},
+		{name: "weekend", now: "2024-06-01 10:00", want: "2024-06-03 09:30"},
+		{name: "holiday during session hours", now: "2024-06-10 10:00", want: "2024-06-11 09:30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Expiry(at(tt.now), intraday)
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Expiry(%s) = %s, want %s", tt.now, got.In(Shanghai).Format("2006-01-02 15:04"), tt.want)
+			}
+		})
+	}
+}
+
+func TestTradingDayHolidays(t *testing.T) {
+	withHolidays(t, "2024-06-10")
+
+	tests := []struct {
+		now            string
+		tradingDay     bool
+		lastTradingDay string
+	}{
+		{now: "2024-06-07 08:00", tradingDay: true, lastTradingDay: "2024-06-06"},
+		{now: "2024-06-07 09:30", tradingDay: true, lastTradingDay: "2024-06-07"},
+		{now: "2024-06-08 10:00", tradingDay: false, lastTradingDay: "2024-06-07"},
+		{now: "2024-06-10 16:00", tradingDay: false, lastTradingDay: "2024-06-07"},
+		{now: "2024-06-11 09:00", tradingDay: true, lastTradingDay: "2024-06-07"},
+		{now: "2024-06-11 15:30", tradingDay: true, lastTradingDay: "2024-06-11"},
+	}
+
+	for _, tt := range tests {
+		now := at(tt.now)
+		if got := IsTradingDay(now); got != tt.tradingDay {
+			t.Errorf("IsTradingDay(%s) = %v, want %v", tt.now, got, tt.tradingDay)
+		}
+		if got := LastTradingDay(now); got != tt.lastTradingDay {
+			t.Errorf("LastTradingDay(%s) = %s, want %s", tt.now, got, tt.lastTradingDay)
+		}
+	}
+}
+
+func TestSetHolidaysRejectsInvalidDate(t *testing.T) {
+	withHolidays(t)
+	if err := SetHolidays([]string{"2024-6-10"}); err == nil {
+		t.Fatal("expected error for malformed date")
+	}
+}
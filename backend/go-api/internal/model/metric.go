@@ -0,0 +1,75 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// MissingText 提示词和文本中缺失数据的占位
+const MissingText = "数据缺失"
+
+// Metric 可缺失的数值指标，用于区分“数据缺失”与真实的0。
+// JSON 中以 null 表示缺失；以 %.2f 等格式化时缺失值输出“数据缺失”
+type Metric struct {
+	Value float64
+	Valid bool
+}
+
+// Val 构造有效的指标值
+func Val(v float64) Metric {
+	return Metric{Value: v, Valid: true}
+}
+
+// Or 返回指标值，缺失时返回 def
+func (m Metric) Or(def float64) float64 {
+	if !m.Valid {
+		return def
+	}
+	return m.Value
+}
+
+// Ptr 返回指向指标值的指针，缺失时返回 nil
+func (m Metric) Ptr() *float64 {
+	if !m.Valid {
+		return nil
+	}
+	v := m.Value
+	return &v
+}
+
+// HundredMillion 元转换为亿元，缺失值保持缺失
+func (m Metric) HundredMillion() Metric {
+	if m.Valid {
+		m.Value /= 1e8
+	}
+	return m
+}
+
+func (m Metric) MarshalJSON() ([]byte, error) {
+	if !m.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(m.Value)
+}
+
+func (m *Metric) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*m = Metric{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &m.Value); err != nil {
+		return fmt.Errorf("解析指标失败: %w", err)
+	}
+	m.Valid = true
+	return nil
+}
+
+// Format 实现 fmt.Formatter：有效值按原格式输出，缺失值输出“数据缺失”
+func (m Metric) Format(f fmt.State, verb rune) {
+	if !m.Valid {
+		fmt.Fprint(f, MissingText)
+		return
+	}
+	fmt.Fprintf(f, fmt.FormatString(f, verb), m.Value)
+}
@@ -2,45 +2,142 @@ package model
 
 // StockAnalyzeRequest 股票分析请求
 type StockAnalyzeRequest struct {
+	Code         string `json:"code" binding:"required"`
+	Name         string `json:"name"`
+	ForceRefresh bool   `json:"force_refresh"` // 忽略缓存结果，强制重新分析
+}
+
+// WatchlistRequest 创建或更新自选股列表的请求。更新时 Codes 为 nil 表示不修改股票
+type WatchlistRequest struct {
+	Name  string   `json:"name"`
+	Codes []string `json:"codes"` // 股票代码或名称
+}
+
+// WatchlistStockRequest 向自选股列表添加股票的请求
+type WatchlistStockRequest struct {
 	Code string `json:"code" binding:"required"`
-	Name string `json:"name"`
 }
 
-// BasicInfo 基本信息
+// AlertRuleRequest 创建提醒规则的请求
+type AlertRuleRequest struct {
+	Code      string  `json:"code" binding:"required"` // 股票代码或名称
+	Kind      string  `json:"kind" binding:"required"`
+	Threshold float64 `json:"threshold"`
+	From      string  `json:"from"` // 仅 action_change：变化前的操作建议（buy/hold/sell 或 买入/持有/卖出），空表示任意
+	To        string  `json:"to"`   // 仅 action_change：变化后的操作建议，空表示任意
+}
+
+// WebhookRequest 创建提醒推送地址的请求
+type WebhookRequest struct {
+	URL    string `json:"url" binding:"required"`
+	Format string `json:"format"` // json（默认）/ wecom / dingtalk
+	Secret string `json:"secret"` // 签名密钥，留空时自动生成
+}
+
+// StockCompareRequest 多股横向对比请求
+type StockCompareRequest struct {
+	Codes []string `json:"codes" binding:"required"` // 2-5个股票代码或名称
+}
+
+// PortfolioHolding 一笔持仓
+type PortfolioHolding struct {
+	Code   string  `json:"code" binding:"required"` // 股票代码或名称
+	Shares float64 `json:"shares"`                  // 持股数，必须为正
+	Cost   float64 `json:"cost"`                    // 成本价（元/股），不能为负
+}
+
+// PortfolioAnalyzeRequest 持仓组合分析请求
+type PortfolioAnalyzeRequest struct {
+	Holdings []PortfolioHolding `json:"holdings" binding:"required"`
+}
+
+// ScreenRequest 条件选股请求
+type ScreenRequest struct {
+	Filter     string `json:"filter"`      // 筛选表达式，空表示全部股票
+	Sort       string `json:"sort"`        // 排序字段，默认 market_cap
+	Order      string `json:"order"`       // asc / desc（默认）
+	Page       int    `json:"page"`        // 页码，默认1
+	PageSize   int    `json:"page_size"`   // 每页数量，默认20
+	AnalyzeTop int    `json:"analyze_top"` // 对排序后的前N只股票发起分析，0表示不分析
+}
+
+// BasicInfo 基本信息。数值指标可能缺失，见 Metric
 type BasicInfo struct {
-	Code       string  `json:"code"`
-	Name       string  `json:"name"`
-	Industry   string  `json:"industry"`
-	MarketCap  float64 `json:"market_cap"`
-	PETTM      float64 `json:"pe_ttm"`
-	PB         float64 `json:"pb"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Industry  string `json:"industry"`
+	MarketCap Metric `json:"market_cap"`
+	PETTM     Metric `json:"pe_ttm"`
+	PB        Metric `json:"pb"`
 }
 
 // PriceInfo 价格信息
 type PriceInfo struct {
-	LatestPrice     float64 `json:"latest_price"`
-	PriceChangePct  float64 `json:"price_change_pct"`
-	Date            string  `json:"date"`
+	LatestPrice    Metric `json:"latest_price"`
+	PriceChangePct Metric `json:"price_change_pct"`
+	Date           string `json:"date"`
 }
 
 // FinancialMetrics 财务指标
 type FinancialMetrics struct {
-	ROE            float64 `json:"roe"`
-	ROA            float64 `json:"roa"`
-	GrossMargin    float64 `json:"gross_margin"`
-	NetMargin      float64 `json:"net_margin"`
-	DebtRatio      float64 `json:"debt_ratio"`
-	CurrentRatio   float64 `json:"current_ratio"`
-	RevenueGrowth  float64 `json:"revenue_growth"`
-	ProfitGrowth   float64 `json:"profit_growth"`
+	ReportDate    string `json:"report_date"` // 报告期 YYYY-MM-DD
+	ROE           Metric `json:"roe"`
+	ROA           Metric `json:"roa"`
+	GrossMargin   Metric `json:"gross_margin"`
+	NetMargin     Metric `json:"net_margin"`
+	DebtRatio     Metric `json:"debt_ratio"`
+	CurrentRatio  Metric `json:"current_ratio"`
+	RevenueGrowth Metric `json:"revenue_growth"`
+	ProfitGrowth  Metric `json:"profit_growth"`
 }
 
 // PythonAnalysisResponse Python分析响应
 type PythonAnalysisResponse struct {
-	Code              string           `json:"code"`
-	Name              string           `json:"name"`
-	BasicInfo         BasicInfo        `json:"basic_info"`
-	Price             PriceInfo        `json:"price"`
-	FinancialMetrics  FinancialMetrics `json:"financial_metrics"`
-	Risks             []string         `json:"risks"`
+	Code             string           `json:"code"`
+	Name             string           `json:"name"`
+	BasicInfo        BasicInfo        `json:"basic_info"`
+	Price            PriceInfo        `json:"price"`
+	FinancialMetrics FinancialMetrics `json:"financial_metrics"`
+	FinancialHistory FinancialHistory `json:"financial_history"`
+	Risks            []string         `json:"risks"`
+}
+
+// Bar 日K线
+type Bar struct {
+	Date   string  `json:"date"` // YYYY-MM-DD
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"` // 成交量（股）
+}
+
+// FinancialPeriod 单个报告期的财务摘要。金额单位为亿元，比率为百分比
+type FinancialPeriod struct {
+	ReportDate    string `json:"report_date"` // YYYY-MM-DD
+	Revenue       Metric `json:"revenue"`     // 营业总收入；季报为年初至报告期末累计值
+	NetProfit     Metric `json:"net_profit"`  // 净利润；季报为年初至报告期末累计值
+	ROE           Metric `json:"roe"`
+	GrossMargin   Metric `json:"gross_margin"`
+	NetMargin     Metric `json:"net_margin"`
+	DebtRatio     Metric `json:"debt_ratio"`
+	RevenueGrowth Metric `json:"revenue_growth"`
+	ProfitGrowth  Metric `json:"profit_growth"`
+	EPS           Metric `json:"eps"`
+}
+
+// FinancialHistory 多期财务摘要，均按报告期升序
+type FinancialHistory struct {
+	Quarterly []FinancialPeriod `json:"quarterly"` // 最近8个报告期
+	Annual    []FinancialPeriod `json:"annual"`    // 最近5个年度
+}
+
+// Security 证券主数据，用于股票搜索
+type Security struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Pinyin   string `json:"pinyin"`   // 名称拼音首字母，如 GZMT
+	Exchange string `json:"exchange"` // SH/SZ/BJ
+	Board    string `json:"board"`    // 主板/创业板/科创板/北交所
+	Industry string `json:"industry"`
 }
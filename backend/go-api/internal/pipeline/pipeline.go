@@ -0,0 +1,236 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"stock-analysis-api/backend/go-api/internal/llm"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StepSpec 单个分析步骤的声明
+type StepSpec struct {
+	Step         llm.AnalysisStep `yaml:"step"`
+	Role         string           `yaml:"role"`       // 前端展示的角色名
+	Inputs       []string         `yaml:"inputs"`     // 依赖的数据键（其他步骤的输出键或基础数据键）
+	OutputKey    string           `yaml:"output_key"` // 本步骤输出写入的数据键
+	SystemPrompt string           `yaml:"system_prompt,omitempty"`
+	UserPrompt   string           `yaml:"user_prompt,omitempty"` // text/template 模板，数据键作为字段
+	LLM          *StepLLM         `yaml:"llm,omitempty"`         // 步骤专用模型，为空时使用默认LLM
+	Decision     bool             `yaml:"decision,omitempty"`    // 是否从输出中抽取结构化决策
+}
+
+// StepLLM 步骤级模型路由配置
+type StepLLM struct {
+	Provider         string `yaml:"provider,omitempty"` // 为空时沿用 LLM_PROVIDER
+	llm.ModelOptions `yaml:",inline"`
+}
+
+// Definition 分析流水线定义
+type Definition struct {
+	Name  string     `yaml:"name"`
+	Steps []StepSpec `yaml:"steps"`
+}
+
+// Default 默认的五步分析流水线
+func Default() *Definition {
+	return &Definition{
+		Name: "default",
+		Steps: []StepSpec{
+			{Step: llm.StepComprehensive, Role: "综合分析", OutputKey: "comprehensive_analysis"},
+			{Step: llm.StepTechnical, Role: "技术分析", Inputs: []string{"technical_summary"}, OutputKey: "technical_analysis"},
+			{Step: llm.StepDebateBull, Role: "多头观点", Inputs: []string{"comprehensive_analysis", "technical_analysis"}, OutputKey: "bull_case"},
+			{Step: llm.StepDebateBear, Role: "空头观点", Inputs: []string{"comprehensive_analysis", "technical_analysis"}, OutputKey: "bear_case"},
+			{Step: llm.StepTrader, Role: "交易员决策", Inputs: []string{"comprehensive_analysis", "technical_analysis", "bull_case", "bear_case"}, OutputKey: "trader_decision", Decision: true},
+			{Step: llm.StepFinal, Role: "最终决策", Inputs: []string{"comprehensive_analysis", "technical_analysis", "bull_case", "bear_case", "trader_decision"}, OutputKey: "final_decision", Decision: true},
+		},
+	}
+}
+
+// Comparison 多股横向对比流水线：对比分析后给出排名结论
+func Comparison() *Definition {
+	return &Definition{
+		Name: "comparison",
+		Steps: []StepSpec{
+			{Step: llm.StepCompare, Role: "横向对比", Inputs: []string{"comparison_table", "peer_trends"}, OutputKey: "compare_analysis"},
+			{Step: llm.StepCompareVerdict, Role: "对比结论", Inputs: []string{"comparison_table", "compare_analysis"}, OutputKey: "compare_verdict"},
+		},
+	}
+}
+
+// Portfolio 持仓组合分析流水线：组合经理给出诊断与调仓建议
+func Portfolio() *Definition {
+	return &Definition{
+		Name: "portfolio",
+		Steps: []StepSpec{
+			{Step: llm.StepPortfolio, Role: "组合经理", Inputs: []string{"portfolio_summary", "holding_trends"}, OutputKey: "portfolio_advice"},
+		},
+	}
+}
+
+// Load 从YAML文件加载流水线定义，path为空时返回默认流水线
+func Load(path string) (*Definition, error) {
+	if path == "" {
+		return Default(), nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取流水线文件失败: %w", err)
+	}
+
+	var def Definition
+	if err := yaml.Unmarshal(raw, &def); err != nil {
+		return nil, fmt.Errorf("解析流水线文件失败: %w", err)
+	}
+
+	// 注册自定义步骤的提示词
+	for _, s := range def.Steps {
+		if s.SystemPrompt == "" && s.UserPrompt == "" {
+			continue
+		}
+		if err := llm.RegisterPrompt(s.Step, s.SystemPrompt, s.UserPrompt); err != nil {
+			return nil, fmt.Errorf("步骤 %s 提示词无效: %w", s.Step, err)
+		}
+	}
+
+	return &def, nil
+}
+
+// Plan 经过校验的执行计划
+type Plan struct {
+	Steps     []StepSpec                              // 拓扑排序后的步骤
+	DependsOn map[llm.AnalysisStep][]llm.AnalysisStep // 步骤之间的依赖关系
+	progress  map[llm.AnalysisStep]int
+	extras    map[llm.AnalysisStep][]llm.PromptInput // 内置提示词未渲染、需追加的已声明输入
+}
+
+// Compile 校验流水线定义并生成执行计划
+func (d *Definition) Compile() (*Plan, error) {
+	if len(d.Steps) == 0 {
+		return nil, fmt.Errorf("流水线 %s 没有任何步骤", d.Name)
+	}
+
+	producers := make(map[string]llm.AnalysisStep)
+	specs := make(map[llm.AnalysisStep]StepSpec)
+	for _, s := range d.Steps {
+		if s.Step == "" || s.OutputKey == "" {
+			return nil, fmt.Errorf("步骤定义缺少 step 或 output_key: %+v", s)
+		}
+		if _, dup := specs[s.Step]; dup {
+			return nil, fmt.Errorf("步骤重复: %s", s.Step)
+		}
+		if other, dup := producers[s.OutputKey]; dup {
+			return nil, fmt.Errorf("输出键 %s 同时由 %s 和 %s 产生", s.OutputKey, other, s.Step)
+		}
+		if s.Role == "" {
+			s.Role = string(s.Step)
+		}
+		specs[s.Step] = s
+		producers[s.OutputKey] = s.Step
+	}
+
+	// 未被任何步骤产出的输入视为基础数据（由prepareLLMData提供）
+	dependsOn := make(map[llm.AnalysisStep][]llm.AnalysisStep)
+	for _, s := range d.Steps {
+		if err := checkRequiredInputs(s, producers); err != nil {
+			return nil, err
+		}
+		for _, input := range s.Inputs {
+			if producer, ok := producers[input]; ok {
+				if producer == s.Step {
+					return nil, fmt.Errorf("步骤 %s 依赖自身输出", s.Step)
+				}
+				dependsOn[s.Step] = append(dependsOn[s.Step], producer)
+			}
+		}
+	}
+
+	// Kahn算法拓扑排序，同时检测环；同层按定义顺序保持稳定
+	indegree := make(map[llm.AnalysisStep]int)
+	for _, s := range d.Steps {
+		indegree[s.Step] = len(dependsOn[s.Step])
+	}
+
+	ordered := make([]StepSpec, 0, len(d.Steps))
+	visited := make(map[llm.AnalysisStep]bool)
+	for len(ordered) < len(d.Steps) {
+		progressed := false
+		for _, s := range d.Steps {
+			if visited[s.Step] || indegree[s.Step] > 0 {
+				continue
+			}
+			visited[s.Step] = true
+			ordered = append(ordered, specs[s.Step])
+			progressed = true
+			for _, other := range d.Steps {
+				for _, dep := range dependsOn[other.Step] {
+					if dep == s.Step {
+						indegree[other.Step]--
+					}
+				}
+			}
+		}
+		if !progressed {
+			return nil, fmt.Errorf("流水线 %s 存在循环依赖", d.Name)
+		}
+	}
+
+	// 进度按拓扑顺序均分，最后一步为100
+	progress := make(map[llm.AnalysisStep]int)
+	for i, s := range ordered {
+		progress[s.Step] = (i + 1) * 100 / len(ordered)
+	}
+
+	// 内置步骤声明了其提示词不渲染的输入（如自定义步骤的输出）时，以产出步骤的角色名为标题追加到提示词
+	extras := make(map[llm.AnalysisStep][]llm.PromptInput)
+	for _, s := range d.Steps {
+		for _, key := range llm.UncoveredInputs(s.Step, s.Inputs) {
+			title := key
+			if producer, ok := producers[key]; ok {
+				title = specs[producer].Role
+			}
+			extras[s.Step] = append(extras[s.Step], llm.PromptInput{Key: key, Title: title})
+		}
+	}
+
+	return &Plan{
+		Steps:     ordered,
+		DependsOn: dependsOn,
+		progress:  progress,
+		extras:    extras,
+	}, nil
+}
+
+// StepData 复制步骤的输入数据，并登记内置提示词需追加的输入
+func (p *Plan) StepData(step llm.AnalysisStep, data map[string]interface{}) map[string]interface{} {
+	stepData := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		stepData[k] = v
+	}
+	llm.WithExtraInputs(stepData, p.extras[step])
+	return stepData
+}
+
+// checkRequiredInputs 校验内置步骤的提示词所需的前序输出已在 inputs 中声明且有步骤产出，
+// 否则步骤会在依赖完成前启动或拿到空内容
+func checkRequiredInputs(s StepSpec, producers map[string]llm.AnalysisStep) error {
+	declared := make(map[string]bool, len(s.Inputs))
+	for _, input := range s.Inputs {
+		declared[input] = true
+	}
+	for _, key := range llm.RequiredInputs(s.Step) {
+		if !declared[key] {
+			return fmt.Errorf("步骤 %s 未在 inputs 中声明必需的输入 %s", s.Step, key)
+		}
+		if _, ok := producers[key]; !ok {
+			return fmt.Errorf("步骤 %s 的必需输入 %s 没有步骤产出", s.Step, key)
+		}
+	}
+	return nil
+}
+
+// Progress 返回步骤对应的进度百分比
+func (p *Plan) Progress(step llm.AnalysisStep) int {
+	return p.progress[step]
+}
@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"stock-analysis-api/backend/go-api/internal/llm"
+)
+
+func TestBuiltinDefinitionsCompile(t *testing.T) {
+	for _, def := range []*Definition{Default(), Comparison(), Portfolio()} {
+		if _, err := def.Compile(); err != nil {
+			t.Errorf("%s: %v", def.Name, err)
+		}
+	}
+}
+
+func TestCompileRequiredInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []StepSpec
+		wantErr string
+	}{
+		{
+			name: "debate without comprehensive step",
+			steps: []StepSpec{
+				{Step: llm.StepDebateBull, Inputs: []string{"comprehensive_analysis"}, OutputKey: "bull_case"},
+			},
+			wantErr: "没有步骤产出",
+		},
+		{
+			name: "debate without declared input",
+			steps: []StepSpec{
+				{Step: llm.StepComprehensive, OutputKey: "comprehensive_analysis"},
+				{Step: llm.StepDebateBear, OutputKey: "bear_case"},
+			},
+			wantErr: "未在 inputs 中声明",
+		},
+		{
+			name: "custom step has no required inputs",
+			steps: []StepSpec{
+				{Step: "esg", Inputs: []string{"industry"}, OutputKey: "esg_analysis"},
+			},
+		},
+		{
+			name: "debate with comprehensive step",
+			steps: []StepSpec{
+				{Step: llm.StepComprehensive, OutputKey: "comprehensive_analysis"},
+				{Step: llm.StepDebateBull, Inputs: []string{"comprehensive_analysis"}, OutputKey: "bull_case"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := &Definition{Name: "test", Steps: tt.steps}
+			_, err := def.Compile()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCustomStepOutputReachesBuiltinPrompt(t *testing.T) {
+	def, err := Load("../../config/pipeline.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := def.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]interface{}{
+		"name":                   "贵州茅台",
+		"code":                   "600519",
+		"comprehensive_analysis": "综合分析内容",
+		"technical_analysis":     "技术分析内容",
+		"industry_analysis":      "白酒行业高端化趋势延续",
+	}
+
+	tests := []struct {
+		step llm.AnalysisStep
+		want bool
+	}{
+		{step: llm.StepDebateBull, want: true},
+		{step: llm.StepDebateBear, want: true},
+		{step: llm.StepTrader, want: false}, // 未声明 industry_analysis
+	}
+	for _, tt := range tests {
+		prompt := llm.BuildUserPrompt(tt.step, plan.StepData(tt.step, data))
+		got := strings.Contains(prompt, "【行业分析】\n白酒行业高端化趋势延续")
+		if got != tt.want {
+			t.Errorf("%s prompt contains industry analysis = %v, want %v\n%s", tt.step, got, tt.want, prompt)
+		}
+	}
+}
+
+func TestDefaultPlanAddsNoExtraInputs(t *testing.T) {
+	plan, err := Default().Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range plan.Steps {
+		if extras := plan.extras[spec.Step]; len(extras) > 0 {
+			t.Errorf("%s: unexpected extra inputs %v", spec.Step, extras)
+		}
+	}
+}
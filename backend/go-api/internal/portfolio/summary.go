@@ -0,0 +1,343 @@
+// Package portfolio 根据持仓（股数、成本价）与行情数据计算组合的权重、浮动盈亏、行业集中度、加权估值与财务质量
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"strings"
+)
+
+// 集中度提示阈值（占组合市值的百分比）
+const (
+	maxPositionWeight = 30.0 // 单只股票
+	maxSectorWeight   = 50.0 // 单一行业
+)
+
+// unknownIndustry 行业缺失时的分组名
+const unknownIndustry = "未知行业"
+
+// noRisk 数据源在未检测到风险时返回的占位提示
+const noRisk = "未检测到明显风险"
+
+// Holding 一笔持仓及其行情数据，Snapshot 为该股票的行情与财务数据
+type Holding struct {
+	Symbol   symbol.Symbol
+	Shares   float64 // 持股数
+	Cost     float64 // 成本价（元/股）
+	Snapshot *model.PythonAnalysisResponse
+}
+
+// Position 单只股票的持仓统计
+type Position struct {
+	Code        string       `json:"code"`
+	Name        string       `json:"name"`
+	Board       string       `json:"board,omitempty"`
+	Industry    string       `json:"industry"`
+	Shares      float64      `json:"shares"`
+	Cost        float64      `json:"cost"`
+	Price       float64      `json:"price"`
+	MarketValue float64      `json:"market_value"` // 持仓市值（元）
+	CostValue   float64      `json:"cost_value"`   // 持仓成本（元）
+	Weight      float64      `json:"weight"`       // 占组合市值的百分比
+	PnL         float64      `json:"pnl"`          // 浮动盈亏（元）
+	PnLPct      model.Metric `json:"pnl_pct"`      // 浮动盈亏比例（%），成本为0时缺失
+	PETTM       model.Metric `json:"pe_ttm"`
+	PB          model.Metric `json:"pb"`
+	ROE         model.Metric `json:"roe"`
+	Risks       []string     `json:"risks,omitempty"` // 数据源的风险提示
+}
+
+// Sector 行业分布
+type Sector struct {
+	Industry string   `json:"industry"`
+	Weight   float64  `json:"weight"` // 占组合市值的百分比
+	Codes    []string `json:"codes"`
+}
+
+// Concentration 集中度指标
+type Concentration struct {
+	TopPosition       float64 `json:"top_position"`       // 第一大持仓权重（%）
+	TopThree          float64 `json:"top_three"`          // 前三大持仓权重合计（%）
+	TopSector         float64 `json:"top_sector"`         // 第一大行业权重（%）
+	HHI               float64 `json:"hhi"`                // 赫芬达尔指数（按持仓权重，0-10000）
+	EffectiveHoldings float64 `json:"effective_holdings"` // 等效持仓数（10000/HHI）
+}
+
+// Factor 组合层面的加权指标。Coverage 为有数据参与计算的持仓权重合计（%）
+type Factor struct {
+	Key      string       `json:"key"`
+	Label    string       `json:"label"`
+	Unit     string       `json:"unit,omitempty"`
+	Value    model.Metric `json:"value"`
+	Coverage float64      `json:"coverage"`
+}
+
+// Summary 组合统计
+type Summary struct {
+	MarketValue   float64       `json:"market_value"`
+	CostValue     float64       `json:"cost_value"`
+	PnL           float64       `json:"pnl"`
+	PnLPct        model.Metric  `json:"pnl_pct"`
+	Positions     []Position    `json:"positions"` // 按权重降序
+	Sectors       []Sector      `json:"sectors"`   // 按权重降序
+	Concentration Concentration `json:"concentration"`
+	Valuation     []Factor      `json:"valuation"`
+	Quality       []Factor      `json:"quality"`
+	LossMaking    float64       `json:"loss_making"` // PE(TTM)为负的持仓权重合计（%）
+	Warnings      []string      `json:"warnings"`
+}
+
+// valuationFactors 估值指标按市值加权调和平均（组合市值/组合盈利），仅计入正值
+var valuationFactors = []struct {
+	key, label string
+	value      func(*model.PythonAnalysisResponse) model.Metric
+}{
+	{"pe_ttm", "市盈率(TTM)", func(s *model.PythonAnalysisResponse) model.Metric { return s.BasicInfo.PETTM }},
+	{"pb", "市净率", func(s *model.PythonAnalysisResponse) model.Metric { return s.BasicInfo.PB }},
+}
+
+// qualityFactors 财务质量指标按市值加权平均
+var qualityFactors = []struct {
+	key, label, unit string
+	value            func(*model.PythonAnalysisResponse) model.Metric
+}{
+	{"roe", "ROE", "%", func(s *model.PythonAnalysisResponse) model.Metric { return s.FinancialMetrics.ROE }},
+	{"gross_margin", "毛利率", "%", func(s *model.PythonAnalysisResponse) model.Metric { return s.FinancialMetrics.GrossMargin }},
+	{"net_margin", "净利率", "%", func(s *model.PythonAnalysisResponse) model.Metric { return s.FinancialMetrics.NetMargin }},
+	{"debt_ratio", "资产负债率", "%", func(s *model.PythonAnalysisResponse) model.Metric { return s.FinancialMetrics.DebtRatio }},
+	{"revenue_growth", "营收增长", "%", func(s *model.PythonAnalysisResponse) model.Metric { return s.FinancialMetrics.RevenueGrowth }},
+	{"profit_growth", "净利润增长", "%", func(s *model.PythonAnalysisResponse) model.Metric { return s.FinancialMetrics.ProfitGrowth }},
+}
+
+// Build 计算组合统计。持仓缺少最新价时无法计算权重，返回错误
+func Build(holdings []Holding) (*Summary, error) {
+	s := &Summary{Positions: make([]Position, 0, len(holdings))}
+	for _, h := range holdings {
+		price := h.Snapshot.Price.LatestPrice
+		if !price.Valid || price.Value <= 0 {
+			return nil, fmt.Errorf("无法获取 %s(%s) 的最新价，无法计算持仓市值", h.Snapshot.Name, h.Snapshot.Code)
+		}
+		p := Position{
+			Code:        h.Snapshot.Code,
+			Name:        h.Snapshot.Name,
+			Board:       string(h.Symbol.Board),
+			Industry:    h.Snapshot.BasicInfo.Industry,
+			Shares:      h.Shares,
+			Cost:        h.Cost,
+			Price:       price.Value,
+			MarketValue: price.Value * h.Shares,
+			CostValue:   h.Cost * h.Shares,
+			PETTM:       h.Snapshot.BasicInfo.PETTM,
+			PB:          h.Snapshot.BasicInfo.PB,
+			ROE:         h.Snapshot.FinancialMetrics.ROE,
+		}
+		if p.Industry == "" {
+			p.Industry = unknownIndustry
+		}
+		for _, r := range h.Snapshot.Risks {
+			if r = strings.TrimSpace(r); r != "" && r != noRisk {
+				p.Risks = append(p.Risks, r)
+			}
+		}
+		p.PnL = p.MarketValue - p.CostValue
+		p.PnLPct = percent(p.PnL, p.CostValue)
+		s.MarketValue += p.MarketValue
+		s.CostValue += p.CostValue
+		s.Positions = append(s.Positions, p)
+	}
+	s.PnL = s.MarketValue - s.CostValue
+	s.PnLPct = percent(s.PnL, s.CostValue)
+
+	for i := range s.Positions {
+		s.Positions[i].Weight = s.Positions[i].MarketValue / s.MarketValue * 100
+	}
+	// 权重相同时保持输入顺序
+	sort.SliceStable(s.Positions, func(i, j int) bool { return s.Positions[i].Weight > s.Positions[j].Weight })
+
+	snapshots := make(map[string]*model.PythonAnalysisResponse, len(holdings))
+	for _, h := range holdings {
+		snapshots[h.Snapshot.Code] = h.Snapshot
+	}
+	s.Sectors = sectors(s.Positions)
+	s.Concentration = concentration(s.Positions, s.Sectors)
+	s.Valuation, s.LossMaking = valuation(s.Positions, snapshots)
+	s.Quality = quality(s.Positions, snapshots)
+	s.Warnings = warnings(s)
+	return s, nil
+}
+
+func sectors(positions []Position) []Sector {
+	var result []Sector
+	index := make(map[string]int)
+	for _, p := range positions {
+		i, ok := index[p.Industry]
+		if !ok {
+			i = len(result)
+			index[p.Industry] = i
+			result = append(result, Sector{Industry: p.Industry})
+		}
+		result[i].Weight += p.Weight
+		result[i].Codes = append(result[i].Codes, p.Code)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Weight > result[j].Weight })
+	return result
+}
+
+func concentration(positions []Position, sectors []Sector) Concentration {
+	var c Concentration
+	for i, p := range positions {
+		if i < 3 {
+			c.TopThree += p.Weight
+		}
+		c.HHI += p.Weight * p.Weight
+	}
+	if len(positions) > 0 {
+		c.TopPosition = positions[0].Weight
+	}
+	if len(sectors) > 0 {
+		c.TopSector = sectors[0].Weight
+	}
+	if c.HHI > 0 {
+		c.EffectiveHoldings = 10000 / c.HHI
+	}
+	return c
+}
+
+// valuation 加权估值，同时返回亏损（PE为负）持仓的权重合计
+func valuation(positions []Position, snapshots map[string]*model.PythonAnalysisResponse) ([]Factor, float64) {
+	factors := make([]Factor, 0, len(valuationFactors))
+	lossMaking := 0.0
+	for _, f := range valuationFactors {
+		factor := Factor{Key: f.key, Label: f.label}
+		var yield float64 // Σ 权重/估值，即组合的盈利（净资产）收益率
+		for _, p := range positions {
+			v := f.value(snapshots[p.Code])
+			if !v.Valid {
+				continue
+			}
+			if v.Value <= 0 {
+				if f.key == "pe_ttm" {
+					lossMaking += p.Weight
+				}
+				continue
+			}
+			factor.Coverage += p.Weight
+			yield += p.Weight / v.Value
+		}
+		if yield > 0 {
+			factor.Value = model.Val(factor.Coverage / yield)
+		}
+		factors = append(factors, factor)
+	}
+	return factors, lossMaking
+}
+
+func quality(positions []Position, snapshots map[string]*model.PythonAnalysisResponse) []Factor {
+	factors := make([]Factor, 0, len(qualityFactors))
+	for _, f := range qualityFactors {
+		factor := Factor{Key: f.key, Label: f.label, Unit: f.unit}
+		var sum float64
+		for _, p := range positions {
+			if v := f.value(snapshots[p.Code]); v.Valid {
+				factor.Coverage += p.Weight
+				sum += p.Weight * v.Value
+			}
+		}
+		if factor.Coverage > 0 {
+			factor.Value = model.Val(sum / factor.Coverage)
+		}
+		factors = append(factors, factor)
+	}
+	return factors
+}
+
+// warnings 集中度与亏损持仓提示，无提示时为空切片
+func warnings(s *Summary) []string {
+	result := []string{}
+	for _, p := range s.Positions {
+		if p.Weight > maxPositionWeight {
+			result = append(result, fmt.Sprintf("单只股票仓位过重: %s(%s) 占 %.1f%%，超过 %.0f%%", p.Name, p.Code, p.Weight, maxPositionWeight))
+		}
+	}
+	for _, sec := range s.Sectors {
+		if sec.Weight > maxSectorWeight && len(s.Sectors) > 1 {
+			result = append(result, fmt.Sprintf("行业集中: %s 占 %.1f%%，超过 %.0f%%", sec.Industry, sec.Weight, maxSectorWeight))
+		}
+	}
+	if len(s.Sectors) == 1 && len(s.Positions) > 1 {
+		result = append(result, "全部持仓集中在同一行业: "+s.Sectors[0].Industry)
+	}
+	if s.LossMaking > 0 {
+		result = append(result, fmt.Sprintf("亏损公司（PE为负）合计占 %.1f%%", s.LossMaking))
+	}
+	return result
+}
+
+// Text 以Markdown描述组合统计，用于提示词
+func (s *Summary) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- 总市值 %.2f 元，总成本 %.2f 元，浮动盈亏 %+.2f 元（%s）\n",
+		s.MarketValue, s.CostValue, s.PnL, withUnit(s.PnLPct, "%+.2f", "%"))
+	fmt.Fprintf(&sb, "- 持仓 %d 只，第一大持仓 %.1f%%，前三大 %.1f%%，第一大行业 %.1f%%，HHI %.0f（等效持仓数 %.1f）\n",
+		len(s.Positions), s.Concentration.TopPosition, s.Concentration.TopThree, s.Concentration.TopSector,
+		s.Concentration.HHI, s.Concentration.EffectiveHoldings)
+
+	sb.WriteString("\n| 股票 | 行业 | 持股 | 成本价 | 现价 | 市值(元) | 权重 | 浮动盈亏(元) | 盈亏比例 | PE(TTM) | PB | ROE |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, p := range s.Positions {
+		fmt.Fprintf(&sb, "| %s(%s) | %s | %.0f | %.2f | %.2f | %.2f | %.1f%% | %+.2f | %s | %.2f | %.2f | %s |\n",
+			p.Name, p.Code, p.Industry, p.Shares, p.Cost, p.Price, p.MarketValue, p.Weight, p.PnL,
+			withUnit(p.PnLPct, "%+.2f", "%"), p.PETTM, p.PB, withUnit(p.ROE, "%.2f", "%"))
+	}
+
+	sb.WriteString("\n行业分布:\n")
+	for _, sec := range s.Sectors {
+		fmt.Fprintf(&sb, "- %s: %.1f%%（%s）\n", sec.Industry, sec.Weight, strings.Join(sec.Codes, "、"))
+	}
+
+	sb.WriteString("\n加权估值（市值加权调和平均，仅计入正值）:\n")
+	for _, f := range s.Valuation {
+		fmt.Fprintf(&sb, "- %s: %.2f（覆盖 %.1f%% 仓位）\n", f.Label, f.Value, f.Coverage)
+	}
+	sb.WriteString("\n加权财务质量（市值加权平均）:\n")
+	for _, f := range s.Quality {
+		fmt.Fprintf(&sb, "- %s: %s（覆盖 %.1f%% 仓位）\n", f.Label, withUnit(f.Value, "%.2f", f.Unit), f.Coverage)
+	}
+
+	if len(s.Warnings) > 0 {
+		sb.WriteString("\n组合风险提示:\n")
+		for _, w := range s.Warnings {
+			fmt.Fprintf(&sb, "- %s\n", w)
+		}
+	}
+
+	header := false
+	for _, p := range s.Positions {
+		if len(p.Risks) == 0 {
+			continue
+		}
+		if !header {
+			sb.WriteString("\n个股风险提示:\n")
+			header = true
+		}
+		fmt.Fprintf(&sb, "- %s(%s): %s\n", p.Name, p.Code, strings.Join(p.Risks, "；"))
+	}
+	return sb.String()
+}
+
+func percent(part, whole float64) model.Metric {
+	if whole <= 0 {
+		return model.Metric{}
+	}
+	return model.Val(part / whole * 100)
+}
+
+// withUnit 按 format 格式化指标并附加单位，缺失时只输出“数据缺失”
+func withUnit(m model.Metric, format, unit string) string {
+	if !m.Valid {
+		return model.MissingText
+	}
+	return fmt.Sprintf(format, m.Value) + unit
+}
@@ -0,0 +1,140 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+)
+
+// holding 构造一笔持仓，pe/roe 为 nil 表示数据缺失
+func holding(code, industry string, price model.Metric, shares, cost float64, pe, roe *float64) Holding {
+	snap := &model.PythonAnalysisResponse{
+		Code:      code,
+		Name:      "股票" + code,
+		BasicInfo: model.BasicInfo{Industry: industry},
+		Price:     model.PriceInfo{LatestPrice: price},
+	}
+	if pe != nil {
+		snap.BasicInfo.PETTM = model.Val(*pe)
+	}
+	if roe != nil {
+		snap.FinancialMetrics.ROE = model.Val(*roe)
+	}
+	sym, _ := symbol.FromCode(code)
+	return Holding{Symbol: sym, Shares: shares, Cost: cost, Snapshot: snap}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestBuildRejectsMissingPrice(t *testing.T) {
+	tests := []struct {
+		name  string
+		price model.Metric
+	}{
+		{name: "missing", price: model.Metric{}},
+		{name: "zero", price: model.Val(0)},
+		{name: "negative", price: model.Val(-1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holdings := []Holding{
+				holding("600519", "白酒", model.Val(100), 100, 80, nil, nil),
+				holding("000858", "白酒", tt.price, 100, 80, nil, nil),
+			}
+			if _, err := Build(holdings); err == nil {
+				t.Fatal("expected error for holding without a valid price")
+			}
+		})
+	}
+}
+
+func TestBuildWeightsAndSummary(t *testing.T) {
+	s, err := Build([]Holding{
+		holding("000858", "白酒", model.Val(50), 200, 0, nil, ptr(20)), // 成本为0，盈亏比例缺失
+		holding("600519", "白酒", model.Val(100), 300, 80, ptr(30), ptr(30)),
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if s.MarketValue != 40000 || s.CostValue != 24000 || s.PnL != 16000 {
+		t.Errorf("market/cost/pnl = %v/%v/%v, want 40000/24000/16000", s.MarketValue, s.CostValue, s.PnL)
+	}
+	if !s.PnLPct.Valid || !near(s.PnLPct.Value, 16000.0/24000*100) {
+		t.Errorf("PnLPct = %+v", s.PnLPct)
+	}
+
+	// 按权重降序
+	wantPositions := []struct {
+		code   string
+		weight float64
+		pnlPct model.Metric
+	}{
+		{code: "600519", weight: 75, pnlPct: model.Val(25)},
+		{code: "000858", weight: 25, pnlPct: model.Metric{}},
+	}
+	for i, want := range wantPositions {
+		p := s.Positions[i]
+		if p.Code != want.code || !near(p.Weight, want.weight) || p.PnLPct != want.pnlPct {
+			t.Errorf("positions[%d] = %s %.2f%% %+v, want %s %.2f%% %+v", i, p.Code, p.Weight, p.PnLPct, want.code, want.weight, want.pnlPct)
+		}
+	}
+
+	if len(s.Sectors) != 1 || !near(s.Sectors[0].Weight, 100) {
+		t.Errorf("sectors = %+v, want one sector at 100%%", s.Sectors)
+	}
+	if c := s.Concentration; !near(c.TopPosition, 75) || !near(c.HHI, 6250) || !near(c.EffectiveHoldings, 1.6) {
+		t.Errorf("concentration = %+v", c)
+	}
+
+	// PE 缺失的持仓不计入覆盖率；ROE 按覆盖的权重加权
+	pe, roe := s.Valuation[0], s.Quality[0]
+	if !near(pe.Coverage, 75) || !pe.Value.Valid || !near(pe.Value.Value, 30) {
+		t.Errorf("pe factor = %+v, want 30 at 75%% coverage", pe)
+	}
+	if !near(roe.Coverage, 100) || !roe.Value.Valid || !near(roe.Value.Value, 27.5) {
+		t.Errorf("roe factor = %+v, want 27.5 at 100%% coverage", roe)
+	}
+	if len(s.Warnings) != 2 {
+		t.Errorf("warnings = %v, want position and single-industry warnings", s.Warnings)
+	}
+}
+
+func TestBuildValuation(t *testing.T) {
+	tests := []struct {
+		name           string
+		pes            []*float64
+		wantPE         model.Metric
+		wantCoverage   float64
+		wantLossMaking float64
+	}{
+		{name: "harmonic mean", pes: []*float64{ptr(10), ptr(40)}, wantPE: model.Val(16), wantCoverage: 100},
+		{name: "loss making excluded", pes: []*float64{ptr(-5), ptr(20)}, wantPE: model.Val(20), wantCoverage: 50, wantLossMaking: 50},
+		{name: "all missing", pes: []*float64{nil, nil}, wantPE: model.Metric{}, wantCoverage: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 两只持仓市值相同，各占50%
+			s, err := Build([]Holding{
+				holding("600519", "白酒", model.Val(10), 100, 10, tt.pes[0], nil),
+				holding("300750", "电池", model.Val(10), 100, 10, tt.pes[1], nil),
+			})
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			pe := s.Valuation[0]
+			if pe.Value.Valid != tt.wantPE.Valid || !near(pe.Value.Value, tt.wantPE.Value) || !near(pe.Coverage, tt.wantCoverage) {
+				t.Errorf("pe = %+v, want %+v at %.0f%% coverage", pe, tt.wantPE, tt.wantCoverage)
+			}
+			if !near(s.LossMaking, tt.wantLossMaking) {
+				t.Errorf("LossMaking = %v, want %v", s.LossMaking, tt.wantLossMaking)
+			}
+		})
+	}
+}
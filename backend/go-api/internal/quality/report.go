@@ -0,0 +1,141 @@
+// Package quality 检查分析输入数据的完整性与时效性，生成数据质量报告
+package quality
+
+import (
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/market"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"strings"
+	"time"
+)
+
+// Issue 单个缺失或过期的数据项
+type Issue struct {
+	Field  string `json:"field"`            // 数据键，如 basic_info.pe_ttm
+	Label  string `json:"label"`            // 中文名称
+	Reason string `json:"reason,omitempty"` // 过期原因
+}
+
+// Report 数据质量报告
+type Report struct {
+	Complete bool    `json:"complete"` // 无缺失且无过期
+	Missing  []Issue `json:"missing"`
+	Stale    []Issue `json:"stale"`
+	Message  string  `json:"message"` // 面向用户的提示，数据完整时为空
+}
+
+// Input 待检查的数据。TechnicalDate 为技术指标所用最新K线日期，K线不可用时为空
+type Input struct {
+	Snapshot      *model.PythonAnalysisResponse
+	TechnicalDate string
+	Now           time.Time
+}
+
+// Check 检查缺失的指标，以及行情、K线、财报是否过期：
+// 行情和K线应不早于最近交易日；财报应不早于当前已过法定披露截止日的最新报告期
+func Check(in Input) *Report {
+	r := &Report{Missing: []Issue{}, Stale: []Issue{}}
+	s := in.Snapshot
+
+	for _, m := range []struct {
+		field, label string
+		value        model.Metric
+	}{
+		{"price.latest_price", "最新价", s.Price.LatestPrice},
+		{"price.price_change_pct", "涨跌幅", s.Price.PriceChangePct},
+		{"basic_info.market_cap", "总市值", s.BasicInfo.MarketCap},
+		{"basic_info.pe_ttm", "市盈率(TTM)", s.BasicInfo.PETTM},
+		{"basic_info.pb", "市净率", s.BasicInfo.PB},
+		{"financial_metrics.roe", "ROE", s.FinancialMetrics.ROE},
+		{"financial_metrics.roa", "ROA", s.FinancialMetrics.ROA},
+		{"financial_metrics.gross_margin", "毛利率", s.FinancialMetrics.GrossMargin},
+		{"financial_metrics.net_margin", "净利率", s.FinancialMetrics.NetMargin},
+		{"financial_metrics.debt_ratio", "资产负债率", s.FinancialMetrics.DebtRatio},
+		{"financial_metrics.current_ratio", "流动比率", s.FinancialMetrics.CurrentRatio},
+		{"financial_metrics.revenue_growth", "营收增长", s.FinancialMetrics.RevenueGrowth},
+		{"financial_metrics.profit_growth", "净利润增长", s.FinancialMetrics.ProfitGrowth},
+	} {
+		if !m.value.Valid {
+			r.Missing = append(r.Missing, Issue{Field: m.field, Label: m.label})
+		}
+	}
+	if s.BasicInfo.Industry == "" {
+		r.Missing = append(r.Missing, Issue{Field: "basic_info.industry", Label: "所属行业"})
+	}
+	if len(s.FinancialHistory.Annual) < 2 {
+		r.Missing = append(r.Missing, Issue{Field: "financial_history", Label: "多期财务数据"})
+	}
+
+	tradingDay := market.LastTradingDay(in.Now)
+	if s.Price.Date == "" {
+		r.Missing = append(r.Missing, Issue{Field: "price.date", Label: "行情日期"})
+	} else if s.Price.Date < tradingDay {
+		r.Stale = append(r.Stale, Issue{Field: "price", Label: "行情",
+			Reason: fmt.Sprintf("行情日期 %s 早于最近交易日 %s", s.Price.Date, tradingDay)})
+	}
+
+	if in.TechnicalDate == "" {
+		r.Missing = append(r.Missing, Issue{Field: "technical", Label: "K线与技术指标"})
+	} else if in.TechnicalDate < tradingDay {
+		r.Stale = append(r.Stale, Issue{Field: "technical", Label: "K线",
+			Reason: fmt.Sprintf("最新K线 %s 早于最近交易日 %s", in.TechnicalDate, tradingDay)})
+	}
+
+	expected := ExpectedReportDate(in.Now)
+	if s.FinancialMetrics.ReportDate == "" {
+		r.Missing = append(r.Missing, Issue{Field: "financial_metrics.report_date", Label: "财报报告期"})
+	} else if s.FinancialMetrics.ReportDate < expected {
+		r.Stale = append(r.Stale, Issue{Field: "financial_metrics", Label: "财务指标",
+			Reason: fmt.Sprintf("报告期 %s 早于应已披露的 %s", s.FinancialMetrics.ReportDate, expected)})
+	}
+
+	r.Complete = len(r.Missing) == 0 && len(r.Stale) == 0
+	r.Message = r.message()
+	return r
+}
+
+// ExpectedReportDate 返回 t 时刻应已披露的最新报告期（YYYY-MM-DD）。
+// 法定披露截止日：一季报4月30日、半年报8月31日、三季报10月31日、年报次年4月30日
+func ExpectedReportDate(t time.Time) string {
+	t = t.In(market.Shanghai)
+	year := t.Year()
+	md := t.Format("01-02")
+	switch {
+	case md > "10-31":
+		return fmt.Sprintf("%d-09-30", year)
+	case md > "08-31":
+		return fmt.Sprintf("%d-06-30", year)
+	case md > "04-30":
+		return fmt.Sprintf("%d-03-31", year)
+	default:
+		return fmt.Sprintf("%d-09-30", year-1)
+	}
+}
+
+// message 生成面向用户的提示，如 "以下数据缺失: 市盈率(TTM)、市净率；以下数据可能过期: 行情日期 ... "
+func (r *Report) message() string {
+	var parts []string
+	if len(r.Missing) > 0 {
+		labels := make([]string, len(r.Missing))
+		for i, issue := range r.Missing {
+			labels[i] = issue.Label
+		}
+		parts = append(parts, "以下数据缺失: "+strings.Join(labels, "、"))
+	}
+	if len(r.Stale) > 0 {
+		reasons := make([]string, len(r.Stale))
+		for i, issue := range r.Stale {
+			reasons[i] = issue.Reason
+		}
+		parts = append(parts, "以下数据可能过期: "+strings.Join(reasons, "、"))
+	}
+	return strings.Join(parts, "；")
+}
+
+// Text 以文本形式描述数据质量问题，用于提示词。数据完整时返回空串
+func (r *Report) Text() string {
+	if r.Complete {
+		return ""
+	}
+	return r.Message + "。分析时请勿将缺失数据视为0，并注明相关结论的不确定性。"
+}
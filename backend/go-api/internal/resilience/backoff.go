@@ -0,0 +1,39 @@
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Backoff 指数退避参数
+type Backoff struct {
+	Base time.Duration // 首次重试等待时间
+	Max  time.Duration // 单次等待上限
+}
+
+// Delay 返回第attempt次重试（从0开始）的等待时间，带±50%随机抖动避免惊群
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Base << attempt
+	if b.Max > 0 && (d > b.Max || d <= 0) {
+		d = b.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	jitter := time.Duration(rand.Int63n(int64(d))) - d/2
+	return d + jitter
+}
+
+// Sleep 等待第attempt次重试的退避时间，ctx取消时提前返回错误
+func (b Backoff) Sleep(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(b.Delay(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState 熔断器状态
+type breakerState int
+
+const (
+	stateClosed   breakerState = iota // 正常放行
+	stateOpen                         // 熔断中，快速失败
+	stateHalfOpen                     // 冷却结束，放行一个探测请求
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker 连续失败达到阈值后熔断，冷却期结束后放行单个探测请求，成功则恢复
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Name 熔断器名称
+func (b *CircuitBreaker) Name() string {
+	return b.name
+}
+
+// Allow 判断当前是否允许发起请求
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = stateHalfOpen
+		b.probing = true
+		return true
+	case stateHalfOpen:
+		// 半开状态下同一时间只放行一个探测请求
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success 记录一次成功，熔断器恢复为关闭状态
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = stateClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure 记录一次失败，返回熔断器是否因此打开
+func (b *CircuitBreaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		opened := b.state != stateOpen
+		b.state = stateOpen
+		b.openedAt = time.Now()
+		return opened
+	}
+	return false
+}
+
+// Release 放弃本次请求而不记录结果（如调用方取消），半开状态下归还探测名额并回到打开状态，
+// 冷却期已过，下一次 Allow 会立即重新放行探测请求
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == stateHalfOpen && b.probing {
+		b.state = stateOpen
+		b.probing = false
+	}
+}
+
+// Closed 判断熔断器是否处于关闭状态，不占用半开探测名额
+func (b *CircuitBreaker) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == stateClosed
+}
+
+// Ready 判断下一次 Allow 是否会放行，只查询不占用半开探测名额
+func (b *CircuitBreaker) Ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		return time.Since(b.openedAt) >= b.cooldown
+	case stateHalfOpen:
+		return !b.probing
+	default:
+		return true
+	}
+}
+
+// State 返回当前状态描述，用于日志
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
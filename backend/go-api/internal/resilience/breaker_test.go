@@ -0,0 +1,114 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := NewCircuitBreaker("test", 2, time.Hour)
+
+	if b.Failure() {
+		t.Fatal("breaker opened before reaching threshold")
+	}
+	if !b.Allow() {
+		t.Fatal("closed breaker should allow requests")
+	}
+	if !b.Failure() {
+		t.Fatal("breaker should open at threshold")
+	}
+	if b.Allow() {
+		t.Fatal("open breaker should reject requests during cooldown")
+	}
+	if b.Closed() {
+		t.Fatal("Closed() reported true for open breaker")
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name       string
+		settle     func(b *CircuitBreaker)
+		wantState  string
+		allowAfter bool
+	}{
+		{
+			name:       "success closes",
+			settle:     func(b *CircuitBreaker) { b.Success() },
+			wantState:  "closed",
+			allowAfter: true,
+		},
+		{
+			name:       "failure reopens",
+			settle:     func(b *CircuitBreaker) { b.Failure() },
+			wantState:  "open",
+			allowAfter: true, // cooldown 为 0，立即进入下一次探测
+		},
+		{
+			name:       "cancelled probe is released",
+			settle:     func(b *CircuitBreaker) { b.Release() },
+			wantState:  "open",
+			allowAfter: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker("test", 1, 0)
+			b.Failure()
+
+			if !b.Allow() {
+				t.Fatal("breaker should allow a probe after cooldown")
+			}
+			if b.Allow() {
+				t.Fatal("half-open breaker should allow only one probe")
+			}
+
+			tt.settle(b)
+			if got := b.State(); got != tt.wantState {
+				t.Fatalf("state = %q, want %q", got, tt.wantState)
+			}
+			if got := b.Allow(); got != tt.allowAfter {
+				t.Fatalf("Allow() after settle = %v, want %v", got, tt.allowAfter)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerReleaseWithoutProbe(t *testing.T) {
+	b := NewCircuitBreaker("test", 1, time.Hour)
+	b.Release()
+	if !b.Closed() || !b.Allow() {
+		t.Fatal("Release on closed breaker should be a no-op")
+	}
+
+	b.Failure()
+	b.Release()
+	if b.Allow() {
+		t.Fatal("Release should not bypass cooldown of an open breaker")
+	}
+}
+
+func TestCircuitBreakerReady(t *testing.T) {
+	b := NewCircuitBreaker("test", 1, time.Hour)
+	if !b.Ready() {
+		t.Fatal("closed breaker should be ready")
+	}
+
+	b.Failure()
+	if b.Ready() {
+		t.Fatal("open breaker should not be ready during cooldown")
+	}
+
+	b = NewCircuitBreaker("test", 1, 0)
+	b.Failure()
+	if !b.Ready() || !b.Ready() {
+		t.Fatal("Ready should not take the half-open probe")
+	}
+	if !b.Allow() {
+		t.Fatal("breaker should allow a probe after cooldown")
+	}
+	if b.Ready() {
+		t.Fatal("breaker with a probe in flight should not be ready")
+	}
+}
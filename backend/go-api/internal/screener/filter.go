@@ -0,0 +1,419 @@
+package screener
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidQuery 筛选条件或排序参数无效
+var ErrInvalidQuery = errors.New("选股条件无效")
+
+// maxFilterLength 筛选表达式的最大长度（字符）
+const maxFilterLength = 1000
+
+// Filter 解析后的筛选条件，可并发使用。语法：
+//
+//	条件   := 比较 | 条件 AND 条件 | 条件 OR 条件 | NOT 条件 | ( 条件 )
+//	比较   := 字段 运算符 值 | 字段 IN (值, ...) | 字段 CONTAINS "文本"
+//	运算符 := > >= < <= = != <>
+//
+// 关键字不区分大小写，AND 优先于 OR；数值字段与数字比较，文本字段与带引号的字符串比较。
+// 指标缺失时比较结果为“未知”，与 SQL 的 NULL 相同按三值逻辑传递：NOT 未知仍为未知，
+// 只有结果为真的股票被选中，因此 roe > 15 与 NOT roe > 15 都不会选中缺少 ROE 的股票
+type Filter struct {
+	root node
+}
+
+// Match 判断股票是否满足条件，空条件匹配全部股票
+func (f *Filter) Match(s *Stock) bool {
+	return f.root == nil || f.root.eval(s) == truthTrue
+}
+
+// Parse 解析筛选表达式，空字符串表示不筛选
+func Parse(input string) (*Filter, error) {
+	if len([]rune(input)) > maxFilterLength {
+		return nil, fmt.Errorf("%w: 筛选条件不能超过%d个字符", ErrInvalidQuery, maxFilterLength)
+	}
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return &Filter{}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "多余的 %q", tok.text)
+	}
+	return &Filter{root: root}, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string // 字符串为去掉引号后的内容
+	pos  int    // 从1开始的字符位置
+}
+
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", pos})
+			i++
+		case strings.ContainsRune("<>=!", r):
+			op := string(r)
+			if i+1 < len(runes) && twoCharOps[string(runes[i:i+2])] {
+				op = string(runes[i : i+2])
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("%w: 位置%d: 无效的运算符 !", ErrInvalidQuery, pos)
+			}
+			tokens = append(tokens, token{tokOp, op, pos})
+			i += len([]rune(op))
+		case r == '"' || r == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("%w: 位置%d: 字符串缺少结束引号", ErrInvalidQuery, pos)
+			}
+			tokens = append(tokens, token{tokString, sb.String(), pos})
+			i = j + 1
+		case r == '-' || r == '.' || unicode.IsDigit(r):
+			j := i + 1
+			for j < len(runes) && (runes[j] == '.' || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, string(runes[i:j]), pos})
+			i = j
+		case r == '_' || r < unicode.MaxASCII && unicode.IsLetter(r):
+			j := i + 1
+			for j < len(runes) && (runes[j] == '_' || runes[j] < unicode.MaxASCII && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[i:j]), pos})
+			i = j
+		default:
+			return nil, fmt.Errorf("%w: 位置%d: 无法识别的字符 %q（文本值需加引号）", ErrInvalidQuery, pos, r)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(runes) + 1}), nil
+}
+
+// twoCharOps 双字符比较运算符
+var twoCharOps = map[string]bool{"<=": true, ">=": true, "!=": true, "<>": true, "==": true}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword 当前记号是否为指定关键字（不区分大小写），是则消费
+func (p *parser) keyword(kw string) bool {
+	if tok := p.peek(); tok.kind == tokIdent && strings.EqualFold(tok.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	if tok.kind == tokEOF {
+		return fmt.Errorf("%w: 条件不完整，%s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf("%w: 位置%d: %s", ErrInvalidQuery, tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.keyword("NOT") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{expr}, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokRParen {
+			return nil, p.errorf(tok, "缺少右括号")
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	tok := p.next()
+	if tok.kind != tokIdent {
+		return nil, p.errorf(tok, "应为字段名")
+	}
+	name := strings.ToLower(tok.text)
+	f, ok := fields[name]
+	if !ok {
+		return nil, p.errorf(tok, "未知字段 %s（可用字段: %s）", tok.text, strings.Join(fieldNames(), ", "))
+	}
+
+	switch {
+	case p.keyword("IN"):
+		if open := p.next(); open.kind != tokLParen {
+			return nil, p.errorf(open, "IN 后应为括号列表")
+		}
+		var values []value
+		for {
+			v, err := p.parseValue(name, f)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			sep := p.next()
+			if sep.kind == tokRParen {
+				break
+			}
+			if sep.kind != tokComma {
+				return nil, p.errorf(sep, "IN 列表应以逗号分隔并以右括号结束")
+			}
+		}
+		return inNode{f, values}, nil
+
+	case p.keyword("CONTAINS"):
+		if f.kind != text {
+			return nil, p.errorf(tok, "CONTAINS 只能用于文本字段，%s 是数值字段", name)
+		}
+		v, err := p.parseValue(name, f)
+		if err != nil {
+			return nil, err
+		}
+		return containsNode{f, strings.ToUpper(v.text)}, nil
+	}
+
+	op := p.next()
+	if op.kind != tokOp {
+		return nil, p.errorf(op, "字段 %s 后应为比较运算符", name)
+	}
+	v, err := p.parseValue(name, f)
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{f, normalizeOp(op.text), v}, nil
+}
+
+// parseValue 解析与字段类型一致的字面值
+func (p *parser) parseValue(name string, f field) (value, error) {
+	tok := p.next()
+	switch {
+	case f.kind == numeric && tok.kind == tokNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return value{}, p.errorf(tok, "无效的数字 %s", tok.text)
+		}
+		return value{num: n}, nil
+	case f.kind == numeric:
+		return value{}, p.errorf(tok, "%s 是数值字段，应与数字比较", name)
+	case tok.kind == tokString:
+		return value{text: tok.text}, nil
+	default:
+		return value{}, p.errorf(tok, "%s 是文本字段，值需加引号，如 %s = \"%s\"", name, name, tok.text)
+	}
+}
+
+func normalizeOp(op string) string {
+	switch op {
+	case "==":
+		return "="
+	case "<>":
+		return "!="
+	default:
+		return op
+	}
+}
+
+// truth 三值逻辑的求值结果。按 假<未知<真 排列，AND 取较小值、OR 取较大值
+type truth int8
+
+const (
+	truthFalse truth = iota
+	truthUnknown
+	truthTrue
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+// node 条件表达式的语法树节点
+type node interface {
+	eval(s *Stock) truth
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(s *Stock) truth { return min(n.left.eval(s), n.right.eval(s)) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(s *Stock) truth { return max(n.left.eval(s), n.right.eval(s)) }
+
+type notNode struct{ expr node }
+
+func (n notNode) eval(s *Stock) truth { return truthTrue - n.expr.eval(s) }
+
+// value 字面值，按字段类型使用 num 或 text
+type value struct {
+	num  float64
+	text string
+}
+
+type compareNode struct {
+	field field
+	op    string
+	value value
+}
+
+func (n compareNode) eval(s *Stock) truth {
+	var c int
+	if n.field.kind == numeric {
+		m := n.field.num(s)
+		if !m.Valid {
+			return truthUnknown
+		}
+		switch {
+		case m.Value < n.value.num:
+			c = -1
+		case m.Value > n.value.num:
+			c = 1
+		}
+	} else {
+		t := n.field.text(s)
+		if t == "" {
+			return truthUnknown
+		}
+		c = strings.Compare(strings.ToUpper(t), strings.ToUpper(n.value.text))
+	}
+
+	switch n.op {
+	case "=":
+		return truthOf(c == 0)
+	case "!=":
+		return truthOf(c != 0)
+	case ">":
+		return truthOf(c > 0)
+	case ">=":
+		return truthOf(c >= 0)
+	case "<":
+		return truthOf(c < 0)
+	default: // "<="
+		return truthOf(c <= 0)
+	}
+}
+
+type inNode struct {
+	field  field
+	values []value
+}
+
+func (n inNode) eval(s *Stock) truth {
+	result := truthFalse
+	for _, v := range n.values {
+		result = max(result, compareNode{n.field, "=", v}.eval(s))
+	}
+	return result
+}
+
+type containsNode struct {
+	field field
+	text  string // 大写
+}
+
+func (n containsNode) eval(s *Stock) truth {
+	t := n.field.text(s)
+	if t == "" {
+		return truthUnknown
+	}
+	return truthOf(strings.Contains(strings.ToUpper(t), n.text))
+}
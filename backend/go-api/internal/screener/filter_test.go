@@ -0,0 +1,126 @@
+package screener
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"stock-analysis-api/backend/go-api/internal/model"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		filter  string
+		wantMsg string
+	}{
+		{filter: "roe >", wantMsg: "条件不完整"},
+		{filter: "foo > 1", wantMsg: "未知字段"},
+		{filter: "roe > \"abc\"", wantMsg: "应与数字比较"},
+		{filter: "industry = 白酒", wantMsg: "无法识别的字符"},
+		{filter: "industry = bank", wantMsg: "值需加引号"},
+		{filter: "roe CONTAINS \"1\"", wantMsg: "只能用于文本字段"},
+		{filter: "(roe > 1", wantMsg: "缺少右括号"},
+		{filter: "roe > 1 roe", wantMsg: "多余的"},
+		{filter: "roe ! 1", wantMsg: "无效的运算符"},
+		{filter: "name = \"abc", wantMsg: "缺少结束引号"},
+		{filter: "code IN (\"600519\" \"000858\")", wantMsg: "逗号分隔"},
+		{filter: "roe > 1.2.3", wantMsg: "无效的数字"},
+		{filter: strings.Repeat("x", maxFilterLength+1), wantMsg: "不能超过"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter[:min(len(tt.filter), 30)], func(t *testing.T) {
+			_, err := Parse(tt.filter)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Fatalf("Parse(%q) err = %v, want ErrInvalidQuery", tt.filter, err)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("Parse(%q) err = %q, want containing %q", tt.filter, err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	moutai := &Stock{
+		Code:      "600519",
+		Name:      "贵州茅台",
+		Exchange:  "SH",
+		Industry:  "白酒",
+		MarketCap: model.Val(19200),
+		PETTM:     model.Val(22.35),
+		ROE:       model.Val(17.51),
+		DebtRatio: model.Val(12.81),
+	}
+	noROE := &Stock{
+		Code:      "000001",
+		Name:      "平安银行",
+		Exchange:  "SZ",
+		MarketCap: model.Val(2100),
+		PETTM:     model.Val(4.5),
+	}
+
+	tests := []struct {
+		filter    string
+		wantFirst bool // moutai
+		wantOther bool // noROE
+	}{
+		{filter: "", wantFirst: true, wantOther: true},
+		{filter: "roe > 15", wantFirst: true},
+		{filter: "ROE >= 17.51 and pe_ttm < 30", wantFirst: true},
+		{filter: "roe < 15", wantFirst: false},
+		{filter: "roe != 15", wantFirst: true},
+		{filter: "NOT roe > 15", wantFirst: false, wantOther: false},
+		{filter: "NOT NOT roe > 15", wantFirst: true, wantOther: false},
+		{filter: "roe > 15 OR pe_ttm < 10", wantFirst: true, wantOther: true},
+		{filter: "NOT (roe > 15 OR pe_ttm < 10)", wantFirst: false, wantOther: false},
+		{filter: "roe > 15 AND pe_ttm < 10", wantFirst: false, wantOther: false},
+		{filter: "NOT (roe > 15 AND pe_ttm < 10)", wantFirst: true, wantOther: false},
+		{filter: "NOT (roe > 100 AND pe_ttm > 10)", wantFirst: true, wantOther: true},
+		{filter: "pe_ttm < 10 OR roe > 15 AND market_cap > 50000", wantOther: true},
+		{filter: "(pe_ttm < 10 OR roe > 15) AND market_cap > 5000", wantFirst: true},
+		{filter: "exchange = \"sz\"", wantOther: true},
+		{filter: "exchange <> \"SZ\"", wantFirst: true},
+		{filter: "code IN (\"600519\", \"000001\")", wantFirst: true, wantOther: true},
+		{filter: "NOT roe IN (1, 2)", wantFirst: true},
+		{filter: "name CONTAINS \"茅台\"", wantFirst: true},
+		{filter: "NOT industry CONTAINS \"酒\"", wantFirst: false, wantOther: false},
+		{filter: "debt_ratio <= 12.81 AND debt_ratio >= 12.81", wantFirst: true},
+		{filter: "market_cap = -1", wantFirst: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := Parse(tt.filter)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.filter, err)
+			}
+			if got := f.Match(moutai); got != tt.wantFirst {
+				t.Errorf("Match(600519) = %v, want %v", got, tt.wantFirst)
+			}
+			if got := f.Match(noROE); got != tt.wantOther {
+				t.Errorf("Match(000001) = %v, want %v", got, tt.wantOther)
+			}
+		})
+	}
+}
+
+func TestPageOffset(t *testing.T) {
+	tests := []struct {
+		page, pageSize, total int
+		want                  int
+	}{
+		{page: 1, pageSize: 20, total: 50, want: 0},
+		{page: 3, pageSize: 20, total: 50, want: 40},
+		{page: 4, pageSize: 20, total: 50, want: 50},
+		{page: 1, pageSize: 20, total: 0, want: 0},
+		{page: 9223372036854775807, pageSize: 100, total: 50, want: 50},
+		{page: 0, pageSize: 20, total: 50, want: 50},
+	}
+
+	for _, tt := range tests {
+		if got := pageOffset(tt.page, tt.pageSize, tt.total); got != tt.want {
+			t.Errorf("pageOffset(%d, %d, %d) = %d, want %d", tt.page, tt.pageSize, tt.total, got, tt.want)
+		}
+	}
+}
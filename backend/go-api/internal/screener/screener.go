@@ -0,0 +1,328 @@
+// Package screener 条件选股：在定期刷新并落库的全市场快照上按筛选表达式求值，支持排序与分页
+package screener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/securities"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotReady 全市场数据尚未加载（首次启动且数据源未返回数据）
+var ErrNotReady = errors.New("全市场数据尚未加载")
+
+// Stock 选股结果中的一只股票，字段名即筛选表达式与排序中使用的字段名
+type Stock struct {
+	Code           string       `json:"code"`
+	Name           string       `json:"name"`
+	Exchange       string       `json:"exchange"`
+	Board          string       `json:"board"`
+	Industry       string       `json:"industry"`
+	LatestPrice    model.Metric `json:"latest_price"`
+	PriceChangePct model.Metric `json:"price_change_pct"`
+	MarketCap      model.Metric `json:"market_cap"` // 总市值（亿元）
+	PETTM          model.Metric `json:"pe_ttm"`
+	PB             model.Metric `json:"pb"`
+	ReportDate     string       `json:"report_date"` // 财务指标报告期
+	ROE            model.Metric `json:"roe"`
+	ROA            model.Metric `json:"roa"`
+	GrossMargin    model.Metric `json:"gross_margin"`
+	NetMargin      model.Metric `json:"net_margin"`
+	DebtRatio      model.Metric `json:"debt_ratio"`
+	CurrentRatio   model.Metric `json:"current_ratio"`
+	RevenueGrowth  model.Metric `json:"revenue_growth"`
+	ProfitGrowth   model.Metric `json:"profit_growth"`
+}
+
+type fieldKind int
+
+const (
+	numeric fieldKind = iota
+	text
+)
+
+// field 可用于筛选和排序的字段
+type field struct {
+	kind fieldKind
+	num  func(*Stock) model.Metric
+	text func(*Stock) string
+}
+
+func num(get func(*Stock) model.Metric) field { return field{kind: numeric, num: get} }
+func str(get func(*Stock) string) field       { return field{kind: text, text: get} }
+
+var fields = map[string]field{
+	"code":             str(func(s *Stock) string { return s.Code }),
+	"name":             str(func(s *Stock) string { return s.Name }),
+	"exchange":         str(func(s *Stock) string { return s.Exchange }),
+	"board":            str(func(s *Stock) string { return s.Board }),
+	"industry":         str(func(s *Stock) string { return s.Industry }),
+	"report_date":      str(func(s *Stock) string { return s.ReportDate }),
+	"latest_price":     num(func(s *Stock) model.Metric { return s.LatestPrice }),
+	"price_change_pct": num(func(s *Stock) model.Metric { return s.PriceChangePct }),
+	"market_cap":       num(func(s *Stock) model.Metric { return s.MarketCap }),
+	"pe_ttm":           num(func(s *Stock) model.Metric { return s.PETTM }),
+	"pb":               num(func(s *Stock) model.Metric { return s.PB }),
+	"roe":              num(func(s *Stock) model.Metric { return s.ROE }),
+	"roa":              num(func(s *Stock) model.Metric { return s.ROA }),
+	"gross_margin":     num(func(s *Stock) model.Metric { return s.GrossMargin }),
+	"net_margin":       num(func(s *Stock) model.Metric { return s.NetMargin }),
+	"debt_ratio":       num(func(s *Stock) model.Metric { return s.DebtRatio }),
+	"current_ratio":    num(func(s *Stock) model.Metric { return s.CurrentRatio }),
+	"revenue_growth":   num(func(s *Stock) model.Metric { return s.RevenueGrowth }),
+	"profit_growth":    num(func(s *Stock) model.Metric { return s.ProfitGrowth }),
+}
+
+func fieldNames() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Query 选股请求
+type Query struct {
+	Filter   *Filter
+	Sort     string // 排序字段，为空时按总市值
+	Asc      bool   // 升序，默认降序
+	Page     int    // 从1开始
+	PageSize int
+	Top      int // 额外返回排序后的前 Top 只股票（不受分页影响）
+}
+
+// Result 选股结果
+type Result struct {
+	Total     int       `json:"total"` // 满足条件的股票数
+	Page      int       `json:"page"`
+	PageSize  int       `json:"page_size"`
+	Stocks    []*Stock  `json:"stocks"`
+	Universe  int       `json:"universe"` // 全市场股票数
+	UpdatedAt time.Time `json:"updated_at"`
+	Top       []*Stock  `json:"-"`
+}
+
+// Screener 条件选股。全市场数据启动时从数据库加载，后台定期从数据源刷新并落库
+type Screener struct {
+	provider datasource.MarketDataProvider
+	store    *store.UniverseStore
+	master   *securities.Master
+	interval time.Duration
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	stocks    []*Stock
+	updatedAt time.Time
+}
+
+// NewScreener 创建选股器并加载数据库中的缓存。interval 为0时只在缓存为空时拉取一次
+func NewScreener(provider datasource.MarketDataProvider, universeStore *store.UniverseStore, master *securities.Master, interval, timeout time.Duration) *Screener {
+	s := &Screener{provider: provider, store: universeStore, master: master, interval: interval, timeout: timeout}
+	raw, updatedAt, err := universeStore.List()
+	if err != nil {
+		log.Printf("加载本地全市场数据失败: %v", err)
+		return s
+	}
+	s.set(raw, updatedAt)
+	if len(raw) > 0 {
+		log.Printf("已加载本地全市场数据: %d 只, 更新于 %s", len(raw), updatedAt.Local().Format(time.DateTime))
+	}
+	return s
+}
+
+// Start 启动后台刷新：缓存为空或已超过刷新间隔时立即刷新，之后按间隔定期刷新
+func (s *Screener) Start(ctx context.Context) {
+	s.mu.RLock()
+	empty, updatedAt := len(s.stocks) == 0, s.updatedAt
+	s.mu.RUnlock()
+	if s.interval <= 0 && !empty {
+		return
+	}
+
+	go func() {
+		due := time.Until(updatedAt.Add(s.interval))
+		if empty || due < 0 {
+			due = 0
+		}
+		timer := time.NewTimer(due)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			if err := s.Refresh(ctx); err != nil {
+				log.Printf("刷新全市场数据失败: %v", err)
+			}
+			if s.interval <= 0 {
+				return
+			}
+			timer.Reset(s.interval)
+		}
+	}()
+}
+
+// Refresh 从数据源拉取全市场数据，落库并替换内存数据
+func (s *Screener) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	raw, err := s.provider.Universe(ctx)
+	if err != nil {
+		return fmt.Errorf("获取全市场数据失败: %w", err)
+	}
+	if len(raw) == 0 {
+		return fmt.Errorf("数据源返回的全市场数据为空")
+	}
+
+	now := time.Now()
+	if err := s.store.ReplaceAll(raw, now); err != nil {
+		return err
+	}
+	s.set(raw, now)
+	log.Printf("全市场数据已刷新: %d 只", len(raw))
+	return nil
+}
+
+// set 转换为选股字段并替换内存数据。无效代码（如B股）被忽略，行业缺失时用证券主数据补齐
+func (s *Screener) set(raw []model.PythonAnalysisResponse, updatedAt time.Time) {
+	stocks := make([]*Stock, 0, len(raw))
+	for i := range raw {
+		r := &raw[i]
+		sym, err := symbol.FromCode(r.Code)
+		if err != nil {
+			continue
+		}
+		stock := &Stock{
+			Code:           sym.Code,
+			Name:           r.Name,
+			Exchange:       string(sym.Exchange),
+			Board:          string(sym.Board),
+			Industry:       r.BasicInfo.Industry,
+			LatestPrice:    r.Price.LatestPrice,
+			PriceChangePct: r.Price.PriceChangePct,
+			MarketCap:      r.BasicInfo.MarketCap.HundredMillion(),
+			PETTM:          r.BasicInfo.PETTM,
+			PB:             r.BasicInfo.PB,
+			ReportDate:     r.FinancialMetrics.ReportDate,
+			ROE:            r.FinancialMetrics.ROE,
+			ROA:            r.FinancialMetrics.ROA,
+			GrossMargin:    r.FinancialMetrics.GrossMargin,
+			NetMargin:      r.FinancialMetrics.NetMargin,
+			DebtRatio:      r.FinancialMetrics.DebtRatio,
+			CurrentRatio:   r.FinancialMetrics.CurrentRatio,
+			RevenueGrowth:  r.FinancialMetrics.RevenueGrowth,
+			ProfitGrowth:   r.FinancialMetrics.ProfitGrowth,
+		}
+		if sec, ok := s.master.Lookup(sym.Code); ok {
+			if stock.Industry == "" {
+				stock.Industry = sec.Industry
+			}
+			if stock.Name == "" {
+				stock.Name = sec.Name
+			}
+		}
+		stocks = append(stocks, stock)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stocks = stocks
+	s.updatedAt = updatedAt
+}
+
+// Screen 按条件筛选、排序并分页。排序字段缺失值的股票排在最后，同值时按代码升序
+func (s *Screener) Screen(q Query) (*Result, error) {
+	sortKey := strings.ToLower(strings.TrimSpace(q.Sort))
+	if sortKey == "" {
+		sortKey = "market_cap"
+	}
+	f, ok := fields[sortKey]
+	if !ok {
+		return nil, fmt.Errorf("%w: 未知排序字段 %s（可用字段: %s）", ErrInvalidQuery, q.Sort, strings.Join(fieldNames(), ", "))
+	}
+
+	s.mu.RLock()
+	all, updatedAt := s.stocks, s.updatedAt
+	s.mu.RUnlock()
+	if len(all) == 0 {
+		return nil, ErrNotReady
+	}
+
+	matched := make([]*Stock, 0)
+	for _, stock := range all {
+		if q.Filter == nil || q.Filter.Match(stock) {
+			matched = append(matched, stock)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if c := compare(f, matched[i], matched[j], q.Asc); c != 0 {
+			return c < 0
+		}
+		return matched[i].Code < matched[j].Code
+	})
+
+	result := &Result{Total: len(matched), Page: q.Page, PageSize: q.PageSize, Universe: len(all), UpdatedAt: updatedAt}
+	start := pageOffset(q.Page, q.PageSize, len(matched))
+	end := start + min(q.PageSize, len(matched)-start)
+	result.Stocks = matched[start:end]
+	result.Top = matched[:min(q.Top, len(matched))]
+	return result, nil
+}
+
+// pageOffset 返回第 page 页的起始下标，超出范围时返回 total；先比较页数再相乘，避免超大页码溢出
+func pageOffset(page, pageSize, total int) int {
+	if page < 1 || pageSize < 1 || page-1 >= (total+pageSize-1)/pageSize {
+		return total
+	}
+	return (page - 1) * pageSize
+}
+
+// compare 按字段比较两只股票，缺失值（空文本）总是排在后面
+func compare(f field, a, b *Stock, asc bool) int {
+	var c int
+	if f.kind == numeric {
+		x, y := f.num(a), f.num(b)
+		switch {
+		case !x.Valid || !y.Valid:
+			return boolCompare(x.Valid, y.Valid)
+		case x.Value < y.Value:
+			c = -1
+		case x.Value > y.Value:
+			c = 1
+		}
+	} else {
+		x, y := f.text(a), f.text(b)
+		if x == "" || y == "" {
+			return boolCompare(x != "", y != "")
+		}
+		c = strings.Compare(x, y)
+	}
+	if !asc {
+		c = -c
+	}
+	return c
+}
+
+// boolCompare 有值者在前
+func boolCompare(x, y bool) int {
+	switch {
+	case x == y:
+		return 0
+	case x:
+		return -1
+	default:
+		return 1
+	}
+}
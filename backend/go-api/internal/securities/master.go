@@ -0,0 +1,251 @@
+// Package securities 维护本地缓存的证券主数据（代码、名称、拼音首字母、交易所、行业），提供模糊与拼音搜索
+package securities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNotReady 证券主数据尚未加载（首次启动且数据源未返回列表）
+var ErrNotReady = errors.New("证券列表尚未加载")
+
+// Master 证券主数据。启动时从数据库加载，后台定期从数据源刷新并落库
+type Master struct {
+	provider datasource.MarketDataProvider
+	store    *store.SecurityStore
+	interval time.Duration
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	entries   []entry
+	byName    map[string]model.Security
+	byCode    map[string]model.Security
+	updatedAt time.Time
+}
+
+// entry 预先计算好的大写形式，避免每次搜索重复转换
+type entry struct {
+	model.Security
+	pinyin string
+}
+
+// Result 单条搜索结果
+type Result struct {
+	model.Security
+	Score int    `json:"score"`
+	Match string `json:"match"` // 命中方式，如 code_exact、name_prefix、pinyin_fuzzy
+}
+
+// NewMaster 创建证券主数据并加载数据库中的缓存
+func NewMaster(provider datasource.MarketDataProvider, securityStore *store.SecurityStore, interval, timeout time.Duration) *Master {
+	m := &Master{provider: provider, store: securityStore, interval: interval, timeout: timeout}
+	securities, updatedAt, err := securityStore.List()
+	if err != nil {
+		log.Printf("加载本地证券列表失败: %v", err)
+		return m
+	}
+	m.set(securities, updatedAt)
+	if len(securities) > 0 {
+		log.Printf("已加载本地证券列表: %d 只, 更新于 %s", len(securities), updatedAt.Local().Format(time.DateTime))
+	}
+	return m
+}
+
+// Start 启动后台刷新：缓存为空或已超过刷新间隔时立即刷新，之后按间隔定期刷新
+func (m *Master) Start(ctx context.Context) {
+	go func() {
+		m.mu.RLock()
+		due := time.Until(m.updatedAt.Add(m.interval))
+		m.mu.RUnlock()
+		if due < 0 {
+			due = 0
+		}
+
+		timer := time.NewTimer(due)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+			if err := m.Refresh(ctx); err != nil {
+				log.Printf("刷新证券列表失败: %v", err)
+			}
+			timer.Reset(m.interval)
+		}
+	}()
+}
+
+// Refresh 从数据源拉取证券列表，补全交易所与板块后落库并替换内存数据。无效代码（如B股）被忽略
+func (m *Master) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	raw, err := m.provider.Securities(ctx)
+	if err != nil {
+		return fmt.Errorf("获取证券列表失败: %w", err)
+	}
+
+	securities := make([]model.Security, 0, len(raw))
+	for _, sec := range raw {
+		sym, err := symbol.FromCode(sec.Code)
+		if err != nil || sec.Name == "" {
+			continue
+		}
+		sec.Exchange, sec.Board = string(sym.Exchange), string(sym.Board)
+		sec.Pinyin = strings.ToUpper(sec.Pinyin)
+		securities = append(securities, sec)
+	}
+	if len(securities) == 0 {
+		return fmt.Errorf("数据源返回的证券列表为空")
+	}
+
+	now := time.Now()
+	if err := m.store.ReplaceAll(securities, now); err != nil {
+		return err
+	}
+	m.set(securities, now)
+	log.Printf("证券列表已刷新: %d 只", len(securities))
+	return nil
+}
+
+func (m *Master) set(securities []model.Security, updatedAt time.Time) {
+	entries := make([]entry, len(securities))
+	byName := make(map[string]model.Security, len(securities))
+	byCode := make(map[string]model.Security, len(securities))
+	for i, sec := range securities {
+		entries[i] = entry{Security: sec, pinyin: strings.ToUpper(sec.Pinyin)}
+		byName[sec.Name] = sec
+		byCode[sec.Code] = sec
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = entries
+	m.byName = byName
+	m.byCode = byCode
+	m.updatedAt = updatedAt
+}
+
+// UpdatedAt 最近一次刷新时间，未加载时为零值
+func (m *Master) UpdatedAt() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.updatedAt
+}
+
+// Resolve 按完整名称查找证券，用于把名称输入解析为代码
+func (m *Master) Resolve(name string) (model.Security, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sec, ok := m.byName[strings.TrimSpace(name)]
+	return sec, ok
+}
+
+// Lookup 按6位代码查找证券
+func (m *Master) Lookup(code string) (model.Security, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sec, ok := m.byCode[code]
+	return sec, ok
+}
+
+// Search 按代码、名称或拼音首字母搜索，返回得分最高的 limit 条。
+// 排序：完全匹配 > 前缀匹配 > 包含 > 按顺序模糊匹配；同分时名称较短、代码较小者在前
+func (m *Master) Search(query string, limit int) ([]Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.entries) == 0 {
+		return nil, ErrNotReady
+	}
+
+	q := normalize(query)
+	if q == "" {
+		return []Result{}, nil
+	}
+
+	results := make([]Result, 0, limit)
+	for _, e := range m.entries {
+		if score, match := score(e, q); score > 0 {
+			results = append(results, Result{Security: e.Security, Score: score, Match: match})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if la, lb := utf8.RuneCountInString(a.Name), utf8.RuneCountInString(b.Name); la != lb {
+			return la < lb
+		}
+		return a.Code < b.Code
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// normalize 规范化查询：600519.SH、sh600519 等代码格式转为6位代码，其余去空格并转大写
+func normalize(query string) string {
+	q := strings.TrimSpace(query)
+	if sym, err := symbol.Parse(q); err == nil && !sym.IsName() {
+		return sym.Code
+	}
+	return strings.ToUpper(strings.Join(strings.Fields(q), ""))
+}
+
+// score 计算单只证券与查询的匹配得分，不匹配时返回0
+func score(e entry, q string) (int, string) {
+	name := strings.ToUpper(e.Name)
+	switch {
+	case e.Code == q:
+		return 100, "code_exact"
+	case name == q:
+		return 95, "name_exact"
+	case e.pinyin != "" && e.pinyin == q:
+		return 90, "pinyin_exact"
+	case strings.HasPrefix(name, q):
+		return 85, "name_prefix"
+	case strings.HasPrefix(e.Code, q):
+		return 80, "code_prefix"
+	case e.pinyin != "" && strings.HasPrefix(e.pinyin, q):
+		return 75, "pinyin_prefix"
+	case strings.Contains(name, q):
+		return 60, "name_contains"
+	case strings.Contains(e.pinyin, q):
+		return 50, "pinyin_contains"
+	case subsequence(name, q):
+		return 40, "name_fuzzy"
+	case subsequence(e.pinyin, q):
+		return 30, "pinyin_fuzzy"
+	default:
+		return 0, ""
+	}
+}
+
+// subsequence 判断 q 的字符是否按顺序出现在 s 中，如“茅台”匹配“贵州茅台”、“GZT”匹配“GZMT”
+func subsequence(s, q string) bool {
+	rest := []rune(q)
+	for _, r := range s {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
@@ -0,0 +1,79 @@
+package securities
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"stock-analysis-api/backend/go-api/internal/model"
+)
+
+func newTestMaster() *Master {
+	m := &Master{}
+	m.set([]model.Security{
+		{Code: "600519", Name: "贵州茅台", Pinyin: "GZMT"},
+		{Code: "000858", Name: "五粮液", Pinyin: "WLY"},
+		{Code: "600809", Name: "山西汾酒", Pinyin: "SXFJ"},
+		{Code: "000568", Name: "泸州老窖", Pinyin: "LZLJ"},
+		{Code: "600600", Name: "青岛啤酒", Pinyin: "QDPJ"},
+		{Code: "300750", Name: "宁德时代", Pinyin: "NDSD"},
+		{Code: "002594", Name: "比亚迪", Pinyin: "BYD"},
+		{Code: "000001", Name: "平安银行", Pinyin: "PAYH"},
+		{Code: "601318", Name: "中国平安", Pinyin: "ZGPA"},
+	}, time.Now())
+	return m
+}
+
+func TestSearchRanking(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		limit     int
+		wantCodes []string
+		wantMatch string // 首条结果的命中方式
+	}{
+		{name: "code exact", query: "600519", limit: 10, wantCodes: []string{"600519"}, wantMatch: "code_exact"},
+		{name: "prefixed code", query: "sh600519", limit: 10, wantCodes: []string{"600519"}, wantMatch: "code_exact"},
+		{name: "suffixed code", query: "600519.SH", limit: 10, wantCodes: []string{"600519"}, wantMatch: "code_exact"},
+		{name: "name exact", query: "五粮液", limit: 10, wantCodes: []string{"000858"}, wantMatch: "name_exact"},
+		{name: "pinyin exact ignores case", query: "gzmt", limit: 10, wantCodes: []string{"600519"}, wantMatch: "pinyin_exact"},
+		{name: "name prefix before contains", query: "平安", limit: 10, wantCodes: []string{"000001", "601318"}, wantMatch: "name_prefix"},
+		{name: "code prefix ties by code", query: "600", limit: 10, wantCodes: []string{"600519", "600600", "600809"}, wantMatch: "code_prefix"},
+		{name: "limit", query: "600", limit: 2, wantCodes: []string{"600519", "600600"}, wantMatch: "code_prefix"},
+		{name: "pinyin prefix before contains", query: "L", limit: 10, wantCodes: []string{"000568", "000858"}, wantMatch: "pinyin_prefix"},
+		{name: "shorter name first", query: "D", limit: 10, wantCodes: []string{"002594", "300750", "600600"}, wantMatch: "pinyin_contains"},
+		{name: "name contains", query: "茅台", limit: 10, wantCodes: []string{"600519"}, wantMatch: "name_contains"},
+		{name: "name fuzzy", query: "贵茅", limit: 10, wantCodes: []string{"600519"}, wantMatch: "name_fuzzy"},
+		{name: "pinyin fuzzy", query: "GZT", limit: 10, wantCodes: []string{"600519"}, wantMatch: "pinyin_fuzzy"},
+		{name: "spaces ignored", query: " 贵州 茅台 ", limit: 10, wantCodes: []string{"600519"}, wantMatch: "name_exact"},
+		{name: "no match", query: "XYZ", limit: 10, wantCodes: []string{}},
+		{name: "blank query", query: "  ", limit: 10, wantCodes: []string{}},
+	}
+
+	m := newTestMaster()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := m.Search(tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			codes := make([]string, len(results))
+			for i, r := range results {
+				codes[i] = r.Code
+			}
+			if !slices.Equal(codes, tt.wantCodes) {
+				t.Fatalf("codes = %v, want %v", codes, tt.wantCodes)
+			}
+			if tt.wantMatch != "" && results[0].Match != tt.wantMatch {
+				t.Errorf("match = %q, want %q", results[0].Match, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestSearchNotReady(t *testing.T) {
+	if _, err := (&Master{}).Search("600519", 10); !errors.Is(err, ErrNotReady) {
+		t.Fatalf("err = %v, want ErrNotReady", err)
+	}
+}
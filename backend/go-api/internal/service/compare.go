@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"stock-analysis-api/backend/go-api/internal/comparison"
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"stock-analysis-api/backend/go-api/internal/pipeline"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// comparePlan 横向对比的固定流水线
+var comparePlan = func() *pipeline.Plan {
+	plan, err := pipeline.Comparison().Compile()
+	if err != nil {
+		panic(err)
+	}
+	return plan
+}()
+
+// Compare 对多只股票进行横向对比：并发获取数据，生成对比表，执行对比流水线并给出排名结论。
+// 事件格式与 Analyze 一致，另有 comparison（对比表）和 verdict（结构化排名）事件
+func (ao *AnalysisOrchestrator) Compare(ctx context.Context, syms []symbol.Symbol, eventChan chan<- SSEEvent) error {
+	defer close(eventChan)
+	startTime := time.Now()
+	labels := make([]string, len(syms))
+	for i, sym := range syms {
+		labels[i] = sym.String()
+	}
+	label := strings.Join(labels, ",")
+
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "progress",
+		Data: map[string]interface{}{
+			"step":     "fetching_data",
+			"message":  fmt.Sprintf("正在获取 %d 只股票的数据...", len(syms)),
+			"progress": 10,
+		},
+	}); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	peers, err := ao.fetchPeers(ctx, syms)
+	if err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	table := comparison.Build(peers)
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "comparison",
+		Data:  table,
+	}); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	codes := make([]string, len(peers))
+	names := make([]string, len(peers))
+	candidates := make(map[string]string, len(peers))
+	var trends strings.Builder
+	for i, p := range peers {
+		codes[i], names[i] = p.Snapshot.Code, p.Snapshot.Name
+		candidates[p.Snapshot.Code] = p.Snapshot.Name
+		if p.Trends != nil {
+			fmt.Fprintf(&trends, "%s(%s):\n%s", p.Snapshot.Name, p.Snapshot.Code, p.Trends.Text())
+		}
+	}
+	code, name := strings.Join(codes, ","), strings.Join(names, " vs ")
+	llmData := map[string]interface{}{
+		"code":             code,
+		"name":             name,
+		"comparison_table": table.Text(),
+		"peer_trends":      trends.String(),
+	}
+
+	usage := &usageCollector{}
+	status := "failed"
+	defer func() {
+		if ctx.Err() != nil {
+			status = "cancelled"
+		}
+		usageReport := usage.report()
+		log.Printf("对比用量: %s, tokens=%d, 估算费用=%.4f元", code, usageReport.Total.TotalTokens, usageReport.Total.Cost)
+		if err := ao.usageLedger.Record(code, name, status, usageReport); err != nil {
+			log.Printf("记录用量失败: %v", err)
+		}
+	}()
+
+	result, err := ao.runPipeline(ctx, comparePlan, llmData, usage, eventChan)
+	if err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	// 从对比结论中抽取结构化排名，抽取失败时前端仍可展示文本结论
+	if verdict, err := llm.ParseVerdictText(result.Outputs[string(llm.StepCompareVerdict)], candidates); err != nil {
+		log.Printf("对比结论抽取失败: %s, %v", code, err)
+	} else if err := emit(ctx, eventChan, SSEEvent{
+		Event: "verdict",
+		Data: map[string]interface{}{
+			"step":    string(llm.StepCompareVerdict),
+			"role":    "对比结论",
+			"source":  "text",
+			"verdict": verdict,
+		},
+	}); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "usage",
+		Data:  usage.report(),
+	}); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+	status = "completed"
+
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "done",
+		Data:  map[string]interface{}{"message": "对比完成"},
+	}); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	log.Printf("对比结束: %s, outcome=completed, 耗时: %v", label, time.Since(startTime))
+	return nil
+}
+
+// fetchPeers 并发获取各股票数据并计算财务趋势，任一股票失败时整体失败
+func (ao *AnalysisOrchestrator) fetchPeers(ctx context.Context, syms []symbol.Symbol) ([]comparison.Peer, error) {
+	peers := make([]comparison.Peer, len(syms))
+	g, gctx := errgroup.WithContext(ctx)
+	for i, sym := range syms {
+		g.Go(func() error {
+			snapshot, err := datasource.Snapshot(gctx, ao.dataProvider, sym.Query())
+			if err != nil {
+				return fmt.Errorf("获取 %s 数据失败: %w", sym.Query(), err)
+			}
+			peers[i] = comparison.Peer{
+				Symbol:   resolveSymbol(sym, snapshot),
+				Snapshot: snapshot,
+				Trends:   financialTrends(snapshot),
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// 按名称输入的股票解析后可能与其他输入重复
+	seen := make(map[string]bool, len(peers))
+	for _, p := range peers {
+		if seen[p.Snapshot.Code] {
+			return nil, fmt.Errorf("对比的股票重复: %s(%s)", p.Snapshot.Name, p.Snapshot.Code)
+		}
+		seen[p.Snapshot.Code] = true
+	}
+	return peers, nil
+}
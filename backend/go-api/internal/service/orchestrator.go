@@ -2,121 +2,379 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"stock-analysis-api/backend/go-api/internal/client"
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/financials"
+	"stock-analysis-api/backend/go-api/internal/indicators"
 	"stock-analysis-api/backend/go-api/internal/llm"
+	"stock-analysis-api/backend/go-api/internal/market"
 	"stock-analysis-api/backend/go-api/internal/model"
+	"stock-analysis-api/backend/go-api/internal/pipeline"
+	"stock-analysis-api/backend/go-api/internal/quality"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"sync"
+	"time"
 )
 
 // SSEEvent SSE事件
 type SSEEvent struct {
+	ID    int64 // 运行内单调递增的事件ID，由RunStore分配
 	Event string
 	Data  interface{}
 }
 
+// AnalyzeOptions 单次分析的选项
+type AnalyzeOptions struct {
+	ForceRefresh bool // 忽略结果缓存，重新调用LLM分析
+}
+
 // AnalysisOrchestrator 分析编排器
 type AnalysisOrchestrator struct {
-	pythonClient *client.PythonClient
+	dataProvider datasource.MarketDataProvider
 	llmClient    llm.LLMClient
+	plan         *pipeline.Plan
+	usageLedger  *UsageLedger
+	reports      *store.ReportStore
+	cache        *ResultCache
 }
 
-func NewAnalysisOrchestrator(pythonClient *client.PythonClient, llmClient llm.LLMClient) *AnalysisOrchestrator {
+func NewAnalysisOrchestrator(dataProvider datasource.MarketDataProvider, llmClient llm.LLMClient, plan *pipeline.Plan, usageLedger *UsageLedger, reports *store.ReportStore, cache *ResultCache) *AnalysisOrchestrator {
 	return &AnalysisOrchestrator{
-		pythonClient: pythonClient,
+		dataProvider: dataProvider,
 		llmClient:    llmClient,
+		plan:         plan,
+		usageLedger:  usageLedger,
+		reports:      reports,
+		cache:        cache,
 	}
 }
 
-// Analyze 执行完整分析流程
-func (ao *AnalysisOrchestrator) Analyze(ctx context.Context, code string, eventChan chan<- SSEEvent) error {
+// Analyze 执行完整分析流程。ctx 取消（如客户端断开）时立即停止所有LLM调用并返回 ctx.Err()
+func (ao *AnalysisOrchestrator) Analyze(ctx context.Context, sym symbol.Symbol, opts AnalyzeOptions, eventChan chan<- SSEEvent) error {
 	defer close(eventChan)
+	startTime := time.Now()
+	code := sym.String()
 
 	// 步骤0: 获取Python分析数据
-	eventChan <- SSEEvent{
+	if err := emit(ctx, eventChan, SSEEvent{
 		Event: "progress",
 		Data: map[string]interface{}{
 			"step":     "fetching_data",
 			"message":  "正在获取股票数据...",
 			"progress": 10,
 		},
+	}); err != nil {
+		return ao.fail(ctx, code, startTime, eventChan, err)
 	}
 
-	pythonData, err := ao.pythonClient.Analyze(code)
+	pythonData, err := datasource.Snapshot(ctx, ao.dataProvider, sym.Query())
 	if err != nil {
-		eventChan <- SSEEvent{
-			Event: "error",
-			Data:  map[string]string{"error": fmt.Sprintf("获取数据失败: %v", err)},
-		}
-		return fmt.Errorf("获取数据失败: %w", err)
+		return ao.fail(ctx, code, startTime, eventChan, fmt.Errorf("获取数据失败: %w", err))
 	}
+	sym = resolveSymbol(sym, pythonData)
 
-	// 准备LLM输入数据
-	llmData := ao.prepareLLMData(pythonData)
-	log.Printf("准备LLM输入数据: %v", llmData)
+	// 获取K线并计算技术指标，失败时仅缺少技术面数据，不影响其余分析
+	technical := ao.technicalSummary(ctx, pythonData.Code)
 
-	// 存储各步骤结果
-	results := make(map[string]string)
+	// 检查数据缺失与过期，提示前端并写入提示词
+	technicalDate := ""
+	if technical != nil {
+		technicalDate = technical.Date
+	}
+	dataQuality := quality.Check(quality.Input{Snapshot: pythonData, TechnicalDate: technicalDate, Now: time.Now()})
+	if !dataQuality.Complete {
+		log.Printf("数据质量提示: %s, %s", pythonData.Code, dataQuality.Message)
+	}
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "data_quality",
+		Data:  dataQuality,
+	}); err != nil {
+		return ao.fail(ctx, code, startTime, eventChan, err)
+	}
 
+	// 准备LLM输入数据
+	llmData := ao.prepareLLMData(sym, pythonData, technical, financialTrends(pythonData), dataQuality)
+	log.Printf("准备LLM输入数据: %v", llmData)
 
-	// 步骤1: 综合分析
-	if err := ao.runStep(ctx, llm.StepComprehensive, "综合分析", llmData, results, eventChan, 20); err != nil {
-		eventChan <- SSEEvent{
-			Event: "error",
-			Data:  map[string]string{"error": err.Error()},
+	// 相同输入、提示词和模型的结果未过期时直接回放
+	fingerprint := ao.fingerprint(llmData)
+	if !opts.ForceRefresh && fingerprint != "" {
+		if cached := ao.cache.lookup(fingerprint); cached != nil {
+			return ao.replay(ctx, startTime, cached, eventChan)
 		}
-		return err
 	}
-	llmData["comprehensive_analysis"] = results[string(llm.StepComprehensive)]
 
-	// 步骤2: 多头观点
-	if err := ao.runStep(ctx, llm.StepDebateBull, "多头观点", llmData, results, eventChan, 40); err != nil {
-		eventChan <- SSEEvent{
-			Event: "error",
-			Data:  map[string]string{"error": err.Error()},
+	// 无论成功与否都记录本次运行的用量并保存报告
+	usage := &usageCollector{}
+	var result *pipelineResult
+	errMsg := ""
+	saved := false
+	defer func() {
+		if saved {
+			return
 		}
-		return err
+		status := "failed"
+		if ctx.Err() != nil {
+			status = "cancelled"
+		}
+		ao.recordRun(pythonData, fingerprint, startTime, status, errMsg, result, usage)
+	}()
+
+	// 按流水线DAG执行各分析步骤
+	result, err = ao.runPipeline(ctx, ao.plan, llmData, usage, eventChan)
+	if err != nil {
+		errMsg = err.Error()
+		return ao.fail(ctx, code, startTime, eventChan, err)
 	}
-	llmData["bull_case"] = results[string(llm.StepDebateBull)]
 
-	// 步骤3: 空头观点
-	if err := ao.runStep(ctx, llm.StepDebateBear, "空头观点", llmData, results, eventChan, 60); err != nil {
-		eventChan <- SSEEvent{
-			Event: "error",
-			Data:  map[string]string{"error": err.Error()},
-		}
-		return err
+	// 发送用量汇总事件
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "usage",
+		Data:  usage.report(),
+	}); err != nil {
+		return ao.fail(ctx, code, startTime, eventChan, err)
 	}
 
-	llmData["bull_case"] = results[string(llm.StepDebateBull)]
-	llmData["bear_case"] = results[string(llm.StepDebateBear)]
+	reportID := ao.recordRun(pythonData, fingerprint, startTime, "completed", "", result, usage)
+	saved = true
 
-	// 步骤4: 交易员决策
-	if err := ao.runStep(ctx, llm.StepTrader, "交易员决策", llmData, results, eventChan, 80); err != nil {
-		eventChan <- SSEEvent{
-			Event: "error",
-			Data:  map[string]string{"error": err.Error()},
-		}
-		return err
+	// 发送完成事件
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "done",
+		Data:  map[string]interface{}{"message": "分析完成", "report_id": reportID},
+	}); err != nil {
+		return ao.fail(ctx, code, startTime, eventChan, err)
 	}
-	llmData["trader_decision"] = results[string(llm.StepTrader)]
 
-	// 步骤5: 最终决策
-	if err := ao.runStep(ctx, llm.StepFinal, "最终决策", llmData, results, eventChan, 100); err != nil {
-		eventChan <- SSEEvent{
-			Event: "error",
-			Data:  map[string]string{"error": err.Error()},
+	log.Printf("分析结束: %s, outcome=completed, 耗时: %v", code, time.Since(startTime))
+	return nil
+}
+
+// recordRun 记录用量账本并保存分析报告，返回报告ID（未配置存储或保存失败时为0）
+func (ao *AnalysisOrchestrator) recordRun(
+	input *model.PythonAnalysisResponse,
+	fingerprint string,
+	startTime time.Time,
+	status string,
+	errMsg string,
+	result *pipelineResult,
+	usage *usageCollector,
+) int64 {
+	usageReport := usage.report()
+	log.Printf("分析用量: %s, tokens=%d, 估算费用=%.4f元", input.Code, usageReport.Total.TotalTokens, usageReport.Total.Cost)
+	if err := ao.usageLedger.Record(input.Code, input.Name, status, usageReport); err != nil {
+		log.Printf("记录用量失败: %v", err)
+	}
+
+	if ao.reports == nil {
+		return 0
+	}
+
+	usageJSON, err := json.Marshal(usageReport)
+	if err != nil {
+		log.Printf("序列化用量失败: %v", err)
+	}
+
+	finishedAt := time.Now()
+	report := &store.Report{
+		Code:        input.Code,
+		Name:        input.Name,
+		Status:      status,
+		Error:       errMsg,
+		Input:       input,
+		Usage:       usageJSON,
+		Providers:   usageReport.providers(),
+		TotalTokens: usageReport.Total.TotalTokens,
+		TotalCost:   usageReport.Total.Cost,
+		StartedAt:   startTime,
+		FinishedAt:  finishedAt,
+		DurationMs:  finishedAt.Sub(startTime).Milliseconds(),
+		Fingerprint: fingerprint,
+	}
+	if status == "completed" {
+		report.ExpiresAt = ao.cache.expiry(finishedAt)
+	}
+	if result != nil {
+		report.Outputs = result.Outputs
+		report.Decisions = result.Decisions
+		report.StepTimings = result.StepTimings
+		if final, ok := result.Decisions[string(llm.StepFinal)]; ok {
+			report.FinalAction = final.Action
+			report.FinalConfidence = final.Confidence
+			report.PromptVersion = llm.PromptVersionFor(llm.StepFinal)
 		}
-		return err
 	}
 
-	// 发送完成事件
-	eventChan <- SSEEvent{
-		Event: "done",
-		Data:  map[string]string{"message": "分析完成"},
+	if err := ao.reports.Save(report); err != nil {
+		log.Printf("保存分析报告失败: %v", err)
+		return 0
 	}
+	log.Printf("分析报告已保存: id=%d, %s, status=%s", report.ID, input.Code, status)
+	return report.ID
+}
 
-	return nil
+// fail 记录分析失败结果。请求已取消时不再发送error事件（客户端已不再读取）
+func (ao *AnalysisOrchestrator) fail(ctx context.Context, code string, startTime time.Time, eventChan chan<- SSEEvent, err error) error {
+	if ctx.Err() != nil {
+		log.Printf("分析结束: %s, outcome=cancelled, 耗时: %v, 原因: %v", code, time.Since(startTime), ctx.Err())
+		return ctx.Err()
+	}
+
+	log.Printf("分析结束: %s, outcome=failed, 耗时: %v, 错误: %v", code, time.Since(startTime), err)
+	emit(ctx, eventChan, SSEEvent{
+		Event: "error",
+		Data:  map[string]string{"error": err.Error()},
+	})
+	return err
+}
+
+// emit 发送事件；ctx 取消时放弃发送，避免在无人读取的通道上永久阻塞
+func emit(ctx context.Context, eventChan chan<- SSEEvent, event SSEEvent) error {
+	select {
+	case eventChan <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pipelineResult 流水线执行结果
+type pipelineResult struct {
+	Outputs     map[string]string        // 各步骤输出，按步骤名索引
+	Decisions   map[string]*llm.Decision // 声明了 decision 的步骤的结构化决策
+	StepTimings map[string]int64         // 各步骤耗时（毫秒）
+}
+
+// runPipeline 按依赖关系调度流水线步骤，无依赖关系的步骤并行执行，
+// 任一步骤失败时取消其余步骤。失败时仍返回已完成步骤的部分结果
+func (ao *AnalysisOrchestrator) runPipeline(
+	ctx context.Context,
+	plan *pipeline.Plan,
+	llmData map[string]interface{},
+	usage *usageCollector,
+	eventChan chan<- SSEEvent,
+) (*pipelineResult, error) {
+	pipelineCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := &pipelineResult{
+		Outputs:     make(map[string]string),
+		Decisions:   make(map[string]*llm.Decision),
+		StepTimings: make(map[string]int64),
+	}
+	var dataMu sync.Mutex
+
+	done := make(map[llm.AnalysisStep]chan struct{}, len(plan.Steps))
+	for _, spec := range plan.Steps {
+		done[spec.Step] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	errChan := make(chan error, len(plan.Steps))
+
+	for _, spec := range plan.Steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// 等待所有依赖步骤完成
+			for _, dep := range plan.DependsOn[spec.Step] {
+				select {
+				case <-done[dep]:
+				case <-pipelineCtx.Done():
+					return
+				}
+			}
+
+			// 复制一份输入数据，避免与其他步骤写入产生竞争
+			dataMu.Lock()
+			stepData := plan.StepData(spec.Step, llmData)
+			dataMu.Unlock()
+
+			stepCtx := llm.WithUsageHook(pipelineCtx, func(u llm.Usage) {
+				usage.add(spec.Step, u)
+			})
+			stepStart := time.Now()
+			content, err := ao.runStep(stepCtx, spec.Step, spec.Role, stepData, eventChan, plan.Progress(spec.Step))
+			if err != nil {
+				errChan <- err
+				// 取消其他仍在执行或等待的步骤
+				cancel()
+				return
+			}
+
+			dataMu.Lock()
+			llmData[spec.OutputKey] = content
+			result.Outputs[string(spec.Step)] = content
+			result.StepTimings[string(spec.Step)] = time.Since(stepStart).Milliseconds()
+			dataMu.Unlock()
+
+			close(done[spec.Step])
+
+			// 下游步骤已可开始，结构化决策抽取与其并行进行
+			if spec.Decision {
+				if decision := ao.extractDecision(stepCtx, spec, content, eventChan); decision != nil {
+					dataMu.Lock()
+					result.Decisions[string(spec.Step)] = decision
+					dataMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	// 返回第一个错误（被取消的步骤产生的错误排在其后）
+	if err := <-errChan; err != nil {
+		return result, err
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// extractDecision 从步骤输出中抽取结构化决策并发送 decision 事件。
+// 优先使用提供商的JSON模式/工具调用，失败时回退到文本抽取；抽取失败不影响整体分析
+func (ao *AnalysisOrchestrator) extractDecision(ctx context.Context, spec pipeline.StepSpec, content string, eventChan chan<- SSEEvent) *llm.Decision {
+	source := "structured"
+	var decision *llm.Decision
+	var err error
+
+	if extractor, ok := ao.llmClient.(llm.DecisionExtractor); ok {
+		decision, err = extractor.ExtractDecision(ctx, spec.Step, content)
+		if err != nil {
+			log.Printf("[%s] 结构化决策抽取失败，回退到文本抽取: %v", spec.Role, err)
+		}
+	}
+	if decision == nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		source = "text"
+		decision, err = llm.ParseDecisionText(content)
+		if err != nil {
+			log.Printf("[%s] 文本决策抽取失败: %v", spec.Role, err)
+			return nil
+		}
+	}
+
+	emit(ctx, eventChan, SSEEvent{
+		Event: "decision",
+		Data: map[string]interface{}{
+			"step":     string(spec.Step),
+			"role":     spec.Role,
+			"source":   source,
+			"decision": decision,
+		},
+	})
+	return decision
 }
 
 func (ao *AnalysisOrchestrator) runStep(
@@ -124,10 +382,9 @@ func (ao *AnalysisOrchestrator) runStep(
 	step llm.AnalysisStep,
 	stepName string,
 	data map[string]interface{},
-	results map[string]string,
 	eventChan chan<- SSEEvent,
 	progress int,
-) error {
+) (string, error) {
 	log.Printf("开始执行: %s", stepName)
 
 	var content string
@@ -146,7 +403,9 @@ func (ao *AnalysisOrchestrator) runStep(
 				"progress": progress,
 			},
 		}
-		eventChan <- event
+		if err := emit(ctx, eventChan, event); err != nil {
+			return err
+		}
 
 		// 每10个delta记录一次
 		if deltaCount%10 == 0 {
@@ -155,12 +414,26 @@ func (ao *AnalysisOrchestrator) runStep(
 		return nil
 	}
 
-	if err := ao.llmClient.StreamAnalyze(ctx, step, data, callback); err != nil {
+	// 提供商重试或切换时丢弃已输出的部分内容，并通知前端重置该步骤
+	stepCtx := llm.WithResetHook(ctx, func(reason string) {
+		log.Printf("[%s] 输出重置: %s", stepName, reason)
+		content = ""
+		deltaCount = 0
+		emit(ctx, eventChan, SSEEvent{
+			Event: "step_reset",
+			Data: map[string]interface{}{
+				"step":   string(step),
+				"role":   stepName,
+				"reason": reason,
+			},
+		})
+	})
+
+	if err := ao.llmClient.StreamAnalyze(stepCtx, step, data, callback); err != nil {
 		log.Printf("[%s] 失败: %v", stepName, err)
-		return fmt.Errorf("%s失败: %w", stepName, err)
+		return "", fmt.Errorf("%s失败: %w", stepName, err)
 	}
 
-	results[string(step)] = content
 	log.Printf("完成执行: %s, 总delta数: %d, 总长度: %d", stepName, deltaCount, len(content))
 	log.Printf("开始发送步骤完成事件: %s", stepName)
 	// 发送步骤完成事件
@@ -172,24 +445,88 @@ func (ao *AnalysisOrchestrator) runStep(
 		},
 	}
 	log.Printf("发送步骤完成事件: %s", stepName)
-	eventChan <- event
+	if err := emit(ctx, eventChan, event); err != nil {
+		return "", err
+	}
 
-	return nil
+	return content, nil
+}
+
+// technicalSummary 获取近一年日K线并计算技术指标摘要，数据不可用时返回 nil
+func (ao *AnalysisOrchestrator) technicalSummary(ctx context.Context, code string) *indicators.Summary {
+	end := time.Now().In(market.Shanghai)
+	bars, err := ao.dataProvider.History(ctx, code, end.AddDate(-1, 0, 0), end)
+	if err != nil {
+		if !errors.Is(err, datasource.ErrNotSupported) {
+			log.Printf("获取K线失败，跳过技术指标: %s, %v", code, err)
+		}
+		return nil
+	}
+
+	summary, err := indicators.Summarize(bars)
+	if err != nil {
+		log.Printf("计算技术指标失败: %s, %v", code, err)
+		return nil
+	}
+	return summary
 }
 
-func (ao *AnalysisOrchestrator) prepareLLMData(pythonData *model.PythonAnalysisResponse) map[string]interface{} {
-	return map[string]interface{}{
-		"code":            pythonData.Code,
-		"name":            pythonData.Name,
-		"industry":        pythonData.BasicInfo.Industry,
-		"market_cap":      pythonData.BasicInfo.MarketCap,
-		"pe_ttm":          pythonData.BasicInfo.PETTM,
-		"pb":              pythonData.BasicInfo.PB,
-		"latest_price":    pythonData.Price.LatestPrice,
-		"roe":             pythonData.FinancialMetrics.ROE,
-		"debt_ratio":      pythonData.FinancialMetrics.DebtRatio,
-		"revenue_growth":  pythonData.FinancialMetrics.RevenueGrowth,
-		"profit_growth":   pythonData.FinancialMetrics.ProfitGrowth,
-		"risks":           pythonData.Risks,
+// financialTrends 根据多期财务摘要计算趋势，历史数据不足时返回 nil
+func financialTrends(pythonData *model.PythonAnalysisResponse) *financials.Trends {
+	trends, err := financials.Analyze(pythonData.FinancialHistory)
+	if err != nil {
+		log.Printf("跳过财务趋势: %s, %v", pythonData.Code, err)
+		return nil
+	}
+	return trends
+}
+
+// resolveSymbol 用数据服务返回的代码和名称补全证券标识，按名称输入时据此识别交易所与板块
+func resolveSymbol(sym symbol.Symbol, pythonData *model.PythonAnalysisResponse) symbol.Symbol {
+	if sym.IsName() {
+		resolved, err := symbol.FromCode(pythonData.Code)
+		if err != nil {
+			log.Printf("无法识别数据服务返回的代码: %s -> %s, %v", sym.Name, pythonData.Code, err)
+			return sym
+		}
+		sym = resolved
+	}
+	sym.Name = pythonData.Name
+	return sym
+}
+
+func (ao *AnalysisOrchestrator) prepareLLMData(sym symbol.Symbol, pythonData *model.PythonAnalysisResponse, technical *indicators.Summary, trends *financials.Trends, dataQuality *quality.Report) map[string]interface{} {
+	data := map[string]interface{}{
+		"code":           pythonData.Code,
+		"name":           pythonData.Name,
+		"exchange":       string(sym.Exchange),
+		"board":          string(sym.Board),
+		"price_limit":    sym.PriceLimit(),
+		"industry":       pythonData.BasicInfo.Industry,
+		"market_cap":     pythonData.BasicInfo.MarketCap.HundredMillion(), // 亿元，与提示词单位一致
+		"pe_ttm":         pythonData.BasicInfo.PETTM,
+		"pb":             pythonData.BasicInfo.PB,
+		"latest_price":   pythonData.Price.LatestPrice,
+		"report_date":    pythonData.FinancialMetrics.ReportDate,
+		"roe":            pythonData.FinancialMetrics.ROE,
+		"roa":            pythonData.FinancialMetrics.ROA,
+		"gross_margin":   pythonData.FinancialMetrics.GrossMargin,
+		"net_margin":     pythonData.FinancialMetrics.NetMargin,
+		"debt_ratio":     pythonData.FinancialMetrics.DebtRatio,
+		"revenue_growth": pythonData.FinancialMetrics.RevenueGrowth,
+		"profit_growth":  pythonData.FinancialMetrics.ProfitGrowth,
+		"risks":          pythonData.Risks,
+	}
+	if technical != nil {
+		data["technical"] = technical
+		data["technical_summary"] = technical.Text()
+	}
+	if trends != nil {
+		data["financial_trends"] = trends
+		data["financial_trend_summary"] = trends.Text()
+	}
+	if text := dataQuality.Text(); text != "" {
+		data["data_quality_summary"] = text
 	}
+	return data
 }
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"stock-analysis-api/backend/go-api/internal/datasource"
+	"stock-analysis-api/backend/go-api/internal/pipeline"
+	"stock-analysis-api/backend/go-api/internal/portfolio"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// holdingFetchConcurrency 组合分析时同时获取行情数据的股票数
+const holdingFetchConcurrency = 5
+
+// portfolioPlan 持仓组合分析的固定流水线
+var portfolioPlan = func() *pipeline.Plan {
+	plan, err := pipeline.Portfolio().Compile()
+	if err != nil {
+		panic(err)
+	}
+	return plan
+}()
+
+// AnalyzePortfolio 分析持仓组合：并发获取各持仓数据，计算权重、盈亏、行业集中度、加权估值与财务质量，
+// 再由组合经理流式给出调仓建议。holdings 的 Snapshot 由本方法填充。
+// 事件格式与 Analyze 一致，另有 portfolio（组合统计）事件
+func (ao *AnalysisOrchestrator) AnalyzePortfolio(ctx context.Context, holdings []portfolio.Holding, eventChan chan<- SSEEvent) error {
+	defer close(eventChan)
+	startTime := time.Now()
+	labels := make([]string, len(holdings))
+	for i, h := range holdings {
+		labels[i] = h.Symbol.String()
+	}
+	label := strings.Join(labels, ",")
+
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "progress",
+		Data: map[string]interface{}{
+			"step":     "fetching_data",
+			"message":  fmt.Sprintf("正在获取 %d 只持仓股票的数据...", len(holdings)),
+			"progress": 10,
+		},
+	}); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	if err := ao.fetchHoldings(ctx, holdings); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+	summary, err := portfolio.Build(holdings)
+	if err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "portfolio",
+		Data:  summary,
+	}); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	codes := make([]string, len(holdings))
+	var trends strings.Builder
+	for i, h := range holdings {
+		codes[i] = h.Snapshot.Code
+		if t := financialTrends(h.Snapshot); t != nil {
+			fmt.Fprintf(&trends, "%s(%s):\n%s", h.Snapshot.Name, h.Snapshot.Code, t.Text())
+		}
+	}
+	code, name := strings.Join(codes, ","), fmt.Sprintf("持仓组合(%d只)", len(holdings))
+	llmData := map[string]interface{}{
+		"code":              code,
+		"name":              name,
+		"portfolio_summary": summary.Text(),
+		"holding_trends":    trends.String(),
+	}
+
+	usage := &usageCollector{}
+	status := "failed"
+	defer func() {
+		if ctx.Err() != nil {
+			status = "cancelled"
+		}
+		usageReport := usage.report()
+		log.Printf("组合分析用量: %s, tokens=%d, 估算费用=%.4f元", code, usageReport.Total.TotalTokens, usageReport.Total.Cost)
+		if err := ao.usageLedger.Record(code, name, status, usageReport); err != nil {
+			log.Printf("记录用量失败: %v", err)
+		}
+	}()
+
+	if _, err := ao.runPipeline(ctx, portfolioPlan, llmData, usage, eventChan); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "usage",
+		Data:  usage.report(),
+	}); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+	status = "completed"
+
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "done",
+		Data:  map[string]interface{}{"message": "组合分析完成"},
+	}); err != nil {
+		return ao.fail(ctx, label, startTime, eventChan, err)
+	}
+
+	log.Printf("组合分析结束: %s, outcome=completed, 耗时: %v", label, time.Since(startTime))
+	return nil
+}
+
+// fetchHoldings 并发获取各持仓的行情数据，任一股票失败时整体失败
+func (ao *AnalysisOrchestrator) fetchHoldings(ctx context.Context, holdings []portfolio.Holding) error {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(holdingFetchConcurrency)
+	for i := range holdings {
+		g.Go(func() error {
+			sym := holdings[i].Symbol
+			snapshot, err := datasource.Snapshot(gctx, ao.dataProvider, sym.Query())
+			if err != nil {
+				return fmt.Errorf("获取 %s 数据失败: %w", sym.Query(), err)
+			}
+			holdings[i].Symbol = resolveSymbol(sym, snapshot)
+			holdings[i].Snapshot = snapshot
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	// 按名称输入的股票解析后可能与其他持仓重复
+	seen := make(map[string]bool, len(holdings))
+	for _, h := range holdings {
+		if seen[h.Snapshot.Code] {
+			return fmt.Errorf("持仓股票重复: %s(%s)，请合并为一笔持仓", h.Snapshot.Name, h.Snapshot.Code)
+		}
+		seen[h.Snapshot.Code] = true
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"stock-analysis-api/backend/go-api/internal/market"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"time"
+)
+
+// replayChunkRunes 回放缓存结果时每个 analysis_step 事件携带的字符数
+const replayChunkRunes = 32
+
+// ResultCache 分析结果缓存。输入数据、提示词版本和模型均相同且未过期的成功报告直接回放，不再调用LLM。
+// 缓存条目即报告存储中带指纹的报告，服务重启后仍然有效
+type ResultCache struct {
+	reports     *store.ReportStore
+	ttl         time.Duration // 交易时段内的有效期；休市期间有效至下一交易时段开盘
+	replayDelay time.Duration // 回放时每个内容片段的间隔，0 表示不模拟流式节奏
+}
+
+func NewResultCache(reports *store.ReportStore, ttl, replayDelay time.Duration) *ResultCache {
+	return &ResultCache{
+		reports:     reports,
+		ttl:         ttl,
+		replayDelay: replayDelay,
+	}
+}
+
+func (rc *ResultCache) enabled() bool {
+	return rc != nil && rc.reports != nil && rc.ttl > 0
+}
+
+// lookup 查找可复用的报告，未命中或查询失败时返回 nil
+func (rc *ResultCache) lookup(fingerprint string) *store.Report {
+	if !rc.enabled() {
+		return nil
+	}
+	report, err := rc.reports.FindCached(fingerprint, time.Now())
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Printf("查询结果缓存失败: %v", err)
+		}
+		return nil
+	}
+	return report
+}
+
+// expiry 返回在 finishedAt 完成的分析结果的失效时间，缓存未启用时返回零值
+func (rc *ResultCache) expiry(finishedAt time.Time) time.Time {
+	if !rc.enabled() {
+		return time.Time{}
+	}
+	return market.Expiry(finishedAt, rc.ttl)
+}
+
+// fingerprint 计算分析指纹：LLM输入数据 + 流水线结构 + 各步骤提示词版本与模型
+func (ao *AnalysisOrchestrator) fingerprint(llmData map[string]interface{}) string {
+	h := sha256.New()
+	// map 序列化时按键排序，结果稳定
+	data, err := json.Marshal(llmData)
+	if err != nil {
+		log.Printf("计算分析指纹失败: %v", err)
+		return ""
+	}
+	h.Write(data)
+	for _, spec := range ao.plan.Steps {
+		fmt.Fprintf(h, "\n%s|%s|%v|%s|%s",
+			spec.Step, spec.OutputKey, ao.plan.DependsOn[spec.Step],
+			llm.PromptVersionFor(spec.Step), llm.DescribeModel(ao.llmClient, spec.Step))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay 以SSE事件回放缓存的分析报告，事件顺序与实际执行一致
+func (ao *AnalysisOrchestrator) replay(ctx context.Context, startTime time.Time, report *store.Report, eventChan chan<- SSEEvent) error {
+	log.Printf("命中结果缓存: %s, report_id=%d, 生成于 %s", report.Code, report.ID, report.FinishedAt.Local().Format(time.DateTime))
+
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "cache_hit",
+		Data: map[string]interface{}{
+			"report_id":  report.ID,
+			"cached_at":  report.FinishedAt,
+			"expires_at": report.ExpiresAt,
+		},
+	}); err != nil {
+		return ao.fail(ctx, report.Code, startTime, eventChan, err)
+	}
+
+	for _, spec := range ao.plan.Steps {
+		content, ok := report.Outputs[string(spec.Step)]
+		if !ok {
+			continue
+		}
+		progress := ao.plan.Progress(spec.Step)
+
+		for _, chunk := range chunkText(content, replayChunkRunes) {
+			if err := emit(ctx, eventChan, SSEEvent{
+				Event: "analysis_step",
+				Data: map[string]interface{}{
+					"step":     string(spec.Step),
+					"role":     spec.Role,
+					"content":  chunk,
+					"progress": progress,
+				},
+			}); err != nil {
+				return ao.fail(ctx, report.Code, startTime, eventChan, err)
+			}
+			if err := sleepCtx(ctx, ao.cache.replayDelay); err != nil {
+				return ao.fail(ctx, report.Code, startTime, eventChan, err)
+			}
+		}
+
+		if err := emit(ctx, eventChan, SSEEvent{
+			Event: "step_completed",
+			Data: map[string]interface{}{
+				"step":      string(spec.Step),
+				"completed": true,
+			},
+		}); err != nil {
+			return ao.fail(ctx, report.Code, startTime, eventChan, err)
+		}
+
+		if decision, ok := report.Decisions[string(spec.Step)]; ok {
+			if err := emit(ctx, eventChan, SSEEvent{
+				Event: "decision",
+				Data: map[string]interface{}{
+					"step":     string(spec.Step),
+					"role":     spec.Role,
+					"source":   "cache",
+					"decision": decision,
+				},
+			}); err != nil {
+				return ao.fail(ctx, report.Code, startTime, eventChan, err)
+			}
+		}
+	}
+
+	// 本次运行未调用LLM，用量为零
+	usageReport := (&usageCollector{}).report()
+	if err := ao.usageLedger.Record(report.Code, report.Name, "cached", usageReport); err != nil {
+		log.Printf("记录用量失败: %v", err)
+	}
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "usage",
+		Data:  usageReport,
+	}); err != nil {
+		return ao.fail(ctx, report.Code, startTime, eventChan, err)
+	}
+
+	if err := emit(ctx, eventChan, SSEEvent{
+		Event: "done",
+		Data:  map[string]interface{}{"message": "分析完成", "report_id": report.ID, "cached": true},
+	}); err != nil {
+		return ao.fail(ctx, report.Code, startTime, eventChan, err)
+	}
+
+	log.Printf("分析结束: %s, outcome=cached, 耗时: %v", report.Code, time.Since(startTime))
+	return nil
+}
+
+// chunkText 按字符数切分文本，不会切断多字节字符
+func chunkText(text string, size int) []string {
+	runes := []rune(text)
+	chunks := make([]string, 0, len(runes)/size+1)
+	for len(runes) > 0 {
+		n := min(size, len(runes))
+		chunks = append(chunks, string(runes[:n]))
+		runes = runes[n:]
+	}
+	return chunks
+}
+
+// sleepCtx 等待 d，ctx 取消时提前返回
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"stock-analysis-api/backend/go-api/internal/market"
+	"stock-analysis-api/backend/go-api/internal/pipeline"
+	"stock-analysis-api/backend/go-api/internal/store"
+)
+
+func newTestReports(t *testing.T) *store.ReportStore {
+	t.Helper()
+	db, err := store.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return store.NewReportStore(db)
+}
+
+func TestResultCacheLookup(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		status    string
+		expiresAt time.Time
+		hit       bool
+	}{
+		{name: "valid", status: "completed", expiresAt: now.Add(time.Hour), hit: true},
+		{name: "expired", status: "completed", expiresAt: now.Add(-time.Second)},
+		{name: "not cacheable", status: "completed"},
+		{name: "failed run", status: "failed", expiresAt: now.Add(time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reports := newTestReports(t)
+			err := reports.Save(&store.Report{
+				Code: "600519.SH", Status: tt.status, Fingerprint: "fp",
+				StartedAt: now, FinishedAt: now, ExpiresAt: tt.expiresAt,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cache := NewResultCache(reports, 5*time.Minute, 0)
+			if got := cache.lookup("fp"); (got != nil) != tt.hit {
+				t.Errorf("lookup hit = %v, want %v", got != nil, tt.hit)
+			}
+			if cache.lookup("other") != nil {
+				t.Error("lookup with another fingerprint should miss")
+			}
+		})
+	}
+}
+
+func TestResultCacheExpiry(t *testing.T) {
+	intraday := time.Date(2024, 6, 6, 10, 0, 0, 0, market.Shanghai)
+
+	cache := NewResultCache(newTestReports(t), 5*time.Minute, 0)
+	if got := cache.expiry(intraday); !got.Equal(market.Expiry(intraday, 5*time.Minute)) {
+		t.Errorf("expiry = %v, want market.Expiry with the cache TTL", got)
+	}
+
+	// TTL 为0或未配置存储时不缓存
+	for _, disabled := range []*ResultCache{nil, NewResultCache(nil, 5*time.Minute, 0), NewResultCache(newTestReports(t), 0, 0)} {
+		if got := disabled.expiry(intraday); !got.IsZero() {
+			t.Errorf("disabled cache expiry = %v, want zero", got)
+		}
+		if disabled.lookup("fp") != nil {
+			t.Error("disabled cache should never hit")
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	plan, err := pipeline.Default().Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := plan.Steps[0].Step, plan.Steps[1].Step
+	report := &store.Report{
+		ID:   7,
+		Code: "600519.SH",
+		Outputs: map[string]string{
+			string(first):  strings.Repeat("多", replayChunkRunes+8), // 切成两段
+			string(second): "空头观点",
+		},
+		Decisions: map[string]*llm.Decision{
+			string(second): {Action: llm.ActionHold},
+		},
+	}
+
+	ao := NewAnalysisOrchestrator(nil, nil, plan, nil, nil, NewResultCache(nil, time.Minute, 0))
+	events := make(chan SSEEvent, 64)
+	if err := ao.replay(context.Background(), time.Now(), report, events); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	close(events)
+
+	var got []string
+	for e := range events {
+		name := e.Event
+		if data, ok := e.Data.(map[string]interface{}); ok && data["step"] != nil {
+			name += ":" + data["step"].(string)
+		}
+		got = append(got, name)
+	}
+	want := []string{
+		"cache_hit",
+		"analysis_step:" + string(first), "analysis_step:" + string(first), "step_completed:" + string(first),
+		"analysis_step:" + string(second), "step_completed:" + string(second), "decision:" + string(second),
+		"usage", "done",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v\nwant %v", got, want)
+	}
+}
+
+func TestReplayStopsWhenCancelled(t *testing.T) {
+	plan, err := pipeline.Default().Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := &store.Report{Code: "600519.SH", Outputs: map[string]string{string(plan.Steps[0].Step): "内容"}}
+	ao := NewAnalysisOrchestrator(nil, nil, plan, nil, nil, NewResultCache(nil, time.Minute, time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ao.replay(ctx, time.Now(), report, make(chan SSEEvent)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
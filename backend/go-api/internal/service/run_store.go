@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Run 一次分析运行。运行期间的全部事件都会按序缓存，
+// 客户端断线后可凭 Last-Event-ID 回放遗漏的事件并继续接收实时事件
+type Run struct {
+	ID        string
+	Code      string
+	CreatedAt time.Time
+
+	mu          sync.Mutex
+	events      []SSEEvent
+	nextID      int64
+	finished    bool
+	finishedAt  time.Time
+	notify      chan struct{} // 每次有新事件或运行结束时关闭并替换
+	subscribers int
+	idleTimer   *time.Timer
+	idleTimeout time.Duration
+	cancel      context.CancelFunc
+}
+
+// append 为事件分配递增ID并缓存，同时唤醒所有等待的订阅者
+func (r *Run) append(event SSEEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	event.ID = r.nextID
+	r.events = append(r.events, event)
+
+	close(r.notify)
+	r.notify = make(chan struct{})
+}
+
+// finish 标记运行结束
+func (r *Run) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.finished = true
+	r.finishedAt = time.Now()
+	if r.idleTimer != nil {
+		r.idleTimer.Stop()
+	}
+
+	close(r.notify)
+	r.notify = make(chan struct{})
+}
+
+// EventsAfter 返回ID大于lastID的事件、运行是否已结束，以及下一次有新事件时会被关闭的通道
+func (r *Run) EventsAfter(lastID int64) ([]SSEEvent, bool, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 事件ID从1开始连续递增，可直接按下标定位
+	start := int(lastID)
+	if start < 0 {
+		start = 0
+	}
+	if start > len(r.events) {
+		start = len(r.events)
+	}
+
+	events := make([]SSEEvent, len(r.events)-start)
+	copy(events, r.events[start:])
+	return events, r.finished, r.notify
+}
+
+// Subscribe 登记一个正在读取事件的客户端
+func (r *Run) Subscribe() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers++
+	if r.idleTimer != nil {
+		r.idleTimer.Stop()
+		r.idleTimer = nil
+	}
+}
+
+// Unsubscribe 注销客户端。最后一个客户端断开后，若在宽限期内无人重连则取消分析，避免空耗LLM调用
+func (r *Run) Unsubscribe() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers--
+	if r.subscribers > 0 || r.finished {
+		return
+	}
+	r.startIdleTimer()
+}
+
+// startIdleTimer 启动无订阅者宽限期计时，调用方需持有r.mu
+func (r *Run) startIdleTimer() {
+	r.idleTimer = time.AfterFunc(r.idleTimeout, func() {
+		r.mu.Lock()
+		idle := r.subscribers == 0 && !r.finished
+		r.mu.Unlock()
+		if !idle {
+			return
+		}
+		log.Printf("运行 %s 在 %v 内无客户端订阅，取消分析", r.ID, r.idleTimeout)
+		r.cancel()
+	})
+}
+
+// RunStore 分析运行的内存存储，结束的运行在保留期后清理
+type RunStore struct {
+	mu          sync.RWMutex
+	runs        map[string]*Run
+	retention   time.Duration
+	idleTimeout time.Duration
+}
+
+func NewRunStore(retention, idleTimeout time.Duration) *RunStore {
+	store := &RunStore{
+		runs:        make(map[string]*Run),
+		retention:   retention,
+		idleTimeout: idleTimeout,
+	}
+	go store.cleanupLoop()
+	return store
+}
+
+// Start 创建一个运行并在后台执行analyze。运行的生命周期与单个HTTP请求解耦，
+// 由订阅者数量和宽限期控制取消：创建后即开始计时，宽限期内始终无人订阅的运行同样会被取消
+func (s *RunStore) Start(code string, analyze func(ctx context.Context, eventChan chan<- SSEEvent) error) *Run {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	run := &Run{
+		ID:          newRunID(),
+		Code:        code,
+		CreatedAt:   time.Now(),
+		notify:      make(chan struct{}),
+		idleTimeout: s.idleTimeout,
+		cancel:      cancel,
+	}
+
+	run.mu.Lock()
+	run.startIdleTimer()
+	run.mu.Unlock()
+
+	s.mu.Lock()
+	s.runs[run.ID] = run
+	s.mu.Unlock()
+
+	// 首个事件告知客户端运行ID，用于断线后重连
+	run.append(SSEEvent{
+		Event: "run",
+		Data:  map[string]string{"run_id": run.ID},
+	})
+
+	eventChan := make(chan SSEEvent, 10)
+	go func() {
+		defer cancel()
+		if err := analyze(ctx, eventChan); err != nil {
+			if errors.Is(err, context.Canceled) {
+				log.Printf("分析已取消 (客户端断开): %s, run=%s", code, run.ID)
+				return
+			}
+			log.Printf("分析失败: %v", err)
+			// 错误已经在orchestrator中发送到eventChan，这里只记录日志
+		}
+	}()
+
+	go func() {
+		for event := range eventChan {
+			run.append(event)
+		}
+		run.finish()
+	}()
+
+	return run
+}
+
+// Get 按ID查找运行
+func (s *RunStore) Get(id string) (*Run, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	run, ok := s.runs[id]
+	return run, ok
+}
+
+func (s *RunStore) cleanupLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		for id, run := range s.runs {
+			run.mu.Lock()
+			expired := run.finished && time.Since(run.finishedAt) > s.retention
+			run.mu.Unlock()
+			if expired {
+				delete(s.runs, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func newRunID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
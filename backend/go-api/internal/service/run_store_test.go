@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// startBlocking 启动一个阻塞到ctx取消的运行，返回的通道在analyze退出时关闭
+func startBlocking(store *RunStore) (*Run, <-chan struct{}) {
+	done := make(chan struct{})
+	run := store.Start("600519", func(ctx context.Context, eventChan chan<- SSEEvent) error {
+		defer close(eventChan)
+		defer close(done)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	return run, done
+}
+
+func TestRunWithoutSubscriberIsCancelled(t *testing.T) {
+	store := NewRunStore(time.Minute, 20*time.Millisecond)
+	_, done := startBlocking(store)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("无人订阅的运行未在宽限期后取消")
+	}
+}
+
+func TestSubscribeStopsIdleTimer(t *testing.T) {
+	store := NewRunStore(time.Minute, 20*time.Millisecond)
+	run, done := startBlocking(store)
+	run.Subscribe()
+
+	select {
+	case <-done:
+		t.Fatal("有订阅者的运行被取消")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	run.Unsubscribe()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("订阅者断开后运行未在宽限期后取消")
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"stock-analysis-api/backend/go-api/internal/market"
+	"stock-analysis-api/backend/go-api/internal/store"
+	"stock-analysis-api/backend/go-api/internal/symbol"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrBatchRunning 已有批量分析正在执行
+var ErrBatchRunning = errors.New("批量分析正在执行")
+
+// BatchScheduler 每个交易日收盘后对所有用户的自选股执行批量分析，保存报告并标记操作建议的变化
+type BatchScheduler struct {
+	orchestrator *AnalysisOrchestrator
+	watchlists   *store.WatchlistStore
+	batches      *store.BatchStore
+	reports      *store.ReportStore
+	at           int // 每日开始时间（北京时间，当日分钟数）
+	concurrency  int
+	stockTimeout time.Duration
+
+	running     atomic.Bool
+	lastAttempt string // 最近一次执行的交易日，避免失败后当天反复补跑
+}
+
+// closeMinute 收盘时间（北京时间15:00，当日分钟数）
+const closeMinute = 15 * 60
+
+// NewBatchScheduler 创建批量分析调度器，at 为北京时间 HH:MM，不能早于收盘。
+// 收盘后当天即为 market.LastTradingDay，next 与 Run 使用同一个交易日标识
+
+func NewBatchScheduler(
+	orchestrator *AnalysisOrchestrator,
+	watchlists *store.WatchlistStore,
+	batches *store.BatchStore,
+	reports *store.ReportStore,
+	at string,
+	concurrency int,
+	stockTimeout time.Duration,
+) (*BatchScheduler, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("批量分析时间格式应为 HH:MM: %s", at)
+	}
+	if t.Hour()*60+t.Minute() < closeMinute {
+		return nil, fmt.Errorf("批量分析时间不能早于15:00收盘: %s", at)
+	}
+	return &BatchScheduler{
+		orchestrator: orchestrator,
+		watchlists:   watchlists,
+		batches:      batches,
+		reports:      reports,
+		at:           t.Hour()*60 + t.Minute(),
+		concurrency:  max(concurrency, 1),
+		stockTimeout: stockTimeout,
+	}, nil
+}
+
+// Start 启动后台调度，ctx 取消时停止并中止正在执行的批量分析
+func (s *BatchScheduler) Start(ctx context.Context) {
+	go func() {
+		for {
+			next := s.next(time.Now())
+			log.Printf("下次自选股批量分析: %s", next.Format(time.DateTime))
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			if _, err := s.Run(ctx); err != nil {
+				log.Printf("自选股批量分析失败: %v", err)
+			}
+		}
+	}()
+}
+
+// next 返回下一次批量分析时间。当天已过开始时间但尚未完成（如服务在收盘后重启）时立即执行
+func (s *BatchScheduler) next(now time.Time) time.Time {
+	now = now.In(market.Shanghai)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, market.Shanghai)
+	for {
+		if market.IsTradingDay(day) {
+			runAt := day.Add(time.Duration(s.at) * time.Minute)
+			if runAt.After(now) {
+				return runAt
+			}
+			tradingDay := day.Format("2006-01-02")
+			if done, err := s.batches.HasRun(tradingDay); err == nil && !done && s.lastAttempt != tradingDay {
+				return now
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
+
+// Run 立即对所有自选股执行一次批量分析，同一时间只允许一次
+func (s *BatchScheduler) Run(ctx context.Context) (*store.BatchRun, error) {
+	if !s.running.CompareAndSwap(false, true) {
+		return nil, ErrBatchRunning
+	}
+	defer s.running.Store(false)
+
+	tradingDay := market.LastTradingDay(time.Now())
+	s.lastAttempt = tradingDay
+
+	stocks, err := s.watchlists.AllStocks()
+	if err != nil {
+		return nil, err
+	}
+	run, err := s.batches.CreateRun(tradingDay, len(stocks))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("开始自选股批量分析: run=%d, 交易日 %s, %d 只股票, 并发 %d", run.ID, tradingDay, len(stocks), s.concurrency)
+
+	var mu sync.Mutex
+	changed := 0
+	g := new(errgroup.Group)
+	g.SetLimit(s.concurrency)
+	for _, stock := range stocks {
+		if ctx.Err() != nil {
+			break
+		}
+		g.Go(func() error {
+			result := s.analyze(ctx, run.ID, stock)
+			if err := s.batches.SaveResult(result); err != nil {
+				log.Printf("保存批量分析结果失败: %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if result.Status == "completed" {
+				run.Succeeded++
+			} else {
+				run.Failed++
+			}
+			if result.Changed {
+				changed++
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	run.Status = "completed"
+	if ctx.Err() != nil {
+		run.Status = "cancelled"
+	}
+	if err := s.batches.FinishRun(run); err != nil {
+		return run, err
+	}
+	log.Printf("自选股批量分析结束: run=%d, status=%s, 成功 %d, 失败 %d, 建议变化 %d, 耗时 %v",
+		run.ID, run.Status, run.Succeeded, run.Failed, changed, run.FinishedAt.Sub(run.StartedAt))
+	return run, nil
+}
+
+// analyze 分析单只股票并读取保存的报告，取最终决策（缺失时取交易员决策）的操作建议
+func (s *BatchScheduler) analyze(ctx context.Context, runID int64, stock store.WatchlistStock) *store.BatchResult {
+	result := &store.BatchResult{RunID: runID, Code: stock.Code, Name: stock.Name, Status: "failed"}
+	defer func() { result.FinishedAt = time.Now() }()
+
+	sym, err := symbol.FromCode(stock.Code)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	sym.Name = stock.Name
+
+	stockCtx, cancel := context.WithTimeout(ctx, s.stockTimeout)
+	defer cancel()
+
+	// 事件仅用于获取报告ID，其余丢弃
+	eventChan := make(chan SSEEvent, 10)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.orchestrator.Analyze(stockCtx, sym, AnalyzeOptions{}, eventChan)
+	}()
+	var reportID int64
+	for event := range eventChan {
+		if data, ok := event.Data.(map[string]interface{}); ok && event.Event == "done" {
+			reportID, _ = data["report_id"].(int64)
+		}
+	}
+	if err := <-errChan; err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if reportID == 0 {
+		result.Error = "分析报告未保存"
+		return result
+	}
+
+	report, err := s.reports.Get(reportID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.ReportID = reportID
+	result.Name = report.Name
+	result.Action, result.Confidence = report.Recommendation()
+	if result.Action == "" {
+		result.Error = "未能从报告中抽取操作建议"
+		return result
+	}
+	result.Status = "completed"
+	return result
+}
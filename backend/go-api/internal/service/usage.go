@@ -0,0 +1,151 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StepUsage 单次LLM调用的用量与估算费用（重试和故障转移的调用会分别计入）
+type StepUsage struct {
+	Step string `json:"step"`
+	llm.Usage
+	Cost       float64 `json:"cost"`
+	PriceKnown bool    `json:"price_known"`
+}
+
+// UsageTotal token与费用合计
+type UsageTotal struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (t *UsageTotal) add(s StepUsage) {
+	t.PromptTokens += s.PromptTokens
+	t.CompletionTokens += s.CompletionTokens
+	t.TotalTokens += s.PromptTokens + s.CompletionTokens
+	t.Cost += s.Cost
+}
+
+// UsageReport 一次分析运行的用量汇总，作为 usage 事件发送给前端
+type UsageReport struct {
+	Steps      []StepUsage            `json:"steps"`
+	ByProvider map[string]*UsageTotal `json:"by_provider"`
+	Total      UsageTotal             `json:"total"`
+	Currency   string                 `json:"currency"`
+}
+
+// providers 返回本次使用的 提供商/模型 列表（去重，逗号分隔）
+func (r UsageReport) providers() string {
+	seen := make(map[string]bool)
+	var list []string
+	for _, s := range r.Steps {
+		key := s.Provider + "/" + s.Model
+		if !seen[key] {
+			seen[key] = true
+			list = append(list, key)
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+// usageCollector 并发安全地收集流水线各步骤的用量
+type usageCollector struct {
+	mu    sync.Mutex
+	steps []StepUsage
+}
+
+func (c *usageCollector) add(step llm.AnalysisStep, usage llm.Usage) {
+	cost, known := llm.EstimateCost(usage)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steps = append(c.steps, StepUsage{
+		Step:       string(step),
+		Usage:      usage,
+		Cost:       cost,
+		PriceKnown: known,
+	})
+}
+
+func (c *usageCollector) report() UsageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := UsageReport{
+		Steps:      append([]StepUsage(nil), c.steps...),
+		ByProvider: make(map[string]*UsageTotal),
+		Currency:   "CNY",
+	}
+	for _, s := range c.steps {
+		total, ok := report.ByProvider[s.Provider]
+		if !ok {
+			total = &UsageTotal{}
+			report.ByProvider[s.Provider] = total
+		}
+		total.add(s)
+		report.Total.add(s)
+	}
+	return report
+}
+
+// UsageLedger 以JSON Lines追加记录每次分析的用量，便于统计每只股票的分析成本
+type UsageLedger struct {
+	mu   sync.Mutex
+	path string
+}
+
+// usageLedgerEntry 账本中的一条记录
+type usageLedgerEntry struct {
+	Time   time.Time   `json:"time"`
+	Code   string      `json:"code"`
+	Name   string      `json:"name"`
+	Status string      `json:"status"`
+	Usage  UsageReport `json:"usage"`
+}
+
+func NewUsageLedger(path string) *UsageLedger {
+	return &UsageLedger{path: path}
+}
+
+// Record 追加一条用量记录，path为空时不记录
+func (l *UsageLedger) Record(code, name, status string, report UsageReport) error {
+	if l == nil || l.path == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(usageLedgerEntry{
+		Time:   time.Now(),
+		Code:   code,
+		Name:   name,
+		Status: status,
+		Usage:  report,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化用量记录失败: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("创建用量记录目录失败: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("打开用量记录文件失败: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入用量记录失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"strings"
+	"time"
+)
+
+// AlertRule 用户定义的提醒规则。规则语义（Kind 的取值与判断）由 alert 包定义
+type AlertRule struct {
+	ID              int64      `json:"id"`
+	UserID          string     `json:"-"`
+	Code            string     `json:"code"`
+	Name            string     `json:"name"`
+	Kind            string     `json:"kind"`
+	Threshold       float64    `json:"threshold"`
+	From            llm.Action `json:"from,omitempty"` // 仅 action_change：变化前的操作建议，空表示任意
+	To              llm.Action `json:"to,omitempty"`   // 仅 action_change：变化后的操作建议，空表示任意
+	State           AlertState `json:"-"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// AlertState 规则上一次检查的结果，用于判断条件是否“刚刚”成立
+type AlertState struct {
+	Initialized bool       `json:"initialized"`
+	Matched     bool       `json:"matched,omitempty"`   // 阈值条件上次是否成立
+	Date        string     `json:"date,omitempty"`      // 仅 change_pct：上次检查的行情日期，跨日后重新计算
+	Risks       []string   `json:"risks,omitempty"`     // 上次看到的风险提示
+	ReportID    int64      `json:"report_id,omitempty"` // 上次看到的最新报告
+	Action      llm.Action `json:"action,omitempty"`    // 上次看到的操作建议
+}
+
+// AlertEvent 一次触发的提醒
+type AlertEvent struct {
+	ID          int64              `json:"id"`
+	RuleID      int64              `json:"rule_id"`
+	UserID      string             `json:"-"`
+	Code        string             `json:"code"`
+	Name        string             `json:"name"`
+	Kind        string             `json:"kind"`
+	Message     string             `json:"message"`
+	Value       *float64           `json:"value,omitempty"` // 触发时的指标值
+	TriggeredAt time.Time          `json:"triggered_at"`
+	Deliveries  []*WebhookDelivery `json:"deliveries,omitempty"`
+}
+
+// AlertStore 提醒规则与提醒事件存储，用户接口按用户隔离
+type AlertStore struct {
+	db *sql.DB
+}
+
+func NewAlertStore(db *sql.DB) *AlertStore {
+	return &AlertStore{db: db}
+}
+
+// CreateRule 创建提醒规则并回填ID
+func (s *AlertStore) CreateRule(r *AlertRule) error {
+	state, err := json.Marshal(r.State)
+	if err != nil {
+		return fmt.Errorf("序列化规则状态失败: %w", err)
+	}
+	r.CreatedAt = time.Now()
+	res, err := s.db.Exec(`INSERT INTO alert_rules (user_id, code, name, kind, threshold, from_action, to_action, state_json, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.UserID, r.Code, r.Name, r.Kind, r.Threshold, string(r.From), string(r.To), string(state),
+		r.CreatedAt.UTC().Format(timeLayout))
+	if err != nil {
+		return fmt.Errorf("创建提醒规则失败: %w", err)
+	}
+	r.ID, err = res.LastInsertId()
+	return err
+}
+
+// ListRules 用户的提醒规则，按创建顺序
+func (s *AlertStore) ListRules(userID string) ([]*AlertRule, error) {
+	return s.queryRules(`WHERE user_id = ? ORDER BY id`, userID)
+}
+
+// AllRules 所有用户的提醒规则，供定时检查使用
+func (s *AlertStore) AllRules() ([]*AlertRule, error) {
+	return s.queryRules(`ORDER BY id`)
+}
+
+// CountRules 用户的提醒规则数量
+func (s *AlertStore) CountRules(userID string) (int, error) {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM alert_rules WHERE user_id = ?`, userID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("查询提醒规则失败: %w", err)
+	}
+	return n, nil
+}
+
+// DeleteRule 删除提醒规则，已触发的提醒事件保留
+func (s *AlertStore) DeleteRule(userID string, id int64) error {
+	res, err := s.db.Exec(`DELETE FROM alert_rules WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("删除提醒规则失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SaveState 保存规则的检查状态。规则已被删除时忽略
+func (s *AlertStore) SaveState(ruleID int64, state AlertState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("序列化规则状态失败: %w", err)
+	}
+	if _, err := s.db.Exec(`UPDATE alert_rules SET state_json = ? WHERE id = ?`, string(data), ruleID); err != nil {
+		return fmt.Errorf("保存规则状态失败: %w", err)
+	}
+	return nil
+}
+
+// Trigger 在同一事务中保存规则状态、记录提醒事件，并为用户的每个 Webhook 创建待推送记录。
+// 规则在检查期间被删除时不记录事件，返回 ErrNotFound
+func (s *AlertStore) Trigger(rule *AlertRule, state AlertState, event *AlertEvent) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("序列化规则状态失败: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := event.TriggeredAt.UTC().Format(timeLayout)
+	res, err := tx.Exec(`UPDATE alert_rules SET state_json = ?, last_triggered_at = ? WHERE id = ?`, string(data), now, rule.ID)
+	if err != nil {
+		return fmt.Errorf("保存规则状态失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	res, err = tx.Exec(`INSERT INTO alert_events (rule_id, user_id, code, name, kind, message, value, triggered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		rule.ID, rule.UserID, event.Code, event.Name, event.Kind, event.Message, event.Value, now)
+	if err != nil {
+		return fmt.Errorf("记录提醒事件失败: %w", err)
+	}
+	if event.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("读取提醒事件ID失败: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO webhook_deliveries (event_id, webhook_id, status, next_attempt_at)
+		SELECT ?, id, 'pending', ? FROM webhooks WHERE user_id = ?`, event.ID, now, rule.UserID); err != nil {
+		return fmt.Errorf("创建推送记录失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交提醒事件失败: %w", err)
+	}
+	event.RuleID, event.UserID = rule.ID, rule.UserID
+	return nil
+}
+
+// ListEvents 用户最近的提醒事件及各 Webhook 的推送状态，按触发时间倒序
+func (s *AlertStore) ListEvents(userID string, limit int) ([]*AlertEvent, error) {
+	rows, err := s.db.Query(`SELECT id, rule_id, code, name, kind, message, value, triggered_at
+		FROM alert_events WHERE user_id = ? ORDER BY id DESC LIMIT ?`, userID, ClampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("查询提醒事件失败: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*AlertEvent, 0)
+	byID := make(map[int64]*AlertEvent)
+	for rows.Next() {
+		e := &AlertEvent{UserID: userID}
+		var value sql.NullFloat64
+		var triggeredAt string
+		if err := rows.Scan(&e.ID, &e.RuleID, &e.Code, &e.Name, &e.Kind, &e.Message, &value, &triggeredAt); err != nil {
+			return nil, fmt.Errorf("读取提醒事件失败: %w", err)
+		}
+		if value.Valid {
+			e.Value = &value.Float64
+		}
+		e.TriggeredAt, _ = time.Parse(timeLayout, triggeredAt)
+		events = append(events, e)
+		byID[e.ID] = e
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return events, nil
+	}
+
+	ids := make([]interface{}, len(events))
+	placeholders := make([]string, len(events))
+	for i, e := range events {
+		ids[i], placeholders[i] = e.ID, "?"
+	}
+	deliveries, err := queryDeliveries(s.db, `WHERE event_id IN (`+strings.Join(placeholders, ", ")+`) ORDER BY id`, ids...)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deliveries {
+		if e := byID[d.EventID]; e != nil {
+			e.Deliveries = append(e.Deliveries, d)
+		}
+	}
+	return events, nil
+}
+
+func (s *AlertStore) queryRules(where string, args ...interface{}) ([]*AlertRule, error) {
+	rows, err := s.db.Query(`SELECT id, user_id, code, name, kind, threshold, from_action, to_action,
+			state_json, last_triggered_at, created_at
+		FROM alert_rules `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询提醒规则失败: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]*AlertRule, 0)
+	for rows.Next() {
+		var r AlertRule
+		var from, to, state, lastTriggeredAt, createdAt string
+		if err := rows.Scan(&r.ID, &r.UserID, &r.Code, &r.Name, &r.Kind, &r.Threshold, &from, &to,
+			&state, &lastTriggeredAt, &createdAt); err != nil {
+			return nil, fmt.Errorf("读取提醒规则失败: %w", err)
+		}
+		if err := json.Unmarshal([]byte(state), &r.State); err != nil {
+			return nil, fmt.Errorf("解析规则状态失败: %w", err)
+		}
+		r.From, r.To = llm.Action(from), llm.Action(to)
+		if lastTriggeredAt != "" {
+			t, _ := time.Parse(timeLayout, lastTriggeredAt)
+			r.LastTriggeredAt = &t
+		}
+		r.CreatedAt, _ = time.Parse(timeLayout, createdAt)
+		rules = append(rules, &r)
+	}
+	return rules, rows.Err()
+}
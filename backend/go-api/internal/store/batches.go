@@ -0,0 +1,153 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"time"
+)
+
+// BatchRun 一次收盘后的自选股批量分析
+type BatchRun struct {
+	ID         int64     `json:"id"`
+	TradingDay string    `json:"trading_day"` // YYYY-MM-DD
+	Status     string    `json:"status"`      // running / completed / cancelled
+	Total      int       `json:"total"`
+	Succeeded  int       `json:"succeeded"`
+	Failed     int       `json:"failed"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+}
+
+// BatchResult 批量分析中单只股票的结果。Changed 表示操作建议与该股票上一次成功的批量分析不同
+type BatchResult struct {
+	RunID            int64      `json:"run_id"`
+	Code             string     `json:"code"`
+	Name             string     `json:"name"`
+	Status           string     `json:"status"` // completed / failed
+	Error            string     `json:"error,omitempty"`
+	ReportID         int64      `json:"report_id,omitempty"`
+	Action           llm.Action `json:"action,omitempty"`
+	Confidence       int        `json:"confidence"`
+	PreviousAction   llm.Action `json:"previous_action,omitempty"`
+	PreviousReportID int64      `json:"previous_report_id,omitempty"`
+	Changed          bool       `json:"changed"`
+	FinishedAt       time.Time  `json:"finished_at"`
+}
+
+// BatchStore 批量分析记录存储
+type BatchStore struct {
+	db *sql.DB
+}
+
+func NewBatchStore(db *sql.DB) *BatchStore {
+	return &BatchStore{db: db}
+}
+
+// CreateRun 记录一次开始执行的批量分析
+func (s *BatchStore) CreateRun(tradingDay string, total int) (*BatchRun, error) {
+	run := &BatchRun{TradingDay: tradingDay, Status: "running", Total: total, StartedAt: time.Now()}
+	res, err := s.db.Exec(`INSERT INTO batch_runs (trading_day, status, total, started_at) VALUES (?, ?, ?, ?)`,
+		tradingDay, run.Status, total, run.StartedAt.UTC().Format(timeLayout))
+	if err != nil {
+		return nil, fmt.Errorf("创建批量分析记录失败: %w", err)
+	}
+	run.ID, err = res.LastInsertId()
+	return run, err
+}
+
+// FinishRun 更新批量分析的最终状态与计数
+func (s *BatchStore) FinishRun(run *BatchRun) error {
+	run.FinishedAt = time.Now()
+	_, err := s.db.Exec(`UPDATE batch_runs SET status = ?, succeeded = ?, failed = ?, finished_at = ? WHERE id = ?`,
+		run.Status, run.Succeeded, run.Failed, run.FinishedAt.UTC().Format(timeLayout), run.ID)
+	if err != nil {
+		return fmt.Errorf("更新批量分析记录失败: %w", err)
+	}
+	return nil
+}
+
+// HasRun 指定交易日是否已有完成的批量分析
+func (s *BatchStore) HasRun(tradingDay string) (bool, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM batch_runs WHERE trading_day = ? AND status = 'completed'`, tradingDay).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("查询批量分析记录失败: %w", err)
+	}
+	return n > 0, nil
+}
+
+// SaveResult 保存单只股票的结果。成功的结果与该股票上一次成功的结果比较，标记操作建议是否变化
+func (s *BatchStore) SaveResult(r *BatchResult) error {
+	if r.Status == "completed" {
+		prev, err := latestResult(s.db, r.Code)
+		if err != nil {
+			return err
+		}
+		if prev != nil && prev.RunID != r.RunID {
+			r.PreviousAction = prev.Action
+			r.PreviousReportID = prev.ReportID
+			r.Changed = prev.Action != r.Action
+		}
+	}
+
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO batch_results (
+			run_id, code, name, status, error, report_id, action, confidence,
+			previous_action, previous_report_id, changed, finished_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.RunID, r.Code, r.Name, r.Status, r.Error, r.ReportID, string(r.Action), r.Confidence,
+		string(r.PreviousAction), r.PreviousReportID, r.Changed, r.FinishedAt.UTC().Format(timeLayout))
+	if err != nil {
+		return fmt.Errorf("保存批量分析结果 %s 失败: %w", r.Code, err)
+	}
+	return nil
+}
+
+// ListRuns 最近的批量分析记录，按开始时间倒序
+func (s *BatchStore) ListRuns(limit int) ([]*BatchRun, error) {
+	rows, err := s.db.Query(`SELECT id, trading_day, status, total, succeeded, failed, started_at, finished_at
+		FROM batch_runs ORDER BY id DESC LIMIT ?`, ClampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("查询批量分析记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]*BatchRun, 0)
+	for rows.Next() {
+		var run BatchRun
+		var startedAt, finishedAt string
+		if err := rows.Scan(&run.ID, &run.TradingDay, &run.Status, &run.Total, &run.Succeeded, &run.Failed,
+			&startedAt, &finishedAt); err != nil {
+			return nil, fmt.Errorf("读取批量分析记录失败: %w", err)
+		}
+		run.StartedAt, _ = time.Parse(timeLayout, startedAt)
+		if finishedAt != "" {
+			run.FinishedAt, _ = time.Parse(timeLayout, finishedAt)
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+// latestResult 股票最近一次成功的批量分析结果，没有时返回 nil
+func latestResult(db *sql.DB, code string) (*BatchResult, error) {
+	var r BatchResult
+	var action, previousAction, finishedAt string
+	err := db.QueryRow(`SELECT run_id, code, name, status, report_id, action, confidence,
+			previous_action, previous_report_id, changed, finished_at
+		FROM batch_results WHERE code = ? AND status = 'completed'
+		ORDER BY run_id DESC LIMIT 1`, code).
+		Scan(&r.RunID, &r.Code, &r.Name, &r.Status, &r.ReportID, &action, &r.Confidence,
+			&previousAction, &r.PreviousReportID, &r.Changed, &finishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询批量分析结果失败: %w", err)
+	}
+	r.Action = llm.Action(action)
+	r.PreviousAction = llm.Action(previousAction)
+	r.FinishedAt, _ = time.Parse(timeLayout, finishedAt)
+	return &r, nil
+}
@@ -0,0 +1,194 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// 列表查询的默认条数与最大条数
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ClampLimit 返回列表查询实际使用的条数：非正数取默认值，超过上限按上限截断
+func ClampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultListLimit
+	}
+	return min(limit, MaxListLimit)
+}
+
+// migrations 按顺序执行的建表/变更语句，已执行的版本记录在 PRAGMA user_version 中
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS reports (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		code          TEXT    NOT NULL,
+		name          TEXT    NOT NULL DEFAULT '',
+		status        TEXT    NOT NULL,
+		error         TEXT    NOT NULL DEFAULT '',
+		input_json    TEXT    NOT NULL DEFAULT '{}',
+		outputs_json  TEXT    NOT NULL DEFAULT '{}',
+		decisions_json TEXT   NOT NULL DEFAULT '{}',
+		usage_json    TEXT    NOT NULL DEFAULT '{}',
+		timings_json  TEXT    NOT NULL DEFAULT '{}',
+		providers     TEXT    NOT NULL DEFAULT '',
+		total_tokens  INTEGER NOT NULL DEFAULT 0,
+		total_cost    REAL    NOT NULL DEFAULT 0,
+		final_action  TEXT    NOT NULL DEFAULT '',
+		final_confidence INTEGER NOT NULL DEFAULT 0,
+		started_at    TEXT    NOT NULL,
+		finished_at   TEXT    NOT NULL,
+		duration_ms   INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_reports_code_started ON reports(code, started_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_reports_started ON reports(started_at)`,
+	`ALTER TABLE reports ADD COLUMN fingerprint TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE reports ADD COLUMN expires_at TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX IF NOT EXISTS idx_reports_fingerprint ON reports(fingerprint, expires_at)`,
+	`CREATE TABLE IF NOT EXISTS securities (
+		code       TEXT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		pinyin     TEXT NOT NULL DEFAULT '',
+		exchange   TEXT NOT NULL DEFAULT '',
+		board      TEXT NOT NULL DEFAULT '',
+		industry   TEXT NOT NULL DEFAULT '',
+		updated_at TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS watchlists (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id    TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		UNIQUE (user_id, name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS watchlist_stocks (
+		watchlist_id INTEGER NOT NULL REFERENCES watchlists(id) ON DELETE CASCADE,
+		code         TEXT NOT NULL,
+		name         TEXT NOT NULL DEFAULT '',
+		added_at     TEXT NOT NULL,
+		PRIMARY KEY (watchlist_id, code)
+	)`,
+	`CREATE TABLE IF NOT EXISTS batch_runs (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		trading_day TEXT    NOT NULL,
+		status      TEXT    NOT NULL,
+		total       INTEGER NOT NULL DEFAULT 0,
+		succeeded   INTEGER NOT NULL DEFAULT 0,
+		failed      INTEGER NOT NULL DEFAULT 0,
+		started_at  TEXT    NOT NULL,
+		finished_at TEXT    NOT NULL DEFAULT ''
+	)`,
+	`CREATE TABLE IF NOT EXISTS batch_results (
+		run_id             INTEGER NOT NULL REFERENCES batch_runs(id) ON DELETE CASCADE,
+		code               TEXT    NOT NULL,
+		name               TEXT    NOT NULL DEFAULT '',
+		status             TEXT    NOT NULL,
+		error              TEXT    NOT NULL DEFAULT '',
+		report_id          INTEGER NOT NULL DEFAULT 0,
+		action             TEXT    NOT NULL DEFAULT '',
+		confidence         INTEGER NOT NULL DEFAULT 0,
+		previous_action    TEXT    NOT NULL DEFAULT '',
+		previous_report_id INTEGER NOT NULL DEFAULT 0,
+		changed            INTEGER NOT NULL DEFAULT 0,
+		finished_at        TEXT    NOT NULL,
+		PRIMARY KEY (run_id, code)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_batch_results_code ON batch_results(code, run_id)`,
+	`CREATE TABLE IF NOT EXISTS alert_rules (
+		id                INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id           TEXT    NOT NULL,
+		code              TEXT    NOT NULL,
+		name              TEXT    NOT NULL DEFAULT '',
+		kind              TEXT    NOT NULL,
+		threshold         REAL    NOT NULL DEFAULT 0,
+		from_action       TEXT    NOT NULL DEFAULT '',
+		to_action         TEXT    NOT NULL DEFAULT '',
+		state_json        TEXT    NOT NULL DEFAULT '{}',
+		last_triggered_at TEXT    NOT NULL DEFAULT '',
+		created_at        TEXT    NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_alert_rules_user ON alert_rules(user_id)`,
+	`CREATE TABLE IF NOT EXISTS alert_events (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id      INTEGER NOT NULL,
+		user_id      TEXT    NOT NULL,
+		code         TEXT    NOT NULL,
+		name         TEXT    NOT NULL DEFAULT '',
+		kind         TEXT    NOT NULL,
+		message      TEXT    NOT NULL,
+		value        REAL,
+		triggered_at TEXT    NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_alert_events_user ON alert_events(user_id, id)`,
+	`CREATE TABLE IF NOT EXISTS webhooks (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id    TEXT    NOT NULL,
+		url        TEXT    NOT NULL,
+		format     TEXT    NOT NULL DEFAULT 'json',
+		secret     TEXT    NOT NULL DEFAULT '',
+		created_at TEXT    NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id              INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id        INTEGER NOT NULL REFERENCES alert_events(id) ON DELETE CASCADE,
+		webhook_id      INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+		status          TEXT    NOT NULL,
+		attempts        INTEGER NOT NULL DEFAULT 0,
+		last_error      TEXT    NOT NULL DEFAULT '',
+		next_attempt_at TEXT    NOT NULL,
+		delivered_at    TEXT    NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(status, next_attempt_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(event_id)`,
+	`ALTER TABLE reports ADD COLUMN prompt_version TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE IF NOT EXISTS universe (
+		code       TEXT PRIMARY KEY,
+		data_json  TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	)`,
+}
+
+// Open 打开（必要时创建）SQLite数据库并执行迁移
+func Open(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建数据库目录失败: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+	// SQLite单写者，限制连接数避免 database is locked
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("读取数据库版本失败: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if _, err := db.Exec(migrations[i]); err != nil {
+			return fmt.Errorf("执行数据库迁移 %d 失败: %w", i+1, err)
+		}
+		if _, err := db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			return fmt.Errorf("更新数据库版本失败: %w", err)
+		}
+	}
+	return nil
+}
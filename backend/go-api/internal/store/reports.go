@@ -0,0 +1,315 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/llm"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"strings"
+	"time"
+)
+
+// timeLayout 统一以UTC定宽格式存储时间，保证字符串比较与时间顺序一致
+const timeLayout = "2006-01-02T15:04:05.000Z"
+
+// ErrNotFound 记录不存在
+var ErrNotFound = errors.New("记录不存在")
+
+// Report 一次分析运行的完整报告
+type Report struct {
+	ID              int64                         `json:"id"`
+	Code            string                        `json:"code"`
+	Name            string                        `json:"name"`
+	Status          string                        `json:"status"` // completed / failed / cancelled
+	Error           string                        `json:"error,omitempty"`
+	Input           *model.PythonAnalysisResponse `json:"input,omitempty"`
+	Outputs         map[string]string             `json:"outputs,omitempty"`   // 各步骤输出，按步骤名索引
+	Decisions       map[string]*llm.Decision      `json:"decisions,omitempty"` // 结构化决策，按步骤名索引
+	Usage           json.RawMessage               `json:"usage,omitempty"`
+	StepTimings     map[string]int64              `json:"step_timings_ms,omitempty"`
+	Providers       string                        `json:"providers"` // 本次使用的提供商/模型，逗号分隔
+	TotalTokens     int                           `json:"total_tokens"`
+	TotalCost       float64                       `json:"total_cost"`
+	FinalAction     llm.Action                    `json:"final_action,omitempty"`
+	FinalConfidence int                           `json:"final_confidence"`
+	StartedAt       time.Time                     `json:"started_at"`
+	FinishedAt      time.Time                     `json:"finished_at"`
+	DurationMs      int64                         `json:"duration_ms"`
+	PromptVersion   string                        `json:"prompt_version,omitempty"` // 最终决策步骤的提示词版本
+	Fingerprint     string                        `json:"fingerprint,omitempty"`    // 输入数据+提示词版本+模型的摘要，用于结果缓存
+	ExpiresAt       time.Time                     `json:"-"`                        // 结果缓存失效时间，零值表示不可复用
+}
+
+// ReportFilter 报告查询条件，零值字段不参与过滤
+type ReportFilter struct {
+	Code   string
+	Status string
+	From   time.Time // 含
+	To     time.Time // 不含
+	Limit  int
+	Offset int
+}
+
+// ReportStore 分析报告存储
+type ReportStore struct {
+	db *sql.DB
+}
+
+func NewReportStore(db *sql.DB) *ReportStore {
+	return &ReportStore{db: db}
+}
+
+// Recommendation 报告的操作建议：取最终决策，缺失时取交易员决策
+func (r *Report) Recommendation() (llm.Action, int) {
+	if r.FinalAction != "" {
+		return r.FinalAction, r.FinalConfidence
+	}
+	if trader := r.Decisions[string(llm.StepTrader)]; trader != nil {
+		return trader.Action, trader.Confidence
+	}
+	return "", 0
+}
+
+// Save 保存报告并回填ID
+func (s *ReportStore) Save(r *Report) error {
+	input, err := json.Marshal(r.Input)
+	if err != nil {
+		return fmt.Errorf("序列化输入数据失败: %w", err)
+	}
+	outputs, err := json.Marshal(r.Outputs)
+	if err != nil {
+		return fmt.Errorf("序列化步骤输出失败: %w", err)
+	}
+	decisions, err := json.Marshal(r.Decisions)
+	if err != nil {
+		return fmt.Errorf("序列化决策失败: %w", err)
+	}
+	timings, err := json.Marshal(r.StepTimings)
+	if err != nil {
+		return fmt.Errorf("序列化耗时失败: %w", err)
+	}
+	usage := r.Usage
+	if len(usage) == 0 {
+		usage = json.RawMessage("{}")
+	}
+	expiresAt := ""
+	if !r.ExpiresAt.IsZero() {
+		expiresAt = r.ExpiresAt.UTC().Format(timeLayout)
+	}
+
+	res, err := s.db.Exec(`INSERT INTO reports (
+			code, name, status, error, input_json, outputs_json, decisions_json, usage_json, timings_json,
+			providers, total_tokens, total_cost, final_action, final_confidence, started_at, finished_at, duration_ms,
+			fingerprint, expires_at, prompt_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Code, r.Name, r.Status, r.Error, string(input), string(outputs), string(decisions), string(usage), string(timings),
+		r.Providers, r.TotalTokens, r.TotalCost, string(r.FinalAction), r.FinalConfidence,
+		r.StartedAt.UTC().Format(timeLayout), r.FinishedAt.UTC().Format(timeLayout), r.DurationMs,
+		r.Fingerprint, expiresAt, r.PromptVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("保存报告失败: %w", err)
+	}
+
+	r.ID, err = res.LastInsertId()
+	return err
+}
+
+// Get 按ID读取完整报告
+func (s *ReportStore) Get(id int64) (*Report, error) {
+	row := s.db.QueryRow(`SELECT id, code, name, status, error, input_json, outputs_json, decisions_json, usage_json, timings_json,
+			providers, total_tokens, total_cost, final_action, final_confidence, started_at, finished_at, duration_ms,
+			fingerprint, expires_at, prompt_version
+		FROM reports WHERE id = ?`, id)
+
+	var r Report
+	var input, outputs, decisions, usage, timings, finalAction, startedAt, finishedAt, expiresAt string
+	err := row.Scan(&r.ID, &r.Code, &r.Name, &r.Status, &r.Error, &input, &outputs, &decisions, &usage, &timings,
+		&r.Providers, &r.TotalTokens, &r.TotalCost, &finalAction, &r.FinalConfidence, &startedAt, &finishedAt, &r.DurationMs,
+		&r.Fingerprint, &expiresAt, &r.PromptVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取报告失败: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(input), &r.Input); err != nil {
+		return nil, fmt.Errorf("解析输入数据失败: %w", err)
+	}
+	if err := json.Unmarshal([]byte(outputs), &r.Outputs); err != nil {
+		return nil, fmt.Errorf("解析步骤输出失败: %w", err)
+	}
+	if err := json.Unmarshal([]byte(decisions), &r.Decisions); err != nil {
+		return nil, fmt.Errorf("解析决策失败: %w", err)
+	}
+	if err := json.Unmarshal([]byte(timings), &r.StepTimings); err != nil {
+		return nil, fmt.Errorf("解析耗时失败: %w", err)
+	}
+	r.Usage = json.RawMessage(usage)
+	r.FinalAction = llm.Action(finalAction)
+	r.StartedAt, _ = time.Parse(timeLayout, startedAt)
+	r.FinishedAt, _ = time.Parse(timeLayout, finishedAt)
+	if expiresAt != "" {
+		r.ExpiresAt, _ = time.Parse(timeLayout, expiresAt)
+	}
+
+	return &r, nil
+}
+
+// FindCached 查找指纹相同且在 now 时仍有效的最近一次成功报告，不存在时返回 ErrNotFound
+func (s *ReportStore) FindCached(fingerprint string, now time.Time) (*Report, error) {
+	var id int64
+	err := s.db.QueryRow(`SELECT id FROM reports
+		WHERE fingerprint = ? AND status = 'completed' AND expires_at > ?
+		ORDER BY id DESC LIMIT 1`, fingerprint, now.UTC().Format(timeLayout)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询缓存报告失败: %w", err)
+	}
+	return s.Get(id)
+}
+
+// LatestReport 股票最近一次完成的分析报告摘要，没有时返回 nil
+func (s *ReportStore) LatestReport(code string) (*Report, error) {
+	reports, err := s.List(ReportFilter{Code: code, Status: "completed", Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, nil
+	}
+	return reports[0], nil
+}
+
+// Recommendation 报告中最终决策步骤的操作建议，用于回测
+type Recommendation struct {
+	ReportID      int64      `json:"report_id"`
+	Code          string     `json:"code"`
+	Name          string     `json:"name"`
+	Action        llm.Action `json:"action"`
+	Confidence    int        `json:"confidence"`
+	Model         string     `json:"model"`          // 最终决策步骤的 提供商/模型
+	PromptVersion string     `json:"prompt_version"` // 早期报告未记录时为空
+	StartedAt     time.Time  `json:"started_at"`
+}
+
+// ListRecommendations 按条件查询已完成且有最终决策的报告，按开始时间升序，不分页（忽略 Status/Limit/Offset）
+func (s *ReportStore) ListRecommendations(f ReportFilter) ([]*Recommendation, error) {
+	where := []string{"status = 'completed'", "final_action != ''"}
+	var args []interface{}
+	if f.Code != "" {
+		where = append(where, "code = ?")
+		args = append(args, f.Code)
+	}
+	if !f.From.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, f.From.UTC().Format(timeLayout))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "started_at < ?")
+		args = append(args, f.To.UTC().Format(timeLayout))
+	}
+
+	rows, err := s.db.Query(`SELECT id, code, name, final_action, final_confidence, usage_json, prompt_version, started_at
+		FROM reports WHERE `+strings.Join(where, " AND ")+` ORDER BY started_at, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询报告失败: %w", err)
+	}
+	defer rows.Close()
+
+	recs := make([]*Recommendation, 0)
+	for rows.Next() {
+		var r Recommendation
+		var action, usage, startedAt string
+		if err := rows.Scan(&r.ReportID, &r.Code, &r.Name, &action, &r.Confidence, &usage, &r.PromptVersion, &startedAt); err != nil {
+			return nil, fmt.Errorf("读取报告失败: %w", err)
+		}
+		r.Action = llm.Action(action)
+		r.Model = finalStepModel(usage)
+		r.StartedAt, _ = time.Parse(timeLayout, startedAt)
+		recs = append(recs, &r)
+	}
+	return recs, rows.Err()
+}
+
+// finalStepModel 从用量记录中取最终决策步骤最后一次调用（故障转移后实际生效）的 提供商/模型
+func finalStepModel(usageJSON string) string {
+	var usage struct {
+		Steps []struct {
+			Step     string `json:"step"`
+			Provider string `json:"provider"`
+			Model    string `json:"model"`
+		} `json:"steps"`
+	}
+	if json.Unmarshal([]byte(usageJSON), &usage) != nil {
+		return ""
+	}
+	model := ""
+	for _, s := range usage.Steps {
+		if s.Step == string(llm.StepFinal) {
+			model = s.Provider + "/" + s.Model
+		}
+	}
+	return model
+}
+
+// List 按条件查询报告摘要（不含输入数据和步骤全文），按开始时间倒序
+func (s *ReportStore) List(f ReportFilter) ([]*Report, error) {
+	var where []string
+	var args []interface{}
+	if f.Code != "" {
+		where = append(where, "code = ?")
+		args = append(args, f.Code)
+	}
+	if f.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, f.Status)
+	}
+	if !f.From.IsZero() {
+		where = append(where, "started_at >= ?")
+		args = append(args, f.From.UTC().Format(timeLayout))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "started_at < ?")
+		args = append(args, f.To.UTC().Format(timeLayout))
+	}
+
+	query := `SELECT id, code, name, status, error, decisions_json, providers, total_tokens, total_cost,
+			final_action, final_confidence, started_at, finished_at, duration_ms
+		FROM reports`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	query += " ORDER BY started_at DESC, id DESC LIMIT ? OFFSET ?"
+	args = append(args, ClampLimit(f.Limit), f.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询报告失败: %w", err)
+	}
+	defer rows.Close()
+
+	reports := make([]*Report, 0)
+	for rows.Next() {
+		var r Report
+		var decisions, finalAction, startedAt, finishedAt string
+		if err := rows.Scan(&r.ID, &r.Code, &r.Name, &r.Status, &r.Error, &decisions, &r.Providers, &r.TotalTokens, &r.TotalCost,
+			&finalAction, &r.FinalConfidence, &startedAt, &finishedAt, &r.DurationMs); err != nil {
+			return nil, fmt.Errorf("读取报告失败: %w", err)
+		}
+		if err := json.Unmarshal([]byte(decisions), &r.Decisions); err != nil {
+			return nil, fmt.Errorf("解析决策失败: %w", err)
+		}
+		r.FinalAction = llm.Action(finalAction)
+		r.StartedAt, _ = time.Parse(timeLayout, startedAt)
+		r.FinishedAt, _ = time.Parse(timeLayout, finishedAt)
+		reports = append(reports, &r)
+	}
+	return reports, rows.Err()
+}
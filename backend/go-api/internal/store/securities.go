@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"time"
+)
+
+// SecurityStore 证券主数据的本地缓存，服务重启后无需等待数据源即可搜索
+type SecurityStore struct {
+	db *sql.DB
+}
+
+func NewSecurityStore(db *sql.DB) *SecurityStore {
+	return &SecurityStore{db: db}
+}
+
+// ReplaceAll 以新列表整体替换证券主数据
+func (s *SecurityStore) ReplaceAll(securities []model.Security, updatedAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM securities`); err != nil {
+		return fmt.Errorf("清空证券列表失败: %w", err)
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO securities (code, name, pinyin, exchange, board, industry, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("准备语句失败: %w", err)
+	}
+	defer stmt.Close()
+
+	ts := updatedAt.UTC().Format(timeLayout)
+	for _, sec := range securities {
+		if _, err := stmt.Exec(sec.Code, sec.Name, sec.Pinyin, sec.Exchange, sec.Board, sec.Industry, ts); err != nil {
+			return fmt.Errorf("保存证券 %s 失败: %w", sec.Code, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交证券列表失败: %w", err)
+	}
+	return nil
+}
+
+// List 读取全部证券及最近更新时间，无数据时返回零值时间
+func (s *SecurityStore) List() ([]model.Security, time.Time, error) {
+	rows, err := s.db.Query(`SELECT code, name, pinyin, exchange, board, industry, updated_at FROM securities ORDER BY code`)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("查询证券列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	var securities []model.Security
+	var updatedAt time.Time
+	for rows.Next() {
+		var sec model.Security
+		var ts string
+		if err := rows.Scan(&sec.Code, &sec.Name, &sec.Pinyin, &sec.Exchange, &sec.Board, &sec.Industry, &ts); err != nil {
+			return nil, time.Time{}, fmt.Errorf("读取证券失败: %w", err)
+		}
+		if t, err := time.Parse(timeLayout, ts); err == nil && t.After(updatedAt) {
+			updatedAt = t
+		}
+		securities = append(securities, sec)
+	}
+	return securities, updatedAt, rows.Err()
+}
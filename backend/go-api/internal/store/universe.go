@@ -0,0 +1,78 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"stock-analysis-api/backend/go-api/internal/model"
+	"time"
+)
+
+// UniverseStore 选股用全市场数据的本地缓存，服务重启后无需等待数据源即可选股
+type UniverseStore struct {
+	db *sql.DB
+}
+
+func NewUniverseStore(db *sql.DB) *UniverseStore {
+	return &UniverseStore{db: db}
+}
+
+// ReplaceAll 以新数据整体替换全市场数据
+func (s *UniverseStore) ReplaceAll(stocks []model.PythonAnalysisResponse, updatedAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM universe`); err != nil {
+		return fmt.Errorf("清空全市场数据失败: %w", err)
+	}
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO universe (code, data_json, updated_at) VALUES (?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("准备语句失败: %w", err)
+	}
+	defer stmt.Close()
+
+	ts := updatedAt.UTC().Format(timeLayout)
+	for _, stock := range stocks {
+		data, err := json.Marshal(stock)
+		if err != nil {
+			return fmt.Errorf("序列化 %s 数据失败: %w", stock.Code, err)
+		}
+		if _, err := stmt.Exec(stock.Code, string(data), ts); err != nil {
+			return fmt.Errorf("保存 %s 数据失败: %w", stock.Code, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交全市场数据失败: %w", err)
+	}
+	return nil
+}
+
+// List 读取全市场数据及最近更新时间，无数据时返回零值时间
+func (s *UniverseStore) List() ([]model.PythonAnalysisResponse, time.Time, error) {
+	rows, err := s.db.Query(`SELECT data_json, updated_at FROM universe ORDER BY code`)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("查询全市场数据失败: %w", err)
+	}
+	defer rows.Close()
+
+	var stocks []model.PythonAnalysisResponse
+	var updatedAt time.Time
+	for rows.Next() {
+		var data, ts string
+		if err := rows.Scan(&data, &ts); err != nil {
+			return nil, time.Time{}, fmt.Errorf("读取全市场数据失败: %w", err)
+		}
+		var stock model.PythonAnalysisResponse
+		if err := json.Unmarshal([]byte(data), &stock); err != nil {
+			return nil, time.Time{}, fmt.Errorf("解析全市场数据失败: %w", err)
+		}
+		if t, err := time.Parse(timeLayout, ts); err == nil && t.After(updatedAt) {
+			updatedAt = t
+		}
+		stocks = append(stocks, stock)
+	}
+	return stocks, updatedAt, rows.Err()
+}
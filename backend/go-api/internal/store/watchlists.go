@@ -0,0 +1,301 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrConflict 记录已存在（如同一用户下重名的自选股列表）
+var ErrConflict = errors.New("记录已存在")
+
+// ErrLimitExceeded 超过数量上限（如自选股列表的股票数）
+var ErrLimitExceeded = errors.New("超过数量上限")
+
+// Watchlist 用户的自选股列表
+type Watchlist struct {
+	ID         int64            `json:"id"`
+	UserID     string           `json:"-"`
+	Name       string           `json:"name"`
+	StockCount int              `json:"stock_count"`
+	Stocks     []WatchlistStock `json:"stocks,omitempty"` // 仅详情接口返回
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// WatchlistStock 自选股列表中的股票
+type WatchlistStock struct {
+	Code    string       `json:"code"` // 6位代码
+	Name    string       `json:"name"`
+	AddedAt time.Time    `json:"added_at"`
+	Latest  *BatchResult `json:"latest,omitempty"` // 最近一次成功的批量分析结果
+}
+
+// WatchlistStore 自选股列表存储，所有操作按用户隔离
+type WatchlistStore struct {
+	db *sql.DB
+}
+
+func NewWatchlistStore(db *sql.DB) *WatchlistStore {
+	return &WatchlistStore{db: db}
+}
+
+// Create 创建自选股列表，同一用户下重名时返回 ErrConflict
+func (s *WatchlistStore) Create(userID, name string, stocks []WatchlistStock) (*Watchlist, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(timeLayout)
+	res, err := tx.Exec(`INSERT INTO watchlists (user_id, name, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+		userID, name, now, now)
+	if isUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: 自选股列表 %s", ErrConflict, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("创建自选股列表失败: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("读取自选股列表ID失败: %w", err)
+	}
+	if err := insertStocks(tx, id, stocks); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交自选股列表失败: %w", err)
+	}
+	return s.Get(userID, id)
+}
+
+// List 用户的全部自选股列表（不含股票明细），按创建顺序
+func (s *WatchlistStore) List(userID string) ([]*Watchlist, error) {
+	rows, err := s.db.Query(`SELECT w.id, w.name, w.created_at, w.updated_at, COUNT(ws.code)
+		FROM watchlists w LEFT JOIN watchlist_stocks ws ON ws.watchlist_id = w.id
+		WHERE w.user_id = ?
+		GROUP BY w.id ORDER BY w.id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询自选股列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	lists := make([]*Watchlist, 0)
+	for rows.Next() {
+		w := &Watchlist{UserID: userID}
+		var createdAt, updatedAt string
+		if err := rows.Scan(&w.ID, &w.Name, &createdAt, &updatedAt, &w.StockCount); err != nil {
+			return nil, fmt.Errorf("读取自选股列表失败: %w", err)
+		}
+		w.CreatedAt, _ = time.Parse(timeLayout, createdAt)
+		w.UpdatedAt, _ = time.Parse(timeLayout, updatedAt)
+		lists = append(lists, w)
+	}
+	return lists, rows.Err()
+}
+
+// Get 读取自选股列表及其股票，附带各股票最近一次批量分析结果。不存在或不属于该用户时返回 ErrNotFound
+func (s *WatchlistStore) Get(userID string, id int64) (*Watchlist, error) {
+	w := &Watchlist{ID: id, UserID: userID}
+	var createdAt, updatedAt string
+	err := s.db.QueryRow(`SELECT name, created_at, updated_at FROM watchlists WHERE id = ? AND user_id = ?`, id, userID).
+		Scan(&w.Name, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取自选股列表失败: %w", err)
+	}
+	w.CreatedAt, _ = time.Parse(timeLayout, createdAt)
+	w.UpdatedAt, _ = time.Parse(timeLayout, updatedAt)
+
+	rows, err := s.db.Query(`SELECT code, name, added_at FROM watchlist_stocks WHERE watchlist_id = ? ORDER BY added_at, code`, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询自选股失败: %w", err)
+	}
+	defer rows.Close()
+
+	w.Stocks = make([]WatchlistStock, 0)
+	for rows.Next() {
+		var st WatchlistStock
+		var addedAt string
+		if err := rows.Scan(&st.Code, &st.Name, &addedAt); err != nil {
+			return nil, fmt.Errorf("读取自选股失败: %w", err)
+		}
+		st.AddedAt, _ = time.Parse(timeLayout, addedAt)
+		w.Stocks = append(w.Stocks, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	w.StockCount = len(w.Stocks)
+
+	for i := range w.Stocks {
+		latest, err := latestResult(s.db, w.Stocks[i].Code)
+		if err != nil {
+			return nil, err
+		}
+		w.Stocks[i].Latest = latest
+	}
+	return w, nil
+}
+
+// Update 重命名自选股列表，stocks 不为 nil 时整体替换股票（保留仍在列表中的股票的加入时间）
+func (s *WatchlistStore) Update(userID string, id int64, name string, stocks []WatchlistStock) (*Watchlist, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := touch(tx, userID, id, name); err != nil {
+		return nil, err
+	}
+	if stocks != nil {
+		keep := make([]interface{}, 0, len(stocks)+1)
+		placeholders := make([]string, 0, len(stocks))
+		keep = append(keep, id)
+		for _, st := range stocks {
+			keep = append(keep, st.Code)
+			placeholders = append(placeholders, "?")
+		}
+		query := `DELETE FROM watchlist_stocks WHERE watchlist_id = ?`
+		if len(placeholders) > 0 {
+			query += ` AND code NOT IN (` + strings.Join(placeholders, ", ") + `)`
+		}
+		if _, err := tx.Exec(query, keep...); err != nil {
+			return nil, fmt.Errorf("更新自选股失败: %w", err)
+		}
+		if err := insertStocks(tx, id, stocks); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交自选股列表失败: %w", err)
+	}
+	return s.Get(userID, id)
+}
+
+// Delete 删除自选股列表及其股票
+func (s *WatchlistStore) Delete(userID string, id int64) error {
+	res, err := s.db.Exec(`DELETE FROM watchlists WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("删除自选股列表失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddStock 向自选股列表添加股票，已存在时不重复添加；新增后超过 maxStocks 时返回 ErrLimitExceeded
+func (s *WatchlistStore) AddStock(userID string, id int64, stock WatchlistStock, maxStocks int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// touch 的 UPDATE 先取得写锁，之后的计数与插入不会与并发的添加交错
+	if err := touch(tx, userID, id, ""); err != nil {
+		return err
+	}
+	var exists, count int
+	if err := tx.QueryRow(`SELECT COUNT(*), COALESCE(SUM(code = ?), 0) FROM watchlist_stocks WHERE watchlist_id = ?`,
+		stock.Code, id).Scan(&count, &exists); err != nil {
+		return fmt.Errorf("统计自选股失败: %w", err)
+	}
+	if exists == 0 && count >= maxStocks {
+		return fmt.Errorf("%w: 自选股列表最多%d只股票", ErrLimitExceeded, maxStocks)
+	}
+	if err := insertStocks(tx, id, []WatchlistStock{stock}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交自选股失败: %w", err)
+	}
+	return nil
+}
+
+// RemoveStock 从自选股列表移除股票，股票不在列表中时返回 ErrNotFound
+func (s *WatchlistStore) RemoveStock(userID string, id int64, code string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := touch(tx, userID, id, ""); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`DELETE FROM watchlist_stocks WHERE watchlist_id = ? AND code = ?`, id, code)
+	if err != nil {
+		return fmt.Errorf("移除自选股失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交自选股失败: %w", err)
+	}
+	return nil
+}
+
+// AllStocks 所有用户自选股的并集（按代码去重），供批量分析使用
+func (s *WatchlistStore) AllStocks() ([]WatchlistStock, error) {
+	rows, err := s.db.Query(`SELECT code, MAX(name) FROM watchlist_stocks GROUP BY code ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("查询自选股失败: %w", err)
+	}
+	defer rows.Close()
+
+	var stocks []WatchlistStock
+	for rows.Next() {
+		var st WatchlistStock
+		if err := rows.Scan(&st.Code, &st.Name); err != nil {
+			return nil, fmt.Errorf("读取自选股失败: %w", err)
+		}
+		stocks = append(stocks, st)
+	}
+	return stocks, rows.Err()
+}
+
+// touch 校验列表归属并更新修改时间，name 非空时同时重命名
+func touch(tx *sql.Tx, userID string, id int64, name string) error {
+	now := time.Now().UTC().Format(timeLayout)
+	var res sql.Result
+	var err error
+	if name != "" {
+		res, err = tx.Exec(`UPDATE watchlists SET name = ?, updated_at = ? WHERE id = ? AND user_id = ?`, name, now, id, userID)
+	} else {
+		res, err = tx.Exec(`UPDATE watchlists SET updated_at = ? WHERE id = ? AND user_id = ?`, now, id, userID)
+	}
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: 自选股列表 %s", ErrConflict, name)
+	}
+	if err != nil {
+		return fmt.Errorf("更新自选股列表失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func insertStocks(tx *sql.Tx, watchlistID int64, stocks []WatchlistStock) error {
+	now := time.Now().UTC().Format(timeLayout)
+	for _, st := range stocks {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO watchlist_stocks (watchlist_id, code, name, added_at) VALUES (?, ?, ?, ?)`,
+			watchlistID, st.Code, st.Name, now); err != nil {
+			return fmt.Errorf("添加自选股 %s 失败: %w", st.Code, err)
+		}
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
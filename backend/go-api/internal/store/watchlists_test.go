@@ -0,0 +1,60 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestAddStockEnforcesLimitUnderConcurrency(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	watchlists := NewWatchlistStore(db)
+
+	w, err := watchlists.Create("u1", "自选", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const maxStocks = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- watchlists.AddStock("u1", w.ID, WatchlistStock{Code: fmt.Sprintf("6000%02d", i)}, maxStocks)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	limited := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrLimitExceeded):
+			limited++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	got, err := watchlists.Get("u1", w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.StockCount != maxStocks || limited != 20-maxStocks {
+		t.Fatalf("stock count = %d, limited = %d, want %d and %d", got.StockCount, limited, maxStocks, 20-maxStocks)
+	}
+
+	// 已在列表中的股票重复添加不受上限影响
+	if err := watchlists.AddStock("u1", w.ID, got.Stocks[0], maxStocks); err != nil {
+		t.Fatalf("re-adding existing stock: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Webhook 用户配置的提醒推送地址
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    string    `json:"-"`
+	URL       string    `json:"url"`
+	Format    string    `json:"format"`           // json / wecom / dingtalk
+	Secret    string    `json:"secret,omitempty"` // HMAC签名密钥，仅创建时返回
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery 提醒事件向单个 Webhook 的推送记录
+type WebhookDelivery struct {
+	ID            int64      `json:"id"`
+	EventID       int64      `json:"-"`
+	WebhookID     int64      `json:"webhook_id"`
+	Status        string     `json:"status"` // pending / delivered / failed
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"-"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// PendingDelivery 到期待推送的记录，附带事件与目标 Webhook
+type PendingDelivery struct {
+	WebhookDelivery
+	Event   AlertEvent
+	Webhook Webhook
+}
+
+// WebhookStore Webhook 配置与推送队列存储
+type WebhookStore struct {
+	db *sql.DB
+}
+
+func NewWebhookStore(db *sql.DB) *WebhookStore {
+	return &WebhookStore{db: db}
+}
+
+// Create 创建 Webhook 并回填ID
+func (s *WebhookStore) Create(w *Webhook) error {
+	w.CreatedAt = time.Now()
+	res, err := s.db.Exec(`INSERT INTO webhooks (user_id, url, format, secret, created_at) VALUES (?, ?, ?, ?, ?)`,
+		w.UserID, w.URL, w.Format, w.Secret, w.CreatedAt.UTC().Format(timeLayout))
+	if err != nil {
+		return fmt.Errorf("创建Webhook失败: %w", err)
+	}
+	w.ID, err = res.LastInsertId()
+	return err
+}
+
+// List 用户的 Webhook（不含密钥），按创建顺序
+func (s *WebhookStore) List(userID string) ([]*Webhook, error) {
+	rows, err := s.db.Query(`SELECT id, url, format, created_at FROM webhooks WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询Webhook失败: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]*Webhook, 0)
+	for rows.Next() {
+		w := &Webhook{UserID: userID}
+		var createdAt string
+		if err := rows.Scan(&w.ID, &w.URL, &w.Format, &createdAt); err != nil {
+			return nil, fmt.Errorf("读取Webhook失败: %w", err)
+		}
+		w.CreatedAt, _ = time.Parse(timeLayout, createdAt)
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// Delete 删除 Webhook 及其未完成的推送
+func (s *WebhookStore) Delete(userID string, id int64) error {
+	res, err := s.db.Exec(`DELETE FROM webhooks WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("删除Webhook失败: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Due 到期待推送的记录，按创建顺序
+func (s *WebhookStore) Due(now time.Time, limit int) ([]*PendingDelivery, error) {
+	rows, err := s.db.Query(`SELECT d.id, d.event_id, d.webhook_id, d.attempts,
+			e.rule_id, e.user_id, e.code, e.name, e.kind, e.message, e.value, e.triggered_at,
+			w.url, w.format, w.secret
+		FROM webhook_deliveries d
+		JOIN alert_events e ON e.id = d.event_id
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+		ORDER BY d.id LIMIT ?`, now.UTC().Format(timeLayout), limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询待推送记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []*PendingDelivery
+	for rows.Next() {
+		p := &PendingDelivery{}
+		p.Status = "pending"
+		var value sql.NullFloat64
+		var triggeredAt string
+		if err := rows.Scan(&p.ID, &p.EventID, &p.WebhookID, &p.Attempts,
+			&p.Event.RuleID, &p.Event.UserID, &p.Event.Code, &p.Event.Name, &p.Event.Kind, &p.Event.Message, &value, &triggeredAt,
+			&p.Webhook.URL, &p.Webhook.Format, &p.Webhook.Secret); err != nil {
+			return nil, fmt.Errorf("读取待推送记录失败: %w", err)
+		}
+		p.Event.ID, p.Webhook.ID = p.EventID, p.WebhookID
+		if value.Valid {
+			p.Event.Value = &value.Float64
+		}
+		p.Event.TriggeredAt, _ = time.Parse(timeLayout, triggeredAt)
+		pending = append(pending, p)
+	}
+	return pending, rows.Err()
+}
+
+// MarkDelivered 记录推送成功
+func (s *WebhookStore) MarkDelivered(id int64, attempts int, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE webhook_deliveries SET status = 'delivered', attempts = ?, last_error = '', delivered_at = ? WHERE id = ?`,
+		attempts, at.UTC().Format(timeLayout), id)
+	if err != nil {
+		return fmt.Errorf("更新推送记录失败: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry 记录推送失败并安排下次重试
+func (s *WebhookStore) MarkRetry(id int64, attempts int, lastError string, next time.Time) error {
+	_, err := s.db.Exec(`UPDATE webhook_deliveries SET attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?`,
+		attempts, lastError, next.UTC().Format(timeLayout), id)
+	if err != nil {
+		return fmt.Errorf("更新推送记录失败: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed 记录推送最终失败，不再重试
+func (s *WebhookStore) MarkFailed(id int64, attempts int, lastError string) error {
+	_, err := s.db.Exec(`UPDATE webhook_deliveries SET status = 'failed', attempts = ?, last_error = ? WHERE id = ?`,
+		attempts, lastError, id)
+	if err != nil {
+		return fmt.Errorf("更新推送记录失败: %w", err)
+	}
+	return nil
+}
+
+func queryDeliveries(db *sql.DB, where string, args ...interface{}) ([]*WebhookDelivery, error) {
+	rows, err := db.Query(`SELECT id, event_id, webhook_id, status, attempts, last_error, next_attempt_at, delivered_at
+		FROM webhook_deliveries `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询推送记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		var d WebhookDelivery
+		var nextAttemptAt, deliveredAt string
+		if err := rows.Scan(&d.ID, &d.EventID, &d.WebhookID, &d.Status, &d.Attempts, &d.LastError,
+			&nextAttemptAt, &deliveredAt); err != nil {
+			return nil, fmt.Errorf("读取推送记录失败: %w", err)
+		}
+		d.NextAttemptAt, _ = time.Parse(timeLayout, nextAttemptAt)
+		if deliveredAt != "" {
+			t, _ := time.Parse(timeLayout, deliveredAt)
+			d.DeliveredAt = &t
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,172 @@
+// Package symbol 解析和校验A股证券代码，识别交易所与板块
+package symbol
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalid 无法识别的股票代码或名称
+var ErrInvalid = errors.New("无效的股票代码或名称")
+
+// Exchange 交易所
+type Exchange string
+
+const (
+	SH Exchange = "SH" // 上海证券交易所
+	SZ Exchange = "SZ" // 深圳证券交易所
+	BJ Exchange = "BJ" // 北京证券交易所
+)
+
+// Board 上市板块
+type Board string
+
+const (
+	MainBoard Board = "主板"
+	ChiNext   Board = "创业板"
+	STAR      Board = "科创板"
+	BSE       Board = "北交所"
+)
+
+// Symbol 解析后的证券标识。按名称输入时仅 Name 有值，代码由数据服务查找后再用 Parse 补全
+type Symbol struct {
+	Code     string   `json:"code,omitempty"` // 6位代码
+	Exchange Exchange `json:"exchange,omitempty"`
+	Board    Board    `json:"board,omitempty"`
+	Name     string   `json:"name,omitempty"`
+}
+
+// IsName 是否为按名称输入、尚未解析出代码的标识
+func (s Symbol) IsName() bool {
+	return s.Code == ""
+}
+
+// Query 传给数据服务的查询值：代码或名称
+func (s Symbol) Query() string {
+	if s.IsName() {
+		return s.Name
+	}
+	return s.Code
+}
+
+// String 代码格式为 600519.SH，名称原样返回
+func (s Symbol) String() string {
+	if s.IsName() {
+		return s.Name
+	}
+	return s.Code + "." + string(s.Exchange)
+}
+
+// PriceLimit 日涨跌幅限制（%）：主板10%（ST股5%），创业板、科创板20%，北交所30%。未知板块返回0
+func (s Symbol) PriceLimit() int {
+	switch s.Board {
+	case MainBoard:
+		if strings.Contains(strings.ToUpper(s.Name), "ST") {
+			return 5
+		}
+		return 10
+	case ChiNext, STAR:
+		return 20
+	case BSE:
+		return 30
+	default:
+		return 0
+	}
+}
+
+// prefixes 代码前缀与交易所、板块的对应关系
+var prefixes = []struct {
+	prefix   string
+	exchange Exchange
+	board    Board
+}{
+	{"600", SH, MainBoard}, {"601", SH, MainBoard}, {"603", SH, MainBoard}, {"605", SH, MainBoard},
+	{"688", SH, STAR}, {"689", SH, STAR},
+	{"000", SZ, MainBoard}, {"001", SZ, MainBoard}, {"002", SZ, MainBoard}, {"003", SZ, MainBoard},
+	{"300", SZ, ChiNext}, {"301", SZ, ChiNext},
+	{"43", BJ, BSE}, {"83", BJ, BSE}, {"87", BJ, BSE}, {"92", BJ, BSE},
+}
+
+var (
+	plainCode    = regexp.MustCompile(`^\d{6}$`)
+	prefixedCode = regexp.MustCompile(`^(SH|SZ|BJ)(\d{6})$`)
+	suffixedCode = regexp.MustCompile(`^(\d{6})\.(SH|SS|SZ|BJ)$`)
+)
+
+// Parse 解析用户输入的股票代码或名称。支持 600519、sh600519、SH600519、600519.SH（及 .SS）、
+// 北交所代码和中文名称（如“贵州茅台”“*ST康美”“万科A”）；代码须为沪深北A股，显式交易所须与代码一致
+func Parse(input string) (Symbol, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return Symbol{}, fmt.Errorf("%w: 输入为空", ErrInvalid)
+	}
+
+	upper := strings.ToUpper(s)
+	var code, exchange string
+	switch {
+	case plainCode.MatchString(upper):
+		code = upper
+	case prefixedCode.MatchString(upper):
+		m := prefixedCode.FindStringSubmatch(upper)
+		exchange, code = m[1], m[2]
+	case suffixedCode.MatchString(upper):
+		m := suffixedCode.FindStringSubmatch(upper)
+		code, exchange = m[1], m[2]
+		if exchange == "SS" {
+			exchange = string(SH)
+		}
+	default:
+		return parseName(s)
+	}
+
+	sym, err := FromCode(code)
+	if err != nil {
+		return Symbol{}, err
+	}
+	if exchange != "" && Exchange(exchange) != sym.Exchange {
+		return Symbol{}, fmt.Errorf("%w: %s 属于%s，与指定的交易所 %s 不符", ErrInvalid, code, sym.Exchange, exchange)
+	}
+	return sym, nil
+}
+
+// FromCode 根据6位代码识别交易所和板块
+func FromCode(code string) (Symbol, error) {
+	if !plainCode.MatchString(code) {
+		return Symbol{}, fmt.Errorf("%w: 代码应为6位数字: %s", ErrInvalid, code)
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(code, p.prefix) {
+			return Symbol{Code: code, Exchange: p.exchange, Board: p.board}, nil
+		}
+	}
+	if strings.HasPrefix(code, "900") || strings.HasPrefix(code, "200") {
+		return Symbol{}, fmt.Errorf("%w: 暂不支持B股: %s", ErrInvalid, code)
+	}
+	return Symbol{}, fmt.Errorf("%w: %s 不是沪深北A股代码", ErrInvalid, code)
+}
+
+// parseName 校验股票名称：2-16个字符，须包含汉字，仅允许汉字、字母、数字及ST前缀的“*”
+func parseName(s string) (Symbol, error) {
+	name := strings.TrimPrefix(s, "*")
+	if n := utf8.RuneCountInString(name); n < 2 || n > 16 {
+		return Symbol{}, fmt.Errorf("%w: %s", ErrInvalid, s)
+	}
+	hasHan := false
+	for _, r := range name {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			hasHan = true
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+		default:
+			return Symbol{}, fmt.Errorf("%w: 名称包含非法字符: %s", ErrInvalid, s)
+		}
+	}
+	if !hasHan {
+		return Symbol{}, fmt.Errorf("%w: %s", ErrInvalid, s)
+	}
+	return Symbol{Name: s}, nil
+}
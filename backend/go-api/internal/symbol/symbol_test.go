@@ -0,0 +1,81 @@
+package symbol
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Symbol
+		wantErr bool
+	}{
+		{in: "600519", want: Symbol{Code: "600519", Exchange: SH, Board: MainBoard}},
+		{in: " sh600519 ", want: Symbol{Code: "600519", Exchange: SH, Board: MainBoard}},
+		{in: "SH600519", want: Symbol{Code: "600519", Exchange: SH, Board: MainBoard}},
+		{in: "600519.SH", want: Symbol{Code: "600519", Exchange: SH, Board: MainBoard}},
+		{in: "600519.ss", want: Symbol{Code: "600519", Exchange: SH, Board: MainBoard}},
+		{in: "688981", want: Symbol{Code: "688981", Exchange: SH, Board: STAR}},
+		{in: "sz000001", want: Symbol{Code: "000001", Exchange: SZ, Board: MainBoard}},
+		{in: "300750.SZ", want: Symbol{Code: "300750", Exchange: SZ, Board: ChiNext}},
+		{in: "430047", want: Symbol{Code: "430047", Exchange: BJ, Board: BSE}},
+		{in: "bj830799", want: Symbol{Code: "830799", Exchange: BJ, Board: BSE}},
+		{in: "920002.BJ", want: Symbol{Code: "920002", Exchange: BJ, Board: BSE}},
+		{in: "贵州茅台", want: Symbol{Name: "贵州茅台"}},
+		{in: "*ST康美", want: Symbol{Name: "*ST康美"}},
+		{in: "万科A", want: Symbol{Name: "万科A"}},
+
+		{in: "", wantErr: true},
+		{in: "   ", wantErr: true},
+		{in: "SZ600519", wantErr: true},  // 交易所与代码不符
+		{in: "600519.SZ", wantErr: true}, // 交易所与代码不符
+		{in: "00700.HK", wantErr: true},  // 港股
+		{in: "HK00700", wantErr: true},   // 港股
+		{in: "900901", wantErr: true},    // B股
+		{in: "200002", wantErr: true},    // B股
+		{in: "12345", wantErr: true},     // 位数不足
+		{in: "6005190", wantErr: true},   // 位数过多
+		{in: "700001", wantErr: true},    // 未知前缀
+		{in: "AAPL", wantErr: true},      // 无汉字
+		{in: "茅", wantErr: true},         // 名称过短
+		{in: "茅台;drop", wantErr: true},   // 非法字符
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q) = %+v, %v, want ErrInvalid", tt.in, got, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPriceLimit(t *testing.T) {
+	tests := []struct {
+		sym  Symbol
+		want int
+	}{
+		{sym: Symbol{Board: MainBoard, Name: "贵州茅台"}, want: 10},
+		{sym: Symbol{Board: MainBoard, Name: "*ST康美"}, want: 5},
+		{sym: Symbol{Board: ChiNext}, want: 20},
+		{sym: Symbol{Board: STAR}, want: 20},
+		{sym: Symbol{Board: BSE}, want: 30},
+		{sym: Symbol{Name: "贵州茅台"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.sym.PriceLimit(); got != tt.want {
+			t.Errorf("%+v.PriceLimit() = %d, want %d", tt.sym, got, tt.want)
+		}
+	}
+}
@@ -84,7 +84,7 @@ def analyze():
             "name": stock_data["basic_info"].get("name", ""),
             "basic_info": stock_data["basic_info"],
             "price": stock_data["price"],
-            **analysis,  # financial_metrics + risks
+            **analysis,  # financial_metrics + financial_history + risks
         }
 
         logger.info(f"返回分析结果: input={input_value}, code={code}, name={result['name']}, "
@@ -98,6 +98,58 @@ def analyze():
         logger.error(f"分析失败: {e}", exc_info=True)
         return jsonify({"error": str(e)}), 500
 
+@app.route('/history', methods=['POST'])
+def history():
+    try:
+        data = request.get_json()
+        input_value = data.get('code')
+        start_date = data.get('start_date', '')
+        end_date = data.get('end_date', '')
+
+        if not input_value:
+            return jsonify({"error": "缺少股票代码或名称"}), 400
+        if not (start_date.isdigit() and len(start_date) == 8 and end_date.isdigit() and len(end_date) == 8):
+            return jsonify({"error": "start_date/end_date 格式应为 YYYYMMDD"}), 400
+
+        code = get_stock_code_by_name(input_value)
+        if not code:
+            return jsonify({"error": f"未找到股票: {input_value}"}), 404
+
+        bars = data_fetcher.get_history(code, start_date, end_date)
+        logger.info(f"返回历史K线: code={code}, {start_date}-{end_date}, {len(bars)}根")
+
+        return jsonify({"code": code, "bars": bars})
+
+    except Exception as e:
+        logger.error(f"获取历史K线失败: {e}", exc_info=True)
+        return jsonify({"error": str(e)}), 500
+
+@app.route('/stocks', methods=['GET'])
+def stocks():
+    """证券列表，供 Go 服务构建本地证券主数据。需逐个请求行业板块，耗时较长"""
+    try:
+        securities = data_fetcher.get_security_list()
+        return jsonify({"stocks": securities, "count": len(securities)})
+
+    except Exception as e:
+        logger.error(f"获取证券列表失败: {e}", exc_info=True)
+        return jsonify({"error": str(e)}), 500
+
+@app.route('/universe', methods=['GET'])
+def universe():
+    """全部A股的行情、估值与最新一期财务指标，供 Go 服务选股。需分页拉取业绩报表，耗时较长"""
+    try:
+        stocks = []
+        for item in data_fetcher.get_universe():
+            financial = item.pop("financial_summary")
+            item["financial_metrics"] = financial_analyzer.extract_metrics(financial)
+            stocks.append(item)
+        return jsonify({"stocks": stocks, "count": len(stocks)})
+
+    except Exception as e:
+        logger.error(f"获取全市场数据失败: {e}", exc_info=True)
+        return jsonify({"error": str(e)}), 500
+
 if __name__ == '__main__':
     logger.info(f"Starting Python Analysis Service on port {config.PORT}")
     app.run(host='0.0.0.0', port=config.PORT, debug=config.DEBUG)
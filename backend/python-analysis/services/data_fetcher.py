@@ -1,9 +1,14 @@
 import akshare as ak
 import pandas as pd
 import time
-from typing import Optional, Dict, Any
+from typing import Optional, Dict, Any, List
 from utils.logger import logger
 
+try:
+    from pypinyin import lazy_pinyin, Style
+except ImportError:  # 未安装 pypinyin 时不提供拼音首字母，Go 端仍可按代码和名称搜索
+    lazy_pinyin = None
+
 
 class StockDataFetcher:
     """股票数据获取服务
@@ -11,7 +16,10 @@ class StockDataFetcher:
     使用可靠的 akshare API：
     - stock_individual_info_em: 基本信息（名称、行业、市值）
     - stock_bid_ask_em: 实时行情（最新价、涨跌幅）
-    - stock_financial_abstract_ths: 财务摘要（ROE、负债率、增长率、EPS、每股净资产）
+    - stock_financial_abstract_ths: 财务摘要（ROE、负债率、增长率、EPS、每股净资产），含多期历史
+    - stock_zh_a_hist: 历史日K线（前复权）
+    - stock_info_a_code_name / stock_board_industry_*_em: 证券列表与所属行业
+    - stock_zh_a_spot_em / stock_yjbb_em / stock_zcfz_em: 全部A股的行情估值与最新一期财务指标（选股）
     """
 
     def __init__(self):
@@ -123,6 +131,237 @@ class StockDataFetcher:
             self.logger.error(f"获取财务摘要失败: {e}")
             return {"error": str(e)}
 
+    def _parse_amount(self, value) -> Optional[float]:
+        """解析同花顺金额字符串（如 "862.28亿"、"3521.60万"），统一换算为亿元"""
+        if value is None or value is False:
+            return None
+        s = str(value).strip()
+        scale = 1.0
+        if s.endswith('万亿'):
+            scale, s = 10000.0, s[:-2]
+        elif s.endswith('亿'):
+            s = s[:-1]
+        elif s.endswith('万'):
+            scale, s = 1e-4, s[:-1]
+        else:
+            scale = 1e-8
+        number = self._safe_float(s)
+        return round(number * scale, 4) if number is not None else None
+
+    def _financial_period(self, row) -> Dict[str, Any]:
+        """将财务摘要的一行转换为报告期指标"""
+        return {
+            "report_date": str(row.get("报告期", "")),
+            "revenue": self._parse_amount(row.get("营业总收入")),
+            "net_profit": self._parse_amount(row.get("净利润")),
+            "roe": self._safe_float(row.get("净资产收益率")),
+            "gross_margin": self._safe_float(row.get("销售毛利率")),
+            "net_margin": self._safe_float(row.get("销售净利率")),
+            "debt_ratio": self._safe_float(row.get("资产负债率")),
+            "revenue_growth": self._safe_float(row.get("营业总收入同比增长率")),
+            "profit_growth": self._safe_float(row.get("净利润同比增长率")),
+            "eps": self._safe_float(row.get("基本每股收益")),
+        }
+
+    def get_financial_history(self, code: str, quarters: int = 8, years: int = 5) -> Dict[str, Any]:
+        """获取多期财务摘要（stock_financial_abstract_ths）
+
+        quarterly 为最近 quarters 个报告期（年初至报告期末累计口径），annual 为最近 years 个年度，
+        均按报告期升序排列；TTM、CAGR 等趋势指标由 Go 服务计算
+        """
+        result: Dict[str, Any] = {"quarterly": [], "annual": []}
+        for key, indicator, limit in (("quarterly", "按报告期", quarters), ("annual", "按年度", years)):
+            try:
+                df = self._retry_call(
+                    lambda: ak.stock_financial_abstract_ths(symbol=code, indicator=indicator),
+                    f"获取财务摘要({indicator})"
+                )
+            except Exception as e:
+                self.logger.error(f"获取财务摘要({indicator})失败: {e}")
+                continue
+            if df is None or df.empty:
+                continue
+
+            df_sorted = df.sort_values('报告期', ascending=False).head(limit)
+            periods = [self._financial_period(row) for _, row in df_sorted.iterrows()]
+            if key == "annual":
+                # 年度报告期为 "2024"，统一为年末日期
+                for period in periods:
+                    if len(period["report_date"]) == 4:
+                        period["report_date"] += "-12-31"
+            result[key] = list(reversed(periods))
+            time.sleep(self.request_interval)
+        return result
+
+    def _pinyin_initials(self, name: str) -> str:
+        """名称的拼音首字母（大写），如 贵州茅台 -> GZMT；字母和数字原样保留"""
+        if lazy_pinyin is None:
+            return ""
+        name = name.replace("*", "")
+        return "".join(lazy_pinyin(name, style=Style.FIRST_LETTER, errors="default")).upper()
+
+    def get_industry_map(self) -> Dict[str, str]:
+        """按东方财富行业板块成分股构建 代码->行业 映射，单个板块失败时跳过"""
+        industries: Dict[str, str] = {}
+        try:
+            boards = self._retry_call(ak.stock_board_industry_name_em, "获取行业板块列表")
+        except Exception as e:
+            self.logger.error(f"获取行业板块列表失败: {e}")
+            return industries
+
+        for board in boards["板块名称"].tolist():
+            try:
+                cons = ak.stock_board_industry_cons_em(symbol=board)
+                for code in cons["代码"].astype(str).tolist():
+                    industries.setdefault(code, board)
+            except Exception as e:
+                self.logger.warning(f"获取行业成分股失败: {board}, {e}")
+            time.sleep(self.request_interval / 2)
+        return industries
+
+    def get_security_list(self) -> List[Dict[str, Any]]:
+        """获取沪深北A股证券列表：代码、名称、拼音首字母、所属行业"""
+        df = self._retry_call(ak.stock_info_a_code_name, "获取证券列表")
+        industries = self.get_industry_map()
+
+        securities = []
+        for _, row in df.iterrows():
+            code = str(row["code"]).zfill(6)
+            name = str(row["name"]).replace(" ", "")
+            securities.append({
+                "code": code,
+                "name": name,
+                "pinyin": self._pinyin_initials(name),
+                "industry": industries.get(code, ""),
+            })
+        self.logger.info(f"证券列表: {len(securities)}只, 含行业{sum(1 for s in securities if s['industry'])}只")
+        return securities
+
+    def _recent_report_dates(self, count: int) -> List[str]:
+        """最近 count 个已结束的报告期末（YYYYMMDD），按时间倒序"""
+        today = pd.Timestamp.today()
+        year, quarter = today.year, (today.month - 1) // 3  # 上一个已结束的季度，0 表示上年第四季度
+        dates = []
+        for _ in range(count):
+            if quarter == 0:
+                year, quarter = year - 1, 4
+            dates.append(f"{year}{['0331', '0630', '0930', '1231'][quarter - 1]}")
+            quarter -= 1
+        return dates
+
+    def _latest_financials(self, periods: int = 2) -> Dict[str, Dict[str, Any]]:
+        """按代码索引的最新一期财务摘要（字段与 get_financial_summary 一致，另含 industry）
+
+        取最近 periods 个已有披露的报告期，尚未披露最新一期的股票使用上一期数据
+        """
+        result: Dict[str, Dict[str, Any]] = {}
+        found = 0
+        for date in self._recent_report_dates(4):
+            if found >= periods:
+                break
+            try:
+                yjbb = self._retry_call(lambda: ak.stock_yjbb_em(date=date), f"获取业绩报表({date})")
+            except Exception as e:
+                self.logger.warning(f"获取业绩报表失败: {date}, {e}")
+                continue
+            if yjbb is None or yjbb.empty:
+                continue
+            found += 1
+
+            debt_ratios: Dict[str, Optional[float]] = {}
+            try:
+                zcfz = self._retry_call(lambda: ak.stock_zcfz_em(date=date), f"获取资产负债表({date})")
+                for code, value in zip(zcfz["股票代码"].astype(str), zcfz["资产负债率"]):
+                    debt_ratios[code.zfill(6)] = self._safe_float(value)
+            except Exception as e:
+                self.logger.warning(f"获取资产负债表失败，资产负债率将缺失: {date}, {e}")
+
+            report_date = f"{date[:4]}-{date[4:6]}-{date[6:]}"
+            for _, row in yjbb.iterrows():
+                code = str(row["股票代码"]).zfill(6)
+                if code in result:
+                    continue  # 已有更新一期的数据
+                revenue = self._safe_float(row.get("营业总收入-营业总收入"))
+                profit = self._safe_float(row.get("净利润-净利润"))
+                industry = row.get("所处行业")
+                result[code] = {
+                    "report_date": report_date,
+                    "industry": "" if pd.isna(industry) else str(industry),
+                    "roe": self._safe_float(row.get("净资产收益率")),
+                    "gross_margin": self._safe_float(row.get("销售毛利率")),
+                    "net_margin": round(profit / revenue * 100, 2) if revenue and profit is not None else None,
+                    "debt_ratio": debt_ratios.get(code),
+                    "revenue_growth": self._safe_float(row.get("营业总收入-同比增长")),
+                    "profit_growth": self._safe_float(row.get("净利润-同比增长")),
+                    "eps": self._safe_float(row.get("每股收益")),
+                    "nav_per_share": self._safe_float(row.get("每股净资产")),
+                }
+            time.sleep(self.request_interval)
+        return result
+
+    def get_universe(self) -> List[Dict[str, Any]]:
+        """全部A股的行情、估值与最新一期财务摘要
+
+        行情与估值取自 stock_zh_a_spot_em（PE 为动态市盈率），财务摘要取自业绩报表与资产负债表，
+        结构与 fetch_all 一致（basic_info、price、financial_summary）
+        """
+        spot = self._retry_call(ak.stock_zh_a_spot_em, "获取A股行情")
+        financials = self._latest_financials()
+        today = pd.Timestamp.now().strftime("%Y-%m-%d")
+
+        stocks = []
+        for _, row in spot.iterrows():
+            code = str(row["代码"]).zfill(6)
+            name = str(row["名称"]).replace(" ", "")
+            financial = dict(financials.get(code, {}))
+            stocks.append({
+                "code": code,
+                "name": name,
+                "basic_info": {
+                    "code": code,
+                    "name": name,
+                    "industry": financial.pop("industry", ""),
+                    "market_cap": self._safe_float(row.get("总市值")),
+                    "pe_ttm": self._safe_float(row.get("市盈率-动态")),
+                    "pb": self._safe_float(row.get("市净率")),
+                },
+                "price": {
+                    "latest_price": self._safe_float(row.get("最新价")),
+                    "price_change_pct": self._safe_float(row.get("涨跌幅")),
+                    "date": today,
+                },
+                "financial_summary": financial,
+            })
+        self.logger.info(f"A股全市场数据: {len(stocks)}只, 含财务指标{len(financials)}只")
+        return stocks
+
+    def get_history(self, code: str, start_date: str, end_date: str) -> List[Dict[str, Any]]:
+        """获取前复权日K线（stock_zh_a_hist）
+
+        start_date/end_date 格式为 YYYYMMDD，返回按日期升序的K线列表，成交量单位为股
+        """
+        df = self._retry_call(
+            lambda: ak.stock_zh_a_hist(symbol=code, period="daily",
+                                       start_date=start_date, end_date=end_date, adjust="qfq"),
+            "获取历史K线"
+        )
+        if df is None or df.empty:
+            return []
+
+        bars = []
+        for _, row in df.iterrows():
+            volume = self._safe_float(row.get("成交量"))
+            bars.append({
+                "date": pd.Timestamp(row["日期"]).strftime("%Y-%m-%d"),
+                "open": self._safe_float(row.get("开盘")),
+                "high": self._safe_float(row.get("最高")),
+                "low": self._safe_float(row.get("最低")),
+                "close": self._safe_float(row.get("收盘")),
+                # akshare 成交量单位为手
+                "volume": volume * 100 if volume is not None else None,
+            })
+        return bars
+
     def _compute_pe_pb(self, price: float, eps: Optional[float],
                        nav_per_share: Optional[float],
                        report_date: str) -> Dict[str, Optional[float]]:
@@ -165,7 +404,11 @@ class StockDataFetcher:
         # 3. 财务摘要
         financial = self.get_financial_summary(code)
 
-        # 4. 根据财务数据计算 PE/PB，补充到 basic_info
+        # 4. 多期财务摘要（最近8个报告期、5个年度）
+        time.sleep(self.request_interval)
+        financial_history = self.get_financial_history(code)
+
+        # 5. 根据财务数据计算 PE/PB，补充到 basic_info
         latest_price = price_data.get("latest_price")
         if latest_price and "error" not in financial:
             valuation = self._compute_pe_pb(
@@ -182,6 +425,7 @@ class StockDataFetcher:
             "basic_info": basic_info,
             "price": price_data,
             "financial_summary": financial,
+            "financial_history": financial_history,
         }
 
         self.logger.info(
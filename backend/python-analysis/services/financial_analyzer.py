@@ -1,4 +1,4 @@
-from typing import Dict, Any, List
+from typing import Dict, Any, List, Optional
 from utils.logger import logger
 
 
@@ -11,8 +11,8 @@ class FinancialAnalyzer:
     def __init__(self):
         self.logger = logger
 
-    def _safe_num(self, value, default=0.0) -> float:
-        """安全获取数值"""
+    def _safe_num(self, value, default=None) -> Optional[float]:
+        """安全获取数值，缺失时返回 None（JSON null），由 Go 服务区分缺失与0"""
         if value is None:
             return default
         try:
@@ -23,8 +23,10 @@ class FinancialAnalyzer:
     def extract_metrics(self, financial: Dict[str, Any]) -> Dict[str, Any]:
         """从财务摘要中提取标准化指标"""
         return {
+            "report_date": financial.get("report_date", ""),
             "roe": self._safe_num(financial.get("roe")),
-            "roa": 0.0,  # stock_financial_abstract_ths 不提供 ROA
+            # stock_financial_abstract_ths 不提供 ROA，如实标记为缺失，不做推算
+            "roa": None,
             "gross_margin": self._safe_num(financial.get("gross_margin")),
             "net_margin": self._safe_num(financial.get("net_margin")),
             "debt_ratio": self._safe_num(financial.get("debt_ratio")),
@@ -34,24 +36,24 @@ class FinancialAnalyzer:
         }
 
     def detect_risks(self, metrics: Dict[str, Any], basic_info: Dict[str, Any]) -> List[str]:
-        """检测财务风险"""
+        """检测财务风险，缺失的指标不参与判断"""
         risks = []
 
         # 高负债风险
-        debt_ratio = metrics.get("debt_ratio", 0)
-        if debt_ratio > 70:
+        debt_ratio = metrics.get("debt_ratio")
+        if debt_ratio is not None and debt_ratio > 70:
             risks.append("资产负债率过高")
-        elif debt_ratio > 60:
+        elif debt_ratio is not None and debt_ratio > 60:
             risks.append("资产负债率偏高")
 
         # 低盈利能力
-        roe = metrics.get("roe", 0)
-        if roe and roe < 5:
+        roe = metrics.get("roe")
+        if roe is not None and roe < 5:
             risks.append("净资产收益率较低")
 
         # 流动性风险
-        current_ratio = metrics.get("current_ratio", 0)
-        if current_ratio and current_ratio < 1:
+        current_ratio = metrics.get("current_ratio")
+        if current_ratio is not None and current_ratio < 1:
             risks.append("流动比率低于1，短期偿债能力弱")
 
         # 估值风险
@@ -60,8 +62,8 @@ class FinancialAnalyzer:
             risks.append("市盈率较高，估值偏贵")
 
         # 增长放缓
-        revenue_growth = metrics.get("revenue_growth", 0)
-        profit_growth = metrics.get("profit_growth", 0)
+        revenue_growth = metrics.get("revenue_growth")
+        profit_growth = metrics.get("profit_growth")
         if revenue_growth is not None and revenue_growth < 0:
             risks.append("营业收入同比下降")
         if profit_growth is not None and profit_growth < 0:
@@ -77,7 +79,7 @@ class FinancialAnalyzer:
         basic_info = stock_data.get("basic_info", {})
 
         if "error" in financial:
-            self.logger.warning(f"财务数据获取失败，使用默认值: {financial.get('error')}")
+            self.logger.warning(f"财务数据获取失败，相关指标将标记为缺失: {financial.get('error')}")
             financial = {}
 
         metrics = self.extract_metrics(financial)
@@ -85,5 +87,6 @@ class FinancialAnalyzer:
 
         return {
             "financial_metrics": metrics,
+            "financial_history": stock_data.get("financial_history", {"quarterly": [], "annual": []}),
             "risks": risks,
         }
@@ -36,7 +36,12 @@ export class SSEClient {
           'Content-Type': 'application/json'
         },
         enableChunked: true, // 开启分块传输
-        success: () => {
+        success: (res) => {
+          // 无效代码等请求错误在打开SSE流之前以JSON返回
+          if (res.statusCode >= 400) {
+            reject(new Error(this.errorMessage(res.data) || `HTTP错误: ${res.statusCode}`))
+            return
+          }
           resolve()
         },
         fail: (err) => {
@@ -52,6 +57,18 @@ export class SSEClient {
     })
   }
 
+  // 提取错误响应中的 error 字段
+  errorMessage(data) {
+    try {
+      const body = typeof data === 'string' ? JSON.parse(data)
+        : data instanceof ArrayBuffer ? JSON.parse(this.arrayBufferToString(data))
+        : data
+      return body && body.error
+    } catch (e) {
+      return ''
+    }
+  }
+
   // 解析SSE数据
   parseSSE(text) {
     // 将新数据追加到缓冲区